@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rekki/blackrock/pkg/auth"
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+)
+
+const keyContext = "blackrock-key"
+
+// pixels can not send headers, they pass the api key as ?api_key=
+//
+// an hmac signature covers the method, the path and the body, so a
+// signed body is read whole, at most maxBody bytes, and put back for the
+// handler
+func authMiddleware(keys *auth.Keys, maxBody int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if keys == nil {
+			c.Next()
+			return
+		}
+
+		authorization := c.GetHeader("Authorization")
+		if authorization == "" && c.Query("api_key") != "" {
+			authorization = "Bearer " + c.Query("api_key")
+		}
+		req := auth.Request{Method: c.Request.Method + " " + c.Request.URL.Path}
+		if auth.IsSigned(authorization) {
+			body, ok := readBody(c, maxBody)
+			if !ok {
+				c.Abort()
+				return
+			}
+			req.Body = body
+			c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		key, err := keys.Authenticate(authorization, req, time.Now())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set(keyContext, key)
+		c.Next()
+	}
+}
+
+// reads and closes the body, answers 413 when it is larger than max
+func readBody(c *gin.Context, max int64) ([]byte, bool) {
+	body := c.Request.Body
+	defer body.Close()
+
+	data, err := ioutil.ReadAll(http.MaxBytesReader(c.Writer, body, max))
+	if err != nil {
+		code := http.StatusBadRequest
+		if int64(len(data)) >= max {
+			code = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return data, true
+}
+
+// checks the event type and sets the tenant of the key that authenticated
+// the request, does nothing without authentication
+func stamp(c *gin.Context, envelope *spec.Envelope) error {
+	v, ok := c.Get(keyContext)
+	if !ok {
+		return nil
+	}
+	return v.(*auth.Key).Stamp(envelope.Metadata)
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/oschwald/geoip2-golang"
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"github.com/rekki/blackrock/pkg/ratelimit"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type batchItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+type batchResponse struct {
+	Success  bool             `json:"success"`
+	Accepted int              `json:"accepted"`
+	Rejected []batchItemError `json:"rejected"`
+}
+
+// a batch is decoded item by item, one bad item does not reject the others
+type batch struct {
+	envelopes []*spec.Envelope
+	indexes   []int
+	rejected  []batchItemError
+	size      int
+}
+
+func (b *batch) add(i int, envelope *spec.Envelope, err error) {
+	if err == nil {
+		err = spec.ValidateEnvelope(envelope)
+	}
+	if err != nil {
+		b.rejected = append(b.rejected, batchItemError{Index: i, Error: err.Error()})
+		return
+	}
+	b.envelopes = append(b.envelopes, envelope)
+	b.indexes = append(b.indexes, i)
+}
+
+// moves the envelopes that fail the check to the rejected ones
+func (b *batch) check(check func(envelope *spec.Envelope) error) {
+	envelopes, indexes := []*spec.Envelope{}, []int{}
+	for i, envelope := range b.envelopes {
+		err := check(envelope)
+		if err != nil {
+			b.rejected = append(b.rejected, batchItemError{Index: b.indexes[i], Error: err.Error()})
+			continue
+		}
+		envelopes = append(envelopes, envelope)
+		indexes = append(indexes, b.indexes[i])
+	}
+	b.envelopes, b.indexes = envelopes, indexes
+}
+
+// JsonFrames as a json array or newline delimited json
+func decodeFlattenBatch(data []byte) (*batch, error) {
+	items, err := spec.SplitFrames(data)
+	if err != nil {
+		return nil, err
+	}
+	b := &batch{size: len(items)}
+	for i, item := range items {
+		envelope, err := spec.DecodeAndFlattenItem(item)
+		b.add(i, envelope, err)
+	}
+	return b, nil
+}
+
+// EnvelopeBatch with content-type application/protobuf, otherwise
+// envelopes as a json array or newline delimited json
+func decodeEnvelopeBatch(c *gin.Context, data []byte) (*batch, error) {
+	if c.Request.Header.Get("content-type") == "application/protobuf" {
+		var in spec.EnvelopeBatch
+		err := proto.Unmarshal(data, &in)
+		if err != nil {
+			return nil, err
+		}
+		b := &batch{size: len(in.Envelopes)}
+		for i, envelope := range in.Envelopes {
+			b.add(i, envelope, nil)
+		}
+		return b, nil
+	}
+
+	items, err := spec.SplitFrames(data)
+	if err != nil {
+		return nil, err
+	}
+	b := &batch{size: len(items)}
+	for i, item := range items {
+		envelope := &spec.Envelope{}
+		err := jsonpb.Unmarshal(bytes.NewReader(item), envelope)
+		b.add(i, envelope, err)
+	}
+	return b, nil
+}
+
+// sends the valid envelopes over one stream, the producer reports the
+// rejected ones by their position in the stream
+func pushBatch(enqueue spec.EnqueueClient, b *batch) (*batchResponse, error) {
+	out := &batchResponse{Rejected: append([]batchItemError{}, b.rejected...)}
+	if len(b.envelopes) > 0 {
+		stream, err := enqueue.SayPush(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, envelope := range b.envelopes {
+			err = stream.Send(envelope)
+			if err != nil {
+				_, _ = stream.CloseAndRecv() // close anyway
+				return nil, err
+			}
+		}
+
+		res, err := stream.CloseAndRecv()
+		if err != nil {
+			violations := invalidFields(err)
+			if violations == nil {
+				return nil, err
+			}
+			for _, v := range violations {
+				var sequence int
+				_, serr := fmt.Sscanf(v.Field, "envelope[%d]", &sequence)
+				if serr != nil || sequence >= len(b.indexes) {
+					return nil, err
+				}
+				out.Rejected = append(out.Rejected, batchItemError{Index: b.indexes[sequence], Error: v.Description})
+			}
+		} else {
+			out.Accepted = int(res.Accepted)
+			for _, r := range res.Rejected {
+				if int(r.Sequence) >= len(b.indexes) {
+					continue
+				}
+				out.Rejected = append(out.Rejected, batchItemError{Index: b.indexes[r.Sequence], Error: r.Error})
+			}
+		}
+	}
+
+	sort.Slice(out.Rejected, func(i, j int) bool {
+		return out.Rejected[i].Index < out.Rejected[j].Index
+	})
+	out.Success = len(out.Rejected) == 0
+	return out, nil
+}
+
+// the producer accepts a push when it took some of the envelopes, a push
+// of one envelope fails with the code of its rejection
+func rejectedError(res *spec.Success) error {
+	if len(res.Rejected) == 0 {
+		return nil
+	}
+	r := res.Rejected[0]
+	code := codes.Code(r.Code)
+	if code == codes.OK {
+		code = codes.InvalidArgument
+	}
+	return status.Error(code, r.Error)
+}
+
+// the producer fails the whole push with InvalidArgument when every
+// envelope is invalid, the details say why for each one
+func invalidFields(err error) []*errdetails.BadRequest_FieldViolation {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.InvalidArgument {
+		return nil
+	}
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			return br.FieldViolations
+		}
+	}
+	return nil
+}
+
+// decorate like /push/flatten does, /push/envelope sends the envelopes as
+// they are, the body is read whole so it can be at most maxBody bytes
+func batchHandler(enqueue spec.EnqueueClient, geoip *geoip2.Reader, limiter *ratelimit.Limiter, decorate bool, maxBatch int, maxBody int64, decode func(c *gin.Context, data []byte) (*batch, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, ok := readBody(c, maxBody)
+		if !ok {
+			return
+		}
+
+		b, err := decode(c, data)
+		if err != nil {
+			log.Warnf("[orgrim] error decoding batch, err: %s", err.Error())
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if b.size > maxBatch {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("batch has %d items, max is %d", b.size, maxBatch)})
+			return
+		}
+		// the middleware took one already
+		if b.size > 1 && !take(c, limiter, b.size-1) {
+			return
+		}
+
+		b.check(func(envelope *spec.Envelope) error {
+			return stamp(c, envelope)
+		})
+
+		if decorate {
+			for _, envelope := range b.envelopes {
+				err = spec.Decorate(geoip, c.Request, envelope)
+				if err != nil {
+					log.Warnf("[orgrim] failed to decorate, err: %s", err.Error())
+				}
+			}
+		}
+
+		res, err := pushBatch(enqueue, b)
+		if err != nil {
+			log.Warnf("[orgrim] error sending batch of %d, err: %s", len(b.envelopes), err.Error())
+			c.JSON(pushErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		code := http.StatusOK
+		if res.Accepted == 0 && b.size > 0 {
+			code = http.StatusBadRequest
+		}
+		c.JSON(code, res)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rekki/blackrock/pkg/ratelimit"
+)
+
+func TestBatchBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	enqueue := &fakeEnqueue{}
+	r := gin.New()
+	r.POST("/push/envelope/batch", batchHandler(enqueue, nil, ratelimit.New(0, 0), false, 2, 256, decodeEnvelopeBatch))
+
+	post := func(body string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/push/envelope/batch", strings.NewReader(body))
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	item := `{"metadata":{"event_type":"click","foreign_type":"user","foreign_id":"1"}}`
+	if code := post(item + "\n" + item); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(enqueue.ids()) != 2 {
+		t.Fatalf("expected 2 envelopes, got %v", enqueue.ids())
+	}
+
+	if code := post(item + "\n" + strings.Repeat(" ", 256)); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", code)
+	}
+	if len(enqueue.ids()) != 2 {
+		t.Fatalf("expected nothing pushed, got %v", enqueue.ids())
+	}
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+)
+
+// forwarder sends envelopes to the producer in the background, the
+// envelopes of one batch go over one SayPush stream which is closed when
+// the batch is full or the flush interval passed. Push never blocks, when
+// the buffer is full or the producer is unreachable the envelopes go to
+// the spill file, and they are sent from there once the producer is back.
+// Delivery from the spill file is at least once.
+type forwarder struct {
+	enqueue       spec.EnqueueClient
+	in            chan *spec.Envelope
+	batchSize     int
+	flushInterval time.Duration
+	spill         *spill
+
+	stream  spec.Enqueue_SayPushClient
+	pending []*spec.Envelope
+	healthy bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newForwarder(enqueue spec.EnqueueClient, buffer, batchSize int, flushInterval time.Duration, sp *spill) *forwarder {
+	f := &forwarder{
+		enqueue:       enqueue,
+		in:            make(chan *spec.Envelope, buffer),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		spill:         sp,
+		healthy:       true,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go f.run()
+	return f
+}
+
+func (f *forwarder) Push(envelope *spec.Envelope) {
+	select {
+	case f.in <- envelope:
+	default:
+		f.spillOrDrop("buffer is full", envelope)
+	}
+}
+
+// Close sends what is buffered, or spills it if the producer does not take it
+func (f *forwarder) Close() {
+	close(f.stop)
+	<-f.done
+	f.spill.Close()
+}
+
+func (f *forwarder) run() {
+	defer close(f.done)
+
+	ticker := time.NewTicker(f.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case envelope := <-f.in:
+			f.send(envelope)
+		case <-ticker.C:
+			f.flush()
+			f.replay()
+		case <-f.stop:
+			for {
+				select {
+				case envelope := <-f.in:
+					f.send(envelope)
+				default:
+					f.flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (f *forwarder) send(envelope *spec.Envelope) {
+	if !f.healthy {
+		f.spillOrDrop("producer is unreachable", envelope)
+		return
+	}
+
+	f.pending = append(f.pending, envelope)
+	if f.stream == nil {
+		stream, err := f.enqueue.SayPush(context.Background())
+		if err != nil {
+			f.fail(err)
+			return
+		}
+		f.stream = stream
+	}
+
+	err := f.stream.Send(envelope)
+	if err != nil {
+		f.fail(err)
+		return
+	}
+
+	if len(f.pending) >= f.batchSize {
+		f.flush()
+	}
+}
+
+func (f *forwarder) flush() {
+	if f.stream == nil {
+		f.pending = f.pending[:0]
+		return
+	}
+
+	res, err := f.stream.CloseAndRecv()
+	f.stream = nil
+	if err != nil {
+		f.fail(err)
+		return
+	}
+	f.rejected(res, f.pending)
+	f.pending = f.pending[:0]
+}
+
+// envelopes the queue did not take are spilled, invalid ones are dropped
+func (f *forwarder) rejected(res *spec.Success, sent []*spec.Envelope) {
+	for _, r := range res.Rejected {
+		if codes.Code(r.Code) == codes.Unavailable && r.Sequence < uint64(len(sent)) {
+			f.spillOrDrop("producer failed to store the envelope", sent[r.Sequence])
+			continue
+		}
+		log.Warnf("[orgrim] producer rejected envelope, err: %s", r.Error)
+	}
+}
+
+// spills the current batch, new envelopes are spilled until the next
+// flush interval checks the producer again
+func (f *forwarder) fail(err error) {
+	log.Warnf("[orgrim] failed to forward %d envelopes, spilling, err: %s", len(f.pending), err.Error())
+	if f.stream != nil {
+		_, _ = f.stream.CloseAndRecv()
+		f.stream = nil
+	}
+	f.spillOrDrop("producer is unreachable", f.pending...)
+	f.pending = f.pending[:0]
+	f.healthy = false
+}
+
+func (f *forwarder) spillOrDrop(reason string, envelopes ...*spec.Envelope) {
+	err := f.spill.Write(envelopes...)
+	if err != nil {
+		log.Warnf("[orgrim] %s, dropping %d envelopes, err: %s", reason, len(envelopes), err.Error())
+	}
+}
+
+// sends the spill file batch by batch until it is empty or the producer
+// fails, this is also how an unhealthy forwarder finds out the producer is
+// back
+func (f *forwarder) replay() {
+	if !f.healthy {
+		_, err := f.enqueue.SayHealth(context.Background(), &spec.HealthRequest{})
+		if err != nil {
+			return
+		}
+		log.Infof("[orgrim] producer is reachable again")
+		f.healthy = true
+	}
+
+	for f.replayBatch() {
+		select {
+		case <-f.stop:
+			return
+		default:
+		}
+	}
+}
+
+// returns true when a batch was sent
+func (f *forwarder) replayBatch() bool {
+	envelopes, err := f.spill.Next(f.batchSize)
+	if err != nil {
+		log.Warnf("[orgrim] failed to read the spill file, err: %s", err.Error())
+		return false
+	}
+	if len(envelopes) == 0 {
+		return false
+	}
+
+	stream, err := f.enqueue.SayPush(context.Background())
+	if err == nil {
+		for _, envelope := range envelopes {
+			err = stream.Send(envelope)
+			if err != nil {
+				break
+			}
+		}
+		res, cerr := stream.CloseAndRecv()
+		if err == nil {
+			err = cerr
+		}
+		if err == nil {
+			f.rejected(res, envelopes)
+		}
+	}
+	if err != nil {
+		log.Warnf("[orgrim] failed to replay %d spilled envelopes, err: %s", len(envelopes), err.Error())
+		f.healthy = false
+		return false
+	}
+
+	err = f.spill.Commit()
+	if err != nil {
+		log.Warnf("[orgrim] failed to commit the spill file, err: %s", err.Error())
+		return false
+	}
+	return true
+}
+
+var errNoSpill = errors.New("no spill file")
+var errSpillFull = errors.New("spill file is full")
+
+// spill is an append only file of [length uint32][envelope] records, Next
+// moves it aside to <fn>.replay and reads it batch by batch while new
+// envelopes go to a fresh <fn>
+type spill struct {
+	fn       string
+	maxBytes int64
+
+	w    *os.File
+	size int64
+
+	r       *os.File
+	br      *bufio.Reader
+	offset  int64
+	pending int64
+
+	sync.Mutex
+}
+
+func openSpill(fn string, maxBytes int64) (*spill, error) {
+	s := &spill{fn: fn, maxBytes: maxBytes}
+	if fn == "" {
+		return s, nil
+	}
+
+	w, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	// a torn write before a crash would swallow the records appended after it
+	size, err := completeRecords(fn)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	err = w.Truncate(size)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	s.w = w
+	s.size = size
+
+	// left over from before a restart, replayed from the start
+	r, err := os.Open(fn + ".replay")
+	if err == nil {
+		s.r = r
+		s.br = bufio.NewReader(r)
+	} else if !os.IsNotExist(err) {
+		w.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *spill) Write(envelopes ...*spec.Envelope) error {
+	if len(envelopes) == 0 {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	if s.w == nil {
+		return errNoSpill
+	}
+
+	buf := []byte{}
+	for _, envelope := range envelopes {
+		data, err := proto.Marshal(envelope)
+		if err != nil {
+			return err
+		}
+		header := make([]byte, 4)
+		binary.LittleEndian.PutUint32(header, uint32(len(data)))
+		buf = append(buf, header...)
+		buf = append(buf, data...)
+	}
+
+	if s.maxBytes > 0 && s.size+int64(len(buf)) > s.maxBytes {
+		return errSpillFull
+	}
+
+	n, err := s.w.Write(buf)
+	s.size += int64(n)
+	return err
+}
+
+// Next reads up to n envelopes, they are read again until Commit
+func (s *spill) Next(n int) ([]*spec.Envelope, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.next(n)
+}
+
+func (s *spill) next(n int) ([]*spec.Envelope, error) {
+	if s.w == nil {
+		return nil, nil
+	}
+
+	if s.r == nil {
+		if s.size == 0 {
+			return nil, nil
+		}
+		err := s.rotate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err := s.r.Seek(s.offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	s.br.Reset(s.r)
+
+	out := []*spec.Envelope{}
+	s.pending = 0
+	header := make([]byte, 4)
+	for len(out) < n {
+		_, err := io.ReadFull(s.br, header)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, binary.LittleEndian.Uint32(header))
+		_, err = io.ReadFull(s.br, data)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			// torn write before a crash
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		envelope := &spec.Envelope{}
+		err = proto.Unmarshal(data, envelope)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, envelope)
+		s.pending += int64(4 + len(data))
+	}
+
+	if len(out) == 0 {
+		err := s.finishReplay()
+		if err != nil || s.size == 0 {
+			return nil, err
+		}
+		// spilled while the replay file was read
+		return s.next(n)
+	}
+	return out, nil
+}
+
+// Commit marks what the last Next returned as sent
+func (s *spill) Commit() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.r == nil {
+		return nil
+	}
+	s.offset += s.pending
+	s.pending = 0
+
+	st, err := s.r.Stat()
+	if err != nil {
+		return err
+	}
+	if s.offset >= st.Size() {
+		return s.finishReplay()
+	}
+	return nil
+}
+
+// the length of the complete records at the start of fn
+func completeRecords(fn string) (int64, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	br := bufio.NewReader(f)
+	size := int64(0)
+	header := make([]byte, 4)
+	for {
+		_, err := io.ReadFull(br, header)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return size, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		length := int64(binary.LittleEndian.Uint32(header))
+		if size+4+length > st.Size() {
+			return size, nil
+		}
+		_, err = br.Discard(int(length))
+		if err != nil {
+			return 0, err
+		}
+		size += 4 + length
+	}
+}
+
+func (s *spill) rotate() error {
+	err := s.w.Close()
+	if err != nil {
+		return err
+	}
+	err = os.Rename(s.fn, s.fn+".replay")
+	if err != nil {
+		return err
+	}
+	w, err := os.OpenFile(s.fn, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	s.w = w
+	s.size = 0
+
+	r, err := os.Open(s.fn + ".replay")
+	if err != nil {
+		return err
+	}
+	s.r = r
+	s.br = bufio.NewReader(r)
+	s.offset = 0
+	return nil
+}
+
+func (s *spill) finishReplay() error {
+	if s.r == nil {
+		return nil
+	}
+	_ = s.r.Close()
+	s.r = nil
+	s.br = nil
+	s.offset = 0
+	s.pending = 0
+	return os.Remove(s.fn + ".replay")
+}
+
+func (s *spill) Close() {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.w != nil {
+		_ = s.w.Sync()
+		_ = s.w.Close()
+		s.w = nil
+	}
+	if s.r != nil {
+		_ = s.r.Close()
+		s.r = nil
+	}
+}
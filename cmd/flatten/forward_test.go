@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+var errDown = errors.New("producer is down")
+
+// fakeEnqueue keeps what the closed streams received, failStreams makes
+// the producer go down after that many streams, the queue does not store
+// the foreign ids in unstored
+type fakeEnqueue struct {
+	down        bool
+	failStreams int
+	streams     int
+	received    []*spec.Envelope
+	unstored    map[string]bool
+
+	sync.Mutex
+}
+
+func (f *fakeEnqueue) SayPush(ctx context.Context, opts ...grpc.CallOption) (spec.Enqueue_SayPushClient, error) {
+	f.Lock()
+	defer f.Unlock()
+	if f.down {
+		return nil, errDown
+	}
+	return &fakePushStream{enqueue: f}, nil
+}
+
+func (f *fakeEnqueue) SayHealth(ctx context.Context, in *spec.HealthRequest, opts ...grpc.CallOption) (*spec.Success, error) {
+	f.Lock()
+	defer f.Unlock()
+	if f.down {
+		return nil, errDown
+	}
+	return &spec.Success{}, nil
+}
+
+func (f *fakeEnqueue) setDown(down bool) {
+	f.Lock()
+	defer f.Unlock()
+	f.down = down
+}
+
+func (f *fakeEnqueue) ids() []string {
+	f.Lock()
+	defer f.Unlock()
+	out := []string{}
+	for _, e := range f.received {
+		out = append(out, e.Metadata.ForeignId)
+	}
+	return out
+}
+
+type fakePushStream struct {
+	grpc.ClientStream
+	enqueue *fakeEnqueue
+	sent    []*spec.Envelope
+}
+
+func (s *fakePushStream) Send(e *spec.Envelope) error {
+	s.enqueue.Lock()
+	defer s.enqueue.Unlock()
+	if s.enqueue.down {
+		return errDown
+	}
+	s.sent = append(s.sent, e)
+	return nil
+}
+
+func (s *fakePushStream) CloseAndRecv() (*spec.Success, error) {
+	s.enqueue.Lock()
+	defer s.enqueue.Unlock()
+	if s.enqueue.down {
+		return nil, errDown
+	}
+	out := &spec.Success{}
+	for i, e := range s.sent {
+		if s.enqueue.unstored[e.Metadata.ForeignId] {
+			out.Rejected = append(out.Rejected, &spec.RejectedEnvelope{Sequence: uint64(i), Error: "queue is down", Code: uint32(codes.Unavailable)})
+			continue
+		}
+		s.enqueue.received = append(s.enqueue.received, e)
+		out.Accepted++
+	}
+	s.enqueue.streams++
+	if s.enqueue.streams == s.enqueue.failStreams {
+		s.enqueue.down = true
+	}
+	out.Success = len(out.Rejected) == 0
+	return out, nil
+}
+
+func envelopes(from, to int) []*spec.Envelope {
+	out := []*spec.Envelope{}
+	for i := from; i < to; i++ {
+		out = append(out, &spec.Envelope{Metadata: &spec.Metadata{EventType: "click", ForeignType: "user", ForeignId: fmt.Sprintf("%d", i)}})
+	}
+	return out
+}
+
+func ids(from, to int) string {
+	out := []string{}
+	for _, e := range envelopes(from, to) {
+		out = append(out, e.Metadata.ForeignId)
+	}
+	return fmt.Sprintf("%v", out)
+}
+
+func spillIds(t *testing.T, sp *spill) string {
+	t.Helper()
+	out := []string{}
+	for {
+		batch, err := sp.Next(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(batch) == 0 {
+			return fmt.Sprintf("%v", out)
+		}
+		for _, e := range batch {
+			out = append(out, e.Metadata.ForeignId)
+		}
+		err = sp.Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func tempSpill(t *testing.T, maxBytes int64) (*spill, func()) {
+	t.Helper()
+	root, err := ioutil.TempDir("", "spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp, err := openSpill(path.Join(root, "spill"), maxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sp, func() {
+		sp.Close()
+		os.RemoveAll(root)
+	}
+}
+
+// not started, the tests call send, flush and replay like run does
+func stoppedForwarder(enqueue spec.EnqueueClient, buffer, batchSize int, sp *spill) *forwarder {
+	return &forwarder{
+		enqueue:       enqueue,
+		in:            make(chan *spec.Envelope, buffer),
+		batchSize:     batchSize,
+		flushInterval: time.Hour,
+		spill:         sp,
+		healthy:       true,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+func TestForwarderSpillAndReplay(t *testing.T) {
+	sp, cleanup := tempSpill(t, 0)
+	defer cleanup()
+
+	enqueue := &fakeEnqueue{down: true}
+	f := stoppedForwarder(enqueue, 100, 5, sp)
+	for _, e := range envelopes(0, 12) {
+		f.send(e)
+	}
+	f.flush()
+	if f.healthy || len(enqueue.ids()) != 0 {
+		t.Fatalf("expected the producer to be unhealthy, got %v %v", f.healthy, enqueue.ids())
+	}
+
+	// still down, nothing is read from the spill file
+	f.replay()
+	if f.healthy || len(enqueue.ids()) != 0 {
+		t.Fatalf("expected nothing replayed, got %v", enqueue.ids())
+	}
+
+	// back for one batch, the rest of the replay file and what is spilled
+	// meanwhile are sent once it is back again
+	enqueue.setDown(false)
+	enqueue.failStreams = 1
+	f.replay()
+	if fmt.Sprintf("%v", enqueue.ids()) != ids(0, 5) || f.healthy {
+		t.Fatalf("expected the first batch, got %v", enqueue.ids())
+	}
+	for _, e := range envelopes(12, 14) {
+		f.send(e)
+	}
+
+	enqueue.setDown(false)
+	f.replay()
+	if fmt.Sprintf("%v", enqueue.ids()) != ids(0, 14) {
+		t.Fatalf("expected everything in one replay, got %v", enqueue.ids())
+	}
+	if got := spillIds(t, sp); got != "[]" {
+		t.Fatalf("expected an empty spill file, got %v", got)
+	}
+	_, err := os.Stat(sp.fn + ".replay")
+	if !os.IsNotExist(err) {
+		t.Fatalf("expected the replay file to be removed, got %v", err)
+	}
+
+	// healthy again, envelopes go straight to the producer
+	for _, e := range envelopes(14, 16) {
+		f.send(e)
+	}
+	f.flush()
+	if fmt.Sprintf("%v", enqueue.ids()) != ids(0, 16) {
+		t.Fatalf("unexpected %v", enqueue.ids())
+	}
+}
+
+func TestForwarderSpillsUnstored(t *testing.T) {
+	sp, cleanup := tempSpill(t, 0)
+	defer cleanup()
+
+	enqueue := &fakeEnqueue{unstored: map[string]bool{"1": true, "3": true}}
+	f := stoppedForwarder(enqueue, 100, 5, sp)
+	for _, e := range envelopes(0, 5) {
+		f.send(e)
+	}
+	if fmt.Sprintf("%v", enqueue.ids()) != "[0 2 4]" {
+		t.Fatalf("unexpected %v", enqueue.ids())
+	}
+
+	// the queue is back, the spilled ones are sent again
+	enqueue.unstored = nil
+	f.replay()
+	if fmt.Sprintf("%v", enqueue.ids()) != "[0 2 4 1 3]" {
+		t.Fatalf("expected the envelopes the queue did not store to be replayed, got %v", enqueue.ids())
+	}
+}
+
+func TestForwarderBufferFull(t *testing.T) {
+	sp, cleanup := tempSpill(t, 0)
+	defer cleanup()
+
+	enqueue := &fakeEnqueue{}
+	f := stoppedForwarder(enqueue, 2, 5, sp)
+	for _, e := range envelopes(0, 5) {
+		f.Push(e)
+	}
+	if len(f.in) != 2 {
+		t.Fatalf("expected 2 buffered envelopes, got %d", len(f.in))
+	}
+	if got := spillIds(t, sp); got != ids(2, 5) {
+		t.Fatalf("expected the envelopes over the buffer to be spilled, got %v", got)
+	}
+
+	// without a spill file they are dropped, Push still does not block
+	none, err := openSpill("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = stoppedForwarder(enqueue, 1, 5, none)
+	for _, e := range envelopes(0, 3) {
+		f.Push(e)
+	}
+	if len(f.in) != 1 {
+		t.Fatalf("expected 1 buffered envelope, got %d", len(f.in))
+	}
+
+	// a full spill file drops them too
+	small, cleanupSmall := tempSpill(t, 30)
+	defer cleanupSmall()
+	f = stoppedForwarder(enqueue, 0, 5, small)
+	for _, e := range envelopes(0, 3) {
+		f.Push(e)
+	}
+	if got := spillIds(t, small); got != ids(0, 1) {
+		t.Fatalf("expected only what fits in the spill file, got %v", got)
+	}
+}
+
+func TestSpillTruncatedRecord(t *testing.T) {
+	sp, cleanup := tempSpill(t, 0)
+	defer cleanup()
+
+	err := sp.Write(envelopes(0, 3)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp.Close()
+
+	// a torn write, the header says 100 bytes but only 10 made it
+	torn := append([]byte{100, 0, 0, 0}, make([]byte, 10)...)
+	fd, err := os.OpenFile(sp.fn, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fd.Write(torn)
+	fd.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sp, err = openSpill(sp.fn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sp.Write(envelopes(3, 4)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := spillIds(t, sp); got != ids(0, 4) {
+		t.Fatalf("expected the torn record to be dropped, got %v", got)
+	}
+
+	// torn in the replay file left over from before a restart
+	err = sp.Write(envelopes(4, 6)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp.Close()
+	err = os.Rename(sp.fn, sp.fn+".replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd, err = os.OpenFile(sp.fn+".replay", os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fd.Write(torn)
+	fd.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp, err = openSpill(sp.fn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sp.Close()
+	if got := spillIds(t, sp); got != ids(4, 6) {
+		t.Fatalf("expected the records before the torn one, got %v", got)
+	}
+}
+
+func TestForwarderCloseDrains(t *testing.T) {
+	sp, cleanup := tempSpill(t, 0)
+	defer cleanup()
+
+	enqueue := &fakeEnqueue{}
+	f := newForwarder(enqueue, 100, 50, time.Hour, sp)
+	for _, e := range envelopes(0, 7) {
+		f.Push(e)
+	}
+	f.Close()
+	if fmt.Sprintf("%v", enqueue.ids()) != ids(0, 7) {
+		t.Fatalf("expected the buffer to be sent on close, got %v", enqueue.ids())
+	}
+
+	// the producer is down, the buffer goes to the spill file
+	sp, err := openSpill(sp.fn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enqueue = &fakeEnqueue{down: true}
+	f = newForwarder(enqueue, 100, 50, time.Hour, sp)
+	for _, e := range envelopes(7, 10) {
+		f.Push(e)
+	}
+	f.Close()
+
+	sp, err = openSpill(sp.fn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sp.Close()
+	if got := spillIds(t, sp); got != ids(7, 10) {
+		t.Fatalf("expected the buffer to be spilled on close, got %v", got)
+	}
+}
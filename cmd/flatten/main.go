@@ -5,13 +5,18 @@ import (
 	"flag"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/proto"
 	"github.com/oschwald/geoip2-golang"
+	"github.com/rekki/blackrock/pkg/auth"
 	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"github.com/rekki/blackrock/pkg/ratelimit"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 
@@ -39,6 +44,20 @@ func UnmarshalAndClose(c *gin.Context, into proto.Message) error {
 func main() {
 	var bind = flag.String("bind", ":9001", "bind to")
 	var remote = flag.String("producer-grpc", ":8001", "connect to producer grpc")
+	var maxBatch = flag.Int("max-batch", 10000, "max items in one batch push")
+	var maxItem = flag.Int64("max-item-kb", 64, "max size of one pushed item in kilobytes, a body can be -max-batch items")
+	var async = flag.Bool("async", false, "answer /push/envelope and /push/flatten before the producer has the envelope, the pixel is always async")
+	var buffer = flag.Int("buffer", 10000, "envelopes buffered in memory for async forwarding")
+	var flushSize = flag.Int("flush-size", 500, "async envelopes sent per push stream")
+	var flushInterval = flag.Int("flush-interval-ms", 1000, "close the async push stream after # milliseconds")
+	var spillFile = flag.String("spill", "", "file for the async envelopes the producer does not take, empty drops them")
+	var spillMax = flag.Int64("spill-max-mb", 1024, "max spill file size in megabytes")
+	var keysFile = flag.String("keys", "", "json file with the api keys allowed to push, nothing means no authentication")
+	var producerApiKey = flag.String("producer-api-key", "", "api key for a producer started with -keys")
+	var rate = flag.Float64("rate", 0, "envelopes per second per api key, or per ip without -keys, 0 for no limit")
+	var burst = flag.Int("burst", 0, "envelopes a client can push at once above -rate, 0 means -rate")
+	var trustedProxies = flag.String("trusted-proxies", "", "csv list of ips and cidrs of the proxies in front, only their x-forwarded-for is used to rate limit by ip")
+	var corsOrigins = flag.String("cors-origins", "", "csv list of allowed origins, nothing means all")
 	var geoipFile = flag.String("geoip", "", "path to https://dev.maxmind.com/geoip/geoip2/geolite2/ file")
 	flag.Parse()
 
@@ -54,9 +73,36 @@ func main() {
 
 	r := gin.Default()
 	r.Use(gin.Recovery())
-	r.Use(cors.Default())
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AddAllowHeaders("Authorization")
+	if *corsOrigins != "" {
+		corsConfig.AllowOrigins = strings.Split(*corsOrigins, ",")
+	} else {
+		corsConfig.AllowAllOrigins = true
+	}
+	r.Use(cors.New(corsConfig))
 
-	conn, err := grpc.Dial(*remote, grpc.WithInsecure())
+	var keys *auth.Keys
+	if *keysFile != "" {
+		keys, err = auth.Load(*keysFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	maxBody := int64(*maxBatch) * *maxItem * 1024
+	authed := authMiddleware(keys, maxBody)
+	limiter := ratelimit.New(*rate, *burst)
+	ratelimit.TrustedProxies, err = ratelimit.ParseProxies(*trustedProxies)
+	if err != nil {
+		log.Fatal(err)
+	}
+	limited := rateLimitMiddleware(limiter)
+
+	dialOpts := []grpc.DialOption{grpc.WithInsecure()}
+	if *producerApiKey != "" {
+		dialOpts = append(dialOpts, auth.WithAPIKey(*producerApiKey))
+	}
+	conn, err := grpc.Dial(*remote, dialOpts...)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -64,6 +110,21 @@ func main() {
 
 	enqueue := spec.NewEnqueueClient(conn)
 
+	sp, err := openSpill(*spillFile, *spillMax*1024*1024)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fwd := newForwarder(enqueue, *buffer, *flushSize, time.Duration(*flushInterval)*time.Millisecond, sp)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		log.Warnf("[orgrim] flushing the buffered envelopes...")
+		fwd.Close()
+		os.Exit(0)
+	}()
+
 	r.GET("/health", func(c *gin.Context) {
 		_, err := enqueue.SayHealth(context.Background(), &spec.HealthRequest{})
 		if err != nil {
@@ -73,7 +134,7 @@ func main() {
 		}
 	})
 
-	r.GET("/png/:event_type/:foreign_type/:foreign_id/*extra", func(c *gin.Context) {
+	r.GET("/png/:event_type/:foreign_type/:foreign_id/*extra", authed, limited, func(c *gin.Context) {
 		envelope := &spec.Envelope{
 			Metadata: &spec.Metadata{
 				CreatedAtNs: time.Now().UnixNano(),
@@ -95,27 +156,18 @@ func main() {
 			}
 			envelope.Metadata.Search = append(envelope.Metadata.Search, spec.KV{Key: kv[0], Value: kv[1]})
 		}
-		err = spec.Decorate(geoip, c.Request, envelope)
+		err := spec.Decorate(geoip, c.Request, envelope)
 		if err != nil {
 			log.Warnf("[orgrim] failed to decorate, err: %s", err.Error())
 		}
-		err = spec.ValidateEnvelope(envelope)
+		err = stamp(c, envelope)
+		if err == nil {
+			err = spec.ValidateEnvelope(envelope)
+		}
 		if err != nil {
 			log.Warnf("[orgrim] invalid input, err: %s", err.Error())
 		} else {
-			stream, err := enqueue.SayPush(context.Background())
-			if err != nil {
-				log.Warnf("[orgrim] error sending message, metadata %v, err: %s", envelope.Metadata, err.Error())
-			} else {
-				err := stream.Send(envelope)
-				if err != nil {
-					log.Warnf("[orgrim] error sending message, metadata %v, err: %s", envelope.Metadata, err.Error())
-				}
-				_, err = stream.CloseAndRecv()
-				if err != nil {
-					log.Warnf("[orgrim] error sending message, metadata %v, err: %s", envelope.Metadata, err.Error())
-				}
-			}
+			fwd.Push(envelope)
 		}
 
 		c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
@@ -124,7 +176,7 @@ func main() {
 		c.Data(200, "image/png", []byte{137, 80, 78, 71, 13, 10, 26, 10, 0, 0, 0, 13, 73, 72, 68, 82, 0, 0, 0, 1, 0, 0, 0, 1, 8, 6, 0, 0, 0, 31, 21, 196, 137, 0, 0, 0, 9, 112, 72, 89, 115, 0, 0, 11, 19, 0, 0, 11, 19, 1, 0, 154, 156, 24, 0, 0, 0, 1, 115, 82, 71, 66, 0, 174, 206, 28, 233, 0, 0, 0, 4, 103, 65, 77, 65, 0, 0, 177, 143, 11, 252, 97, 5, 0, 0, 0, 16, 73, 68, 65, 84, 120, 1, 1, 5, 0, 250, 255, 0, 0, 0, 0, 0, 0, 5, 0, 1, 100, 120, 149, 56, 0, 0, 0, 0, 73, 69, 78, 68, 174, 66, 96, 130})
 	})
 
-	r.POST("/push/envelope", func(c *gin.Context) {
+	r.POST("/push/envelope", authed, limited, func(c *gin.Context) {
 		var envelope spec.Envelope
 		err := UnmarshalAndClose(c, &envelope)
 		if err != nil {
@@ -132,11 +184,27 @@ func main() {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		err = stamp(c, &envelope)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
+		if *async {
+			err = spec.ValidateEnvelope(&envelope)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			fwd.Push(&envelope)
+			c.JSON(200, gin.H{"success": true})
+			return
+		}
 
 		stream, err := enqueue.SayPush(context.Background())
 		if err != nil {
 			log.Warnf("[orgrim] error sending message, metadata %v, err: %s", envelope.Metadata, err.Error())
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(pushErrorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
 		err = stream.Send(&envelope)
@@ -145,20 +213,23 @@ func main() {
 			_, _ = stream.CloseAndRecv() // close anyway
 
 			log.Warnf("[orgrim] error sending message, metadata %v, err: %s", envelope.Metadata, err.Error())
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(pushErrorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
-		_, err = stream.CloseAndRecv()
+		res, err := stream.CloseAndRecv()
+		if err == nil {
+			err = rejectedError(res)
+		}
 		if err != nil {
 			log.Warnf("[orgrim] error sending message, metadata %v, err: %s", envelope.Metadata, err.Error())
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(pushErrorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
 
 		c.JSON(200, gin.H{"success": true})
 	})
 
-	r.POST("/push/flatten", func(c *gin.Context) {
+	r.POST("/push/flatten", authed, limited, func(c *gin.Context) {
 		body := c.Request.Body
 		defer body.Close()
 
@@ -168,32 +239,57 @@ func main() {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		err = stamp(c, converted)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		err = spec.Decorate(geoip, c.Request, converted)
 		if err != nil {
 			log.Warnf("[orgrim] failed to decorate, err: %s", err.Error())
 		}
 
+		if *async {
+			err = spec.ValidateEnvelope(converted)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			fwd.Push(converted)
+			c.JSON(200, gin.H{"success": true})
+			return
+		}
+
 		stream, err := enqueue.SayPush(context.Background())
 		if err != nil {
 			log.Warnf("[orgrim] error sending message, metadata %v, err: %s", converted.Metadata, err.Error())
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(pushErrorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
 
 		err = stream.Send(converted)
 		if err != nil {
 			log.Warnf("[orgrim] error sending message, metadata %v, err: %s", converted.Metadata, err.Error())
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(pushErrorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
-		_, err = stream.CloseAndRecv()
+		res, err := stream.CloseAndRecv()
+		if err == nil {
+			err = rejectedError(res)
+		}
 		if err != nil {
 			log.Warnf("[orgrim] error sending message, metadata %v, err: %s", converted.Metadata, err.Error())
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(pushErrorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
 
 		c.JSON(200, gin.H{"success": true})
 	})
+
+	r.POST("/push/envelope/batch", authed, limited, batchHandler(enqueue, geoip, limiter, false, *maxBatch, maxBody, decodeEnvelopeBatch))
+	r.POST("/push/flatten/batch", authed, limited, batchHandler(enqueue, geoip, limiter, true, *maxBatch, maxBody, func(c *gin.Context, data []byte) (*batch, error) {
+		return decodeFlattenBatch(data)
+	}))
+
 	log.Panic(r.Run(*bind))
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rekki/blackrock/pkg/auth"
+	"github.com/rekki/blackrock/pkg/ratelimit"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// requests are limited per api key, or per ip without authentication,
+// gin's ClientIP trusts the x-forwarded-for of anyone
+func client(c *gin.Context) string {
+	if v, ok := c.Get(keyContext); ok {
+		return "key:" + v.(*auth.Key).Name()
+	}
+	return "ip:" + ratelimit.TrustedProxies.RemoteIP(c.Request.RemoteAddr, c.Request.Header.Values("X-Forwarded-For"))
+}
+
+// take n tokens, or answer 429 with Retry-After and abort
+func take(c *gin.Context, limiter *ratelimit.Limiter, n int) bool {
+	ok, retry := limiter.AllowN(client(c), n, time.Now())
+	if ok {
+		return true
+	}
+	if retry > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("rate limit exceeded, retry in %s", retry.Round(time.Millisecond))})
+	} else {
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("rate limit exceeded, %d items is more than the burst", n)})
+	}
+	return false
+}
+
+// one token per request, batches take the rest once decoded
+func rateLimitMiddleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if take(c, limiter, 1) {
+			c.Next()
+		}
+	}
+}
+
+// the producer has its own limits, a client over them gets a 429 as well,
+// envelopes the queue did not take get a 503 to push them again later
+func pushErrorStatus(err error) int {
+	switch status.Code(err) {
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
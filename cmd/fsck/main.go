@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/rekki/blackrock/pkg/index"
+	. "github.com/rekki/blackrock/pkg/logger"
+)
+
+func main() {
+	var proot = flag.String("root", "/blackrock/data-topic", "root directory for the files root/topic")
+	var segmentStep = flag.Int("segment-step", 3600, "segment step")
+	var segment = flag.String("segment", "", "check only this segment id, e.g. 438000")
+	var tenant = flag.String("tenant", "", "check only the segments of this tenant, default is every tenant and the envelopes without one")
+	var rebuild = flag.Bool("rebuild", false, "rebuild the inverted index of broken segments from the forward index, do not use while the search server is running")
+	var compact = flag.Bool("compact", false, "rewrite the segments without their forgotten documents, do not use while the search server is running")
+	var pwhitelist = flag.String("whitelist", "", "csv list of indexable search terms used with -rebuild and -compact, nothing means all")
+	var logLevel = flag.Int("log-level", 0, "log level")
+	flag.Parse()
+
+	LogInit(*logLevel)
+
+	whitelist := map[string]bool{}
+	for _, v := range strings.Split(*pwhitelist, ",") {
+		if len(v) > 0 {
+			whitelist[v] = true
+		}
+	}
+
+	// root/<step> has the envelopes without tenant, root/<tenant>/<step>
+	// the others
+	roots := []string{}
+	if *tenant != "" {
+		if !index.ValidTenant(*tenant) {
+			Log.Fatal(index.ErrBadTenant)
+		}
+		roots = append(roots, path.Join(*proot, *tenant))
+	} else {
+		roots = append(roots, *proot)
+		dirs, err := ioutil.ReadDir(*proot)
+		if err != nil {
+			Log.Fatal(err)
+		}
+		for _, d := range dirs {
+			if d.IsDir() && index.ValidTenant(d.Name()) {
+				roots = append(roots, path.Join(*proot, d.Name()))
+			}
+		}
+	}
+
+	segments := []string{}
+	for _, r := range roots {
+		root := path.Join(r, fmt.Sprintf("%d", *segmentStep))
+		if *segment != "" {
+			if _, err := os.Stat(path.Join(root, *segment)); err == nil || len(roots) == 1 {
+				segments = append(segments, path.Join(root, *segment))
+			}
+			continue
+		}
+		dirs, err := ioutil.ReadDir(root)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			Log.Fatal(err)
+		}
+		for _, d := range dirs {
+			if _, err := strconv.ParseInt(d.Name(), 10, 64); err != nil || !d.IsDir() {
+				continue
+			}
+			segments = append(segments, path.Join(root, d.Name()))
+		}
+	}
+
+	broken := 0
+	for _, p := range segments {
+		if *compact {
+			n, err := index.CompactSegment(p, whitelist)
+			if err != nil {
+				Log.Warnf("%s: failed to compact, err: %s", p, err.Error())
+				broken++
+				continue
+			}
+			if n > 0 {
+				fmt.Printf("COMPACTED %s removed %d documents\n", p, n)
+			}
+		}
+
+		report, err := index.CheckSegment(p)
+		if err != nil {
+			Log.Warnf("%s: failed to check, err: %s", p, err.Error())
+			broken++
+			continue
+		}
+
+		if report.OK() {
+			fmt.Printf("OK %s\n", report)
+			continue
+		}
+		fmt.Printf("BROKEN %s\n", report)
+
+		if !*rebuild {
+			broken++
+			continue
+		}
+
+		n, err := index.RebuildSegment(p, whitelist)
+		if err != nil {
+			Log.Warnf("%s: failed to rebuild, err: %s", p, err.Error())
+			broken++
+			continue
+		}
+
+		report, err = index.CheckSegment(p)
+		if err != nil || !report.OK() {
+			Log.Warnf("%s: still broken after rebuilding %d documents", p, n)
+			broken++
+			continue
+		}
+		fmt.Printf("REBUILT %s\n", report)
+	}
+
+	if broken > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/gogo/protobuf/proto"
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"github.com/rekki/blackrock/pkg/logger"
+	"github.com/rekki/blackrock/pkg/queue"
+	"github.com/rekki/blackrock/pkg/rules"
+	pen "github.com/rekki/go-pen"
+)
+
+func consumeEvents(root string, pr *PartitionReader, maxInflight int) error {
+	l := logger.Log
+	fileLock := flock.New(path.Join(root, fmt.Sprintf("partition_%d.lock", pr.Partition)))
+	err := fileLock.Lock()
+	if err != nil {
+		return err
+	}
+	defer fileLock.Close() // also unlocks
+
+	ow, err := pen.NewOffsetWriter(path.Join(root, fmt.Sprintf("partition_%d.offset", pr.Partition)))
+	if err != nil {
+		return err
+	}
+	defer ow.Close() // close also syncs
+
+	offset := ow.ReadOrDefault(queue.FirstOffset)
+
+	if offset != queue.FirstOffset {
+		offset++ // start from the next one, as we already stored the current one
+	}
+	err = pr.Reader.SetOffset(offset)
+	if err != nil {
+		return err
+	}
+
+	l.Infof("starting partition: %d at offset: %d", pr.Partition, offset)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// one stream per destination for the whole partition, each search node
+	// acknowledges the highest offset it fsynced, the stored offset is the
+	// highest one with everything before it acknowledged or dropped
+	tracker := newOffsetTracker(ow, pr.Partition, maxInflight, pr.DeadLetter)
+	ackError := make(chan error, 1)
+	streams := map[string]spec.Search_SayPushAckedClient{}
+	open := func(route string) (spec.Search_SayPushAckedClient, error) {
+		if stream, ok := streams[route]; ok {
+			return stream, nil
+		}
+		si := pr.Search
+		if route != "" {
+			si = pr.Clusters[route]
+		}
+		stream, err := si.SayPushAcked(ctx)
+		if err != nil {
+			return nil, err
+		}
+		streams[route] = stream
+		go func() {
+			err := receiveAcks(stream, tracker, route)
+			select {
+			case ackError <- err:
+			default:
+			}
+			cancel()
+		}()
+		return stream, nil
+	}
+
+	for {
+		m, err := pr.Reader.Fetch(ctx)
+		if err != nil {
+			select {
+			case ackErr := <-ackError:
+				return ackErr
+			default:
+				return err
+			}
+		}
+
+		select {
+		case tracker.slots <- true:
+		case ackErr := <-ackError:
+			return ackErr
+		}
+
+		envelope := spec.Envelope{}
+		err = proto.Unmarshal(m.Value, &envelope)
+		if err != nil {
+			err = tracker.reject(m, fmt.Errorf("failed to unmarshal: %s", err.Error()))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		keep, route := true, ""
+		if envelope.Metadata != nil {
+			envelope.Metadata.Id = uint64(m.Partition)<<56 | uint64(m.Offset)
+			if pr.Rules != nil {
+				keep, route, err = pr.Rules.Apply(envelope.Metadata)
+				if err != nil {
+					err = tracker.reject(m, fmt.Errorf("failed to apply the rules: %s", err.Error()))
+					if err != nil {
+						return err
+					}
+					continue
+				}
+			}
+		}
+
+		if !keep {
+			err = tracker.drop(m.Offset)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		stream, err := open(route)
+		if err != nil {
+			return err
+		}
+		tracker.send(m, route)
+
+		err = stream.Send(&spec.SequencedEnvelope{Sequence: uint64(m.Offset), Envelope: &envelope, PartitionId: true})
+		if err != nil {
+			// the reason is returned by Recv
+			return <-ackError
+		}
+	}
+}
+
+func receiveAcks(stream spec.Search_SayPushAckedClient, tracker *offsetTracker, route string) error {
+	for {
+		ack, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		err = tracker.ack(route, ack)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+type pendingOffset struct {
+	offset  int64
+	route   string
+	dropped bool
+	message queue.Message
+}
+
+// offsets sent but not acknowledged, slots bounds what is in flight
+type offsetTracker struct {
+	ow         *pen.OffsetWriter
+	partition  int
+	deadLetter *deadLetter
+	pending    []pendingOffset
+	acked      map[string]int64
+	slots      chan bool
+	sync.Mutex
+}
+
+func newOffsetTracker(ow *pen.OffsetWriter, partition int, maxInflight int, dl *deadLetter) *offsetTracker {
+	return &offsetTracker{ow: ow, partition: partition, deadLetter: dl, acked: map[string]int64{}, slots: make(chan bool, maxInflight)}
+}
+
+// a slot must be taken before send, drop or reject
+func (t *offsetTracker) send(m queue.Message, route string) {
+	t.Lock()
+	defer t.Unlock()
+	t.pending = append(t.pending, pendingOffset{offset: m.Offset, route: route, message: m})
+}
+
+func (t *offsetTracker) drop(offset int64) error {
+	t.Lock()
+	defer t.Unlock()
+	t.pending = append(t.pending, pendingOffset{offset: offset, dropped: true})
+	return t.advance()
+}
+
+// the message is never sent, it goes to the dead letter topic
+func (t *offsetTracker) reject(m queue.Message, reason error) error {
+	err := t.deadLetter.send(m, reason)
+	if err != nil {
+		return err
+	}
+	return t.drop(m.Offset)
+}
+
+func (t *offsetTracker) ack(route string, ack *spec.PushAck) error {
+	t.Lock()
+	defer t.Unlock()
+
+	// rejected envelopes go to the dead letter topic before the offset moves past them
+	for _, r := range ack.Rejected {
+		for _, p := range t.pending {
+			if p.route == route && !p.dropped && p.offset == int64(r.Sequence) {
+				err := t.deadLetter.send(p.message, errors.New(r.Error))
+				if err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+
+	t.acked[route] = int64(ack.Sequence)
+	return t.advance()
+}
+
+// must be called with the lock held
+func (t *offsetTracker) advance() error {
+	done := 0
+	for done < len(t.pending) {
+		p := t.pending[done]
+		if !p.dropped {
+			acked, ok := t.acked[p.route]
+			if !ok || p.offset > acked {
+				break
+			}
+		}
+		done++
+	}
+	if done == 0 {
+		return nil
+	}
+
+	last := t.pending[done-1].offset
+	t.pending = t.pending[done:]
+	for i := 0; i < done; i++ {
+		<-t.slots
+	}
+
+	err := t.ow.SetOffset(last)
+	if err != nil {
+		return err
+	}
+	logger.Log.Infof("acknowledged partition: %d, offset: %d", t.partition, last)
+	return nil
+}
+
+type PartitionReader struct {
+	Reader     queue.Reader
+	Partition  int
+	Search     spec.SearchClient
+	Clusters   map[string]spec.SearchClient
+	Rules      *rules.Rules
+	DeadLetter *deadLetter
+}
+
+// clients has one entry with empty node id when everything goes to the same search node
+func consume(q queue.Queue, clients map[string]spec.SearchClient, nodes []string, clusters map[string]spec.SearchClient, r *rules.Rules, dl *deadLetter, root, dataTopic string, static string, nodeId string, maxInflight int, maxBackoff time.Duration) error {
+	all, err := q.Partitions(dataTopic)
+	if err != nil {
+		return err
+	}
+
+	partitions, err := claimPartitions(all, static, nodeId, nodes)
+	if err != nil {
+		return err
+	}
+	if len(partitions) == 0 {
+		return errors.New("no partitions to consume")
+	}
+
+	readers := []*PartitionReader{}
+	for _, p := range partitions {
+		rd, err := q.Reader(dataTopic, p)
+		if err != nil {
+			return err
+		}
+		si, ok := clients[""]
+		if !ok {
+			owner := partitionOwner(nodes, p)
+			si = clients[owner]
+			logger.Log.Infof("partition %d goes to node %s", p, owner)
+		}
+		readers = append(readers, &PartitionReader{Reader: rd, Partition: p, Search: si, Clusters: clusters, Rules: r, DeadLetter: dl})
+	}
+
+	err = consumeEventsFromAllPartitions(root, readers, maxInflight, maxBackoff)
+	if err != nil {
+		logger.Log.Warnf("error consuming events: %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// consumeWithBackoff restarts the partition from the last acknowledged
+// offset when the search node is unavailable, overloaded or rejects the
+// stream, waiting twice as long after every failure up to maxBackoff
+func consumeWithBackoff(root string, pr *PartitionReader, maxInflight int, maxBackoff time.Duration) error {
+	backoff := 100 * time.Millisecond
+	for {
+		started := time.Now()
+		err := consumeEvents(root, pr, maxInflight)
+		if err == queue.ErrClosed {
+			// the reader is closed
+			return err
+		}
+
+		if time.Since(started) > maxBackoff {
+			backoff = 100 * time.Millisecond
+		}
+		logger.Log.Warnf("partition %d failed, retrying in %s, err: %v", pr.Partition, backoff, err)
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func consumeEventsFromAllPartitions(root string, pr []*PartitionReader, maxInflight int, maxBackoff time.Duration) error {
+	errChan := make(chan error)
+
+	for _, p := range pr {
+		go func(p *PartitionReader) {
+			errChan <- consumeWithBackoff(root, p, maxInflight, maxBackoff)
+		}(p)
+	}
+
+	var lastError error
+	for range pr {
+		err := <-errChan
+		lastError = err
+		logger.Log.Warnf("received error: %s", err)
+		for _, p := range pr {
+			p.Reader.Close()
+		}
+	}
+
+	return lastError
+}
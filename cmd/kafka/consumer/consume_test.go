@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"github.com/rekki/blackrock/pkg/logger"
+	"github.com/rekki/blackrock/pkg/queue"
+	pen "github.com/rekki/go-pen"
+	"google.golang.org/grpc"
+)
+
+func init() {
+	logger.LogInit(3)
+}
+
+var errNodeGone = errors.New("search node went away")
+
+type failingWriter struct{}
+
+func (failingWriter) Write(ctx context.Context, messages ...queue.Message) error {
+	return errors.New("dead letter topic is down")
+}
+
+func (failingWriter) Stats() interface{} {
+	return nil
+}
+
+func (failingWriter) Close() error {
+	return nil
+}
+
+// fakeSearch answers SayPushAcked with the same stream, the test reads
+// what was sent and decides what is acknowledged
+type fakeSearch struct {
+	spec.SearchClient
+	stream *fakeAckedStream
+}
+
+func (f *fakeSearch) SayPushAcked(ctx context.Context, opts ...grpc.CallOption) (spec.Search_SayPushAckedClient, error) {
+	return f.stream, nil
+}
+
+type fakeAckedStream struct {
+	grpc.ClientStream
+	sent chan *spec.SequencedEnvelope
+	acks chan *spec.PushAck
+	fail chan error
+}
+
+func newFakeAckedStream() *fakeAckedStream {
+	return &fakeAckedStream{sent: make(chan *spec.SequencedEnvelope, 100), acks: make(chan *spec.PushAck), fail: make(chan error)}
+}
+
+func (s *fakeAckedStream) Send(e *spec.SequencedEnvelope) error {
+	s.sent <- e
+	return nil
+}
+
+func (s *fakeAckedStream) Recv() (*spec.PushAck, error) {
+	select {
+	case ack := <-s.acks:
+		return ack, nil
+	case err := <-s.fail:
+		return nil, err
+	}
+}
+
+func tempOffsetWriter(t *testing.T) (*pen.OffsetWriter, func()) {
+	t.Helper()
+	root, err := ioutil.TempDir("", "consumer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ow, err := pen.NewOffsetWriter(path.Join(root, "partition_0.offset"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ow, func() {
+		ow.Close()
+		os.RemoveAll(root)
+	}
+}
+
+func TestOffsetTracker(t *testing.T) {
+	ow, cleanup := tempOffsetWriter(t)
+	defer cleanup()
+
+	tracker := newOffsetTracker(ow, 0, 10, nil)
+	send := func(offset int64, route string) {
+		tracker.slots <- true
+		tracker.send(queue.Message{Offset: offset}, route)
+	}
+	expect := func(offset int64, inflight int) {
+		t.Helper()
+		if got := ow.ReadOrDefault(-1); got != offset {
+			t.Fatalf("expected offset %d, got %d", offset, got)
+		}
+		if len(tracker.slots) != inflight {
+			t.Fatalf("expected %d in flight, got %d", inflight, len(tracker.slots))
+		}
+	}
+
+	send(0, "a")
+	send(1, "b")
+	send(2, "a")
+	tracker.slots <- true
+	err := tracker.drop(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	send(4, "b")
+	expect(-1, 5)
+
+	// b has not acknowledged 1, nothing after it is stored
+	err = tracker.ack("a", &spec.PushAck{Sequence: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(0, 4)
+
+	// the dropped 3 does not wait for anyone, 4 waits for b
+	err = tracker.ack("b", &spec.PushAck{Sequence: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(3, 1)
+
+	err = tracker.ack("b", &spec.PushAck{Sequence: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(4, 0)
+
+	// a rejected envelope that can not be dead lettered blocks the offset
+	tracker.deadLetter = &deadLetter{w: failingWriter{}}
+	send(5, "a")
+	err = tracker.ack("a", &spec.PushAck{Sequence: 5, Rejected: []*spec.RejectedEnvelope{{Sequence: 5, Error: "bad"}}})
+	if err == nil {
+		t.Fatal("expected the ack to fail")
+	}
+	expect(4, 1)
+
+	// a message that can not be dead lettered is not dropped either
+	tracker.slots <- true
+	err = tracker.reject(queue.Message{Offset: 6}, errors.New("bad"))
+	if err == nil {
+		t.Fatal("expected the reject to fail")
+	}
+	expect(4, 2)
+}
+
+func TestConsumeAcked(t *testing.T) {
+	root, err := ioutil.TempDir("", "consumer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	q, err := queue.Open("file://" + path.Join(root, "queue"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := q.Writer("data", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		data, err := proto.Marshal(&spec.Envelope{Metadata: &spec.Metadata{EventType: "click", ForeignType: "user", ForeignId: "x"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = w.Write(context.Background(), queue.Message{Value: data})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	rd, err := q.Reader("data", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rd.Close()
+
+	stored := func() int64 {
+		ow, err := pen.NewOffsetWriter(path.Join(root, "partition_0.offset"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ow.Close()
+		return ow.ReadOrDefault(-1)
+	}
+	waitStored := func(offset int64) {
+		t.Helper()
+		for i := 0; i < 100 && stored() != offset; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := stored(); got != offset {
+			t.Fatalf("expected offset %d, got %d", offset, got)
+		}
+	}
+	expectSent := func(stream *fakeAckedStream, offsets ...uint64) {
+		t.Helper()
+		for _, offset := range offsets {
+			select {
+			case e := <-stream.sent:
+				if e.Sequence != offset || e.Envelope.Metadata.Id != offset || !e.PartitionId {
+					t.Fatalf("expected offset %d, got %v", offset, e)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("expected offset %d to be sent", offset)
+			}
+		}
+		select {
+		case e := <-stream.sent:
+			t.Fatalf("expected at most %d in flight, got %v", len(offsets), e)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+
+	stream := newFakeAckedStream()
+	pr := &PartitionReader{Reader: rd, Partition: 0, Search: &fakeSearch{stream: stream}, DeadLetter: &deadLetter{w: failingWriter{}}}
+	done := make(chan error, 1)
+	go func() {
+		done <- consumeEvents(root, pr, 2)
+	}()
+
+	expectSent(stream, 0, 1)
+	stream.acks <- &spec.PushAck{Sequence: 0}
+	waitStored(0)
+	expectSent(stream, 2)
+	stream.acks <- &spec.PushAck{Sequence: 2}
+	waitStored(2)
+	expectSent(stream, 3, 4)
+
+	// 3 is rejected and can not be dead lettered, the partition stops
+	// without storing it
+	stream.acks <- &spec.PushAck{Sequence: 4, Rejected: []*spec.RejectedEnvelope{{Sequence: 3, Error: "bad"}}}
+	err = <-done
+	if err == nil {
+		t.Fatal("expected the failed ack to stop the partition")
+	}
+	if got := stored(); got != 2 {
+		t.Fatalf("expected offset 2, got %d", got)
+	}
+
+	// restarts after the stored offset, a broken stream does not store anything
+	stream = newFakeAckedStream()
+	pr.Search = &fakeSearch{stream: stream}
+	go func() {
+		done <- consumeEvents(root, pr, 2)
+	}()
+	expectSent(stream, 3, 4)
+	stream.fail <- errNodeGone
+	err = <-done
+	if err != errNodeGone {
+		t.Fatalf("expected %v, got %v", errNodeGone, err)
+	}
+	if got := stored(); got != 2 {
+		t.Fatalf("expected offset 2, got %d", got)
+	}
+}
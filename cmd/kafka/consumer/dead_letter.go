@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/rekki/blackrock/pkg/logger"
+	"github.com/rekki/blackrock/pkg/queue"
+)
+
+// deadLetter keeps messages that can not be indexed, the original value
+// is written as is with the reason and the original position in the
+// headers, use cmd/kafka/replay to send them to the data topic again
+type deadLetter struct {
+	w queue.Writer
+}
+
+func newDeadLetter(q queue.Queue, topic string) (*deadLetter, error) {
+	w, err := q.Writer(topic, false)
+	if err != nil {
+		return nil, err
+	}
+	return &deadLetter{w: w}, nil
+}
+
+// without a dead letter topic the message is only logged
+func (d *deadLetter) send(m queue.Message, reason error) error {
+	logger.Log.Warnf("rejected partition: %d, offset: %d, error: %s", m.Partition, m.Offset, reason.Error())
+	if d == nil {
+		return nil
+	}
+
+	return d.w.Write(context.Background(), queue.Message{
+		Key:   m.Key,
+		Value: m.Value,
+		Headers: []queue.Header{
+			{Key: "blackrock-error", Value: []byte(reason.Error())},
+			{Key: "blackrock-topic", Value: []byte(m.Topic)},
+			{Key: "blackrock-partition", Value: []byte(strconv.Itoa(m.Partition))},
+			{Key: "blackrock-offset", Value: []byte(strconv.FormatInt(m.Offset, 10))},
+		},
+	})
+}
+
+func (d *deadLetter) Close() error {
+	if d == nil {
+		return nil
+	}
+	return d.w.Close()
+}
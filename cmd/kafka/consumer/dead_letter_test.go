@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/rekki/blackrock/pkg/queue"
+)
+
+func TestDeadLetter(t *testing.T) {
+	root, err := ioutil.TempDir("", "dead_letter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	q, err := queue.Open("file://" + root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl, err := newDeadLetter(q, "dead")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dl.send(queue.Message{Topic: "data", Partition: 3, Offset: 42, Key: []byte("k"), Value: []byte("v")}, errors.New("bad event_type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dl.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rd, err := q.Reader("dead", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rd.Close()
+	m, err := rd.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(m.Key) != "k" || string(m.Value) != "v" {
+		t.Fatalf("expected the original key and value, got %s %s", m.Key, m.Value)
+	}
+	expected := map[string]string{
+		"blackrock-error":     "bad event_type",
+		"blackrock-topic":     "data",
+		"blackrock-partition": "3",
+		"blackrock-offset":    "42",
+	}
+	if len(m.Headers) != len(expected) {
+		t.Fatalf("expected %d headers, got %v", len(expected), m.Headers)
+	}
+	for _, h := range m.Headers {
+		if expected[h.Key] != string(h.Value) {
+			t.Fatalf("header %s: expected %s, got %s", h.Key, expected[h.Key], h.Value)
+		}
+	}
+
+	// without a dead letter topic the message is only logged
+	var none *deadLetter
+	err = none.send(queue.Message{}, errors.New("bad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = none.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -4,59 +4,155 @@ import (
 	"context"
 	"flag"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/rekki/blackrock/pkg/auth"
 	spec "github.com/rekki/blackrock/pkg/blackrock_io"
 	. "github.com/rekki/blackrock/pkg/logger"
+	"github.com/rekki/blackrock/pkg/queue"
+	"github.com/rekki/blackrock/pkg/rules"
 	_ "github.com/segmentio/kafka-go/snappy"
 
 	"google.golang.org/grpc"
 )
 
-func main() {
-	var remote = flag.String("search-grpc", ":8002", "connect to search grpc")
-	var dataTopic = flag.String("topic-data", "blackrock-data", "topic for the data")
-	var root = flag.String("root", "/blackrock", "root where to store the kafka offsets and locks")
-	var kafkaServers = flag.String("kafka", "localhost:9092", "comma separated list of kafka servers")
-	var logLevel = flag.Int("log-level", 0, "log level")
-	flag.Parse()
-	LogInit(*logLevel)
-
-	err := os.MkdirAll(*root, 0700)
-	if err != nil {
-		Log.Fatal(err)
-	}
+func connect(remote string, opts ...grpc.DialOption) (spec.SearchClient, *grpc.ClientConn) {
 	var (
-		si   spec.SearchClient
 		conn *grpc.ClientConn
+		err  error
 	)
 
 	// just keep trying every second
 
 	for {
-		conn, err = grpc.Dial(*remote, grpc.WithInsecure())
+		conn, err = grpc.Dial(remote, append([]grpc.DialOption{grpc.WithInsecure()}, opts...)...)
 		if err != nil {
-			Log.Warnf("error connecting, sleeping 1 second, err: %v", err.Error())
+			Log.Warnf("error connecting to %s, sleeping 1 second, err: %v", remote, err.Error())
 			time.Sleep(1 * time.Second)
 			continue
 		}
 		break
 	}
 
-	si = spec.NewSearchClient(conn)
+	si := spec.NewSearchClient(conn)
 	for {
 		_, err = si.SayHealth(context.Background(), &spec.HealthRequest{})
 		if err != nil {
-			Log.Warnf("error health, sleeping 1 second, err: %v", err.Error())
+			Log.Warnf("error health %s, sleeping 1 second, err: %v", remote, err.Error())
 			time.Sleep(1 * time.Second)
 			continue
 		}
 
 		break
 	}
-	err = consumeKafka(si, *root, *dataTopic, *kafkaServers)
+	return si, conn
+}
+
+func main() {
+	var remote = flag.String("search-grpc", ":8002", "connect to search grpc, or csv list of node_id=host:port to route each partition to the node owning it")
+	var dataTopic = flag.String("topic-data", "blackrock-data", "topic for the data")
+	var root = flag.String("root", "/blackrock", "root where to store the kafka offsets and locks")
+	var kafkaServers = flag.String("kafka", "localhost:9092", "comma separated list of kafka servers")
+	var queueUrl = flag.String("queue", "", "queue to read from, kafka://host:port,host:port or file:///path?partitions=#, nothing means kafka://<-kafka>")
+	var partitions = flag.String("partitions", "", "csv list of partitions to consume, nothing means all or the ones owned by -node-id")
+	var nodeId = flag.String("node-id", "", "consume only the partitions owned by this node, partitions are spread over -nodes with consistent hashing")
+	var pnodes = flag.String("nodes", "", "csv list of all node ids, defaults to the node ids in -search-grpc")
+	var maxInflight = flag.Int("max-inflight", 10000, "max envelopes per partition sent to search and not acknowledged yet")
+	var deadLetterTopic = flag.String("topic-dead-letter", "", "topic for envelopes that can not be indexed, nothing means they are only logged")
+	var rulesFile = flag.String("rules", "", "json file with rules to drop, transform, sample and route envelopes")
+	var pclusters = flag.String("clusters", "", "csv list of route=host:port, search nodes for the routes used in -rules")
+	var maxBackoff = flag.Int("max-backoff", 30, "max seconds to wait before retrying a partition after the search node failed")
+	var apiKey = flag.String("api-key", "", "api key for the search nodes started with -keys")
+	var logLevel = flag.Int("log-level", 0, "log level")
+	flag.Parse()
+	LogInit(*logLevel)
+
+	err := os.MkdirAll(*root, 0700)
+	if err != nil {
+		Log.Fatal(err)
+	}
+
+	if *queueUrl == "" {
+		*queueUrl = "kafka://" + *kafkaServers
+	}
+	q, err := queue.Open(*queueUrl)
+	if err != nil {
+		Log.Fatal(err)
+	}
+
+	addresses, nodes, err := parseSearchNodes(*remote)
+	if err != nil {
+		Log.Fatal(err)
+	}
+	if *pnodes != "" {
+		nodes = strings.Split(*pnodes, ",")
+		sort.Strings(nodes)
+	}
+	if _, single := addresses[""]; !single {
+		for _, node := range nodes {
+			if _, ok := addresses[node]; !ok {
+				Log.Fatalf("node %s has no address in -search-grpc", node)
+			}
+		}
+	}
+
+	dialOpts := []grpc.DialOption{}
+	if *apiKey != "" {
+		dialOpts = append(dialOpts, auth.WithAPIKey(*apiKey))
+	}
+
+	clients := map[string]spec.SearchClient{}
+	for node, address := range addresses {
+		si, conn := connect(address, dialOpts...)
+		defer conn.Close()
+		clients[node] = si
+	}
+
+	var r *rules.Rules
+	if *rulesFile != "" {
+		r, err = rules.Load(*rulesFile)
+		if err != nil {
+			Log.Fatal(err)
+		}
+	}
+
+	clusters := map[string]spec.SearchClient{}
+	if *pclusters != "" {
+		for _, v := range strings.Split(*pclusters, ",") {
+			kv := strings.SplitN(v, "=", 2)
+			if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+				Log.Fatalf("bad cluster %q, expected route=host:port", v)
+			}
+			si, conn := connect(kv[1], dialOpts...)
+			defer conn.Close()
+			clusters[kv[0]] = si
+		}
+	}
+	if r != nil {
+		for _, route := range r.Routes() {
+			if _, ok := clusters[route]; !ok {
+				Log.Fatalf("route %s has no address in -clusters", route)
+			}
+		}
+	}
+
+	var dl *deadLetter
+	if *deadLetterTopic != "" {
+		err = q.Health(*deadLetterTopic)
+		if err != nil {
+			Log.Fatal(err)
+		}
+		dl, err = newDeadLetter(q, *deadLetterTopic)
+		if err != nil {
+			Log.Fatal(err)
+		}
+		defer dl.Close()
+	}
+
+	err = consume(q, clients, nodes, clusters, r, dl, *root, *dataTopic, *partitions, *nodeId, *maxInflight, time.Duration(*maxBackoff)*time.Second)
 	if err != nil {
-		conn.Close()
 		Log.Fatalf("failed to run the proxy, err: %s", err.Error())
 	}
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rekki/blackrock/pkg/depths"
+)
+
+// partitionOwner picks the node for a partition with rendezvous hashing,
+// adding or removing a node moves only the partitions of that node
+func partitionOwner(nodes []string, partition int) string {
+	owner := ""
+	best := uint64(0)
+	for _, node := range nodes {
+		score := depths.Hashs(fmt.Sprintf("%s:%d", node, partition))
+		if owner == "" || score > best || (score == best && node < owner) {
+			owner = node
+			best = score
+		}
+	}
+	return owner
+}
+
+// claimPartitions returns the partitions this consumer reads, either the
+// static csv list, or the ones owned by nodeId among nodes, or all of them
+func claimPartitions(partitions []int, static string, nodeId string, nodes []string) ([]int, error) {
+	if static != "" {
+		wanted := map[int]bool{}
+		for _, v := range strings.Split(static, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("bad partition %q: %s", v, err.Error())
+			}
+			wanted[id] = true
+		}
+
+		out := []int{}
+		for _, p := range partitions {
+			if wanted[p] {
+				out = append(out, p)
+				delete(wanted, p)
+			}
+		}
+		if len(wanted) > 0 {
+			return nil, fmt.Errorf("partitions %v are not in the topic", wanted)
+		}
+		return out, nil
+	}
+
+	if nodeId == "" {
+		return partitions, nil
+	}
+
+	found := false
+	for _, node := range nodes {
+		if node == nodeId {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("node %s is not in the list of nodes %v", nodeId, nodes)
+	}
+
+	out := []int{}
+	for _, p := range partitions {
+		if partitionOwner(nodes, p) == nodeId {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// parseSearchNodes accepts a single address, or a csv list of
+// node_id=address to route every partition to the search node owning it
+func parseSearchNodes(s string) (map[string]string, []string, error) {
+	if !strings.Contains(s, "=") {
+		return map[string]string{"": s}, nil, nil
+	}
+
+	addresses := map[string]string{}
+	nodes := []string{}
+	for _, v := range strings.Split(s, ",") {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, nil, fmt.Errorf("bad search node %q, expected node_id=host:port", v)
+		}
+		addresses[kv[0]] = kv[1]
+		nodes = append(nodes, kv[0])
+	}
+	sort.Strings(nodes)
+	return addresses, nodes, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func topic(n int) []int {
+	out := []int{}
+	for i := 0; i < n; i++ {
+		out = append(out, i)
+	}
+	return out
+}
+
+func TestClaimPartitions(t *testing.T) {
+	cases := []struct {
+		partitions []int
+		static     string
+		nodeId     string
+		nodes      []string
+		expected   string
+		fails      bool
+	}{
+		// the static list keeps the order of the topic
+		{topic(4), "2, 0", "", nil, "[0 2]", false},
+		{topic(4), "2,0", "a", []string{"a", "b"}, "[0 2]", false},
+		{topic(4), "4", "", nil, "", true},
+		{topic(4), "x", "", nil, "", true},
+		// no sharding
+		{topic(4), "", "", nil, "[0 1 2 3]", false},
+		{topic(4), "", "", []string{"a", "b"}, "[0 1 2 3]", false},
+		// a single consumer reads everything
+		{topic(4), "", "a", []string{"a"}, "[0 1 2 3]", false},
+		{topic(0), "", "a", []string{"a"}, "[]", false},
+		{topic(4), "", "c", []string{"a", "b"}, "", true},
+	}
+	for _, c := range cases {
+		got, err := claimPartitions(c.partitions, c.static, c.nodeId, c.nodes)
+		if (err != nil) != c.fails {
+			t.Fatalf("%v %q %q %v: unexpected error %v", c.partitions, c.static, c.nodeId, c.nodes, err)
+		}
+		if !c.fails && fmt.Sprintf("%v", got) != c.expected {
+			t.Fatalf("%v %q %q %v: expected %s got %v", c.partitions, c.static, c.nodeId, c.nodes, c.expected, got)
+		}
+	}
+}
+
+// every partition is claimed by exactly one node, whatever the counts
+func TestClaimPartitionsCoverTopic(t *testing.T) {
+	cases := []struct {
+		partitions int
+		nodes      []string
+	}{
+		{1, []string{"a", "b", "c"}},
+		{2, []string{"a", "b", "c"}},
+		{7, []string{"a", "b", "c"}},
+		{64, []string{"a", "b", "c"}},
+		{64, []string{"a", "b", "c", "d", "e"}},
+		{3, []string{"a", "b", "c", "d", "e", "f", "g"}},
+	}
+	for _, c := range cases {
+		owners := map[int]string{}
+		for _, node := range c.nodes {
+			claimed, err := claimPartitions(topic(c.partitions), "", node, c.nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range claimed {
+				if owners[p] != "" {
+					t.Fatalf("%d partitions %v: partition %d claimed by %s and %s", c.partitions, c.nodes, p, owners[p], node)
+				}
+				owners[p] = node
+			}
+		}
+		if len(owners) != c.partitions {
+			t.Fatalf("%d partitions %v: only %d are claimed", c.partitions, c.nodes, len(owners))
+		}
+		// with many partitions every node gets some
+		if c.partitions >= 16*len(c.nodes) {
+			for _, node := range c.nodes {
+				claimed, _ := claimPartitions(topic(c.partitions), "", node, c.nodes)
+				if len(claimed) == 0 {
+					t.Fatalf("%d partitions %v: %s claims nothing", c.partitions, c.nodes, node)
+				}
+			}
+		}
+	}
+}
+
+// adding or removing a node moves only the partitions of that node
+func TestPartitionOwnerRebalance(t *testing.T) {
+	cases := []struct {
+		before []string
+		after  []string
+	}{
+		{[]string{"a"}, []string{"a", "b"}},
+		{[]string{"a", "b", "c"}, []string{"a", "b", "c", "d"}},
+		{[]string{"a", "b", "c", "d"}, []string{"a", "c", "d"}},
+		{[]string{"a", "b"}, []string{"b"}},
+		// the order of the list does not matter
+		{[]string{"c", "a", "b"}, []string{"a", "b", "c"}},
+	}
+	for _, c := range cases {
+		inBefore, inAfter := map[string]bool{}, map[string]bool{}
+		for _, node := range c.before {
+			inBefore[node] = true
+		}
+		for _, node := range c.after {
+			inAfter[node] = true
+		}
+
+		moved := 0
+		for p := 0; p < 256; p++ {
+			from := partitionOwner(c.before, p)
+			to := partitionOwner(c.after, p)
+			if from == to {
+				continue
+			}
+			moved++
+			if inAfter[from] && inBefore[to] {
+				t.Fatalf("%v -> %v: partition %d moved from %s to %s, both in both lists", c.before, c.after, p, from, to)
+			}
+		}
+		if len(c.before) == len(c.after) && moved != 0 {
+			t.Fatalf("%v -> %v: expected nothing to move, %d moved", c.before, c.after, moved)
+		}
+		if len(c.before) != len(c.after) && moved == 0 {
+			t.Fatalf("%v -> %v: expected partitions to move", c.before, c.after)
+		}
+	}
+
+	if owner := partitionOwner(nil, 0); owner != "" {
+		t.Fatalf("expected no owner without nodes, got %s", owner)
+	}
+}
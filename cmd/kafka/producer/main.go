@@ -3,35 +3,108 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/rekki/blackrock/pkg/auth"
 	spec "github.com/rekki/blackrock/pkg/blackrock_io"
 	"github.com/rekki/blackrock/pkg/depths"
 	. "github.com/rekki/blackrock/pkg/logger"
-	"github.com/segmentio/kafka-go"
-	"github.com/segmentio/kafka-go/snappy"
+	"github.com/rekki/blackrock/pkg/queue"
+	"github.com/rekki/blackrock/pkg/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
-	"strings"
 	"time"
 )
 
 type server struct {
-	kw           *kafka.Writer
-	kafkaServers string
-	topic        string
+	w     queue.Writer
+	sync  queue.Writer
+	q     queue.Queue
+	topic string
+	// wait for the queue to store every push, not only the ones asking for it
+	alwaysSync bool
+	limits     *spec.Limits
+	// key messages by foreign_type:foreign_id so one entity stays on one partition
+	keyByEntity bool
+	// envelopes per second per api key, or per ip without authentication
+	limiter *ratelimit.Limiter
+}
+
+const syncHeader = "blackrock-sync"
+
+// a push asks for sync delivery with the blackrock-sync: true grpc
+// metadata, or the Grpc-Metadata-Blackrock-Sync: true http header
+func wantsSync(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	for _, v := range md.Get(syncHeader) {
+		if v == "true" || v == "1" {
+			return true
+		}
+	}
+	return false
 }
 
+const pushBatchSize = 1000
+
+// SayPush reports how many envelopes were accepted and why the others
+// were rejected, with the index of each in the stream. In sync mode
+// accepted means stored by the queue, otherwise only handed over to it.
+// If no envelope is accepted the push fails with Unavailable when the queue
+// did not take them, with ResourceExhausted when the client is over its
+// rate limit, and with InvalidArgument and the bad fields as details when
+// they are all invalid. The code of every rejected envelope says which.
 func (s *server) SayPush(stream spec.Enqueue_SayPushServer) error {
-	ctx := context.Background()
-	for {
+	ctx := stream.Context()
+	client := ratelimit.Client(ctx)
+	w := s.w
+	if s.alwaysSync || wantsSync(ctx) {
+		w = s.sync
+	}
+
+	out := &spec.Success{}
+	violations := []*errdetails.BadRequest_FieldViolation{}
+	limited := 0
+	retry := time.Duration(0)
+	failed := 0
+	var writeErr error
+	batch := []queue.Message{}
+	indexes := []uint64{}
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		err := w.Write(ctx, batch...)
+		if err != nil {
+			Log.Warnf("failed to write %d envelopes, err: %s", len(batch), err.Error())
+			for _, i := range indexes {
+				out.Rejected = append(out.Rejected, &spec.RejectedEnvelope{Sequence: i, Error: err.Error(), Code: uint32(codes.Unavailable)})
+			}
+			failed += len(batch)
+			writeErr = err
+		} else {
+			out.Accepted += uint32(len(batch))
+		}
+		batch = batch[:0]
+		indexes = indexes[:0]
+	}
+
+	for i := uint64(0); ; i++ {
 		envelope, err := stream.Recv()
 		if err == io.EOF {
 			break
@@ -40,25 +113,71 @@ func (s *server) SayPush(stream spec.Enqueue_SayPushServer) error {
 			return err
 		}
 
+		err = s.limits.Validate(envelope)
+		if err != nil {
+			out.Rejected = append(out.Rejected, &spec.RejectedEnvelope{Sequence: i, Error: err.Error(), Code: uint32(codes.InvalidArgument)})
+			if ve, ok := err.(*spec.ValidationError); ok {
+				violations = append(violations, &errdetails.BadRequest_FieldViolation{
+					Field:       fmt.Sprintf("envelope[%d].%s", i, ve.Field),
+					Description: ve.Reason,
+				})
+			}
+			continue
+		}
+
+		allowed, wait := s.limiter.AllowN(client, 1, time.Now())
+		if !allowed {
+			out.Rejected = append(out.Rejected, &spec.RejectedEnvelope{Sequence: i, Error: fmt.Sprintf("rate limit exceeded, retry in %s", wait.Round(time.Millisecond)), Code: uint32(codes.ResourceExhausted)})
+			limited++
+			if wait > retry {
+				retry = wait
+			}
+			continue
+		}
+
 		if envelope.Metadata.CreatedAtNs == 0 {
 			envelope.Metadata.CreatedAtNs = time.Now().UnixNano()
 		}
 
 		encoded, err := proto.Marshal(envelope)
 		if err != nil {
-			return err
+			out.Rejected = append(out.Rejected, &spec.RejectedEnvelope{Sequence: i, Error: err.Error(), Code: uint32(codes.InvalidArgument)})
+			continue
 		}
 
-		err = s.kw.WriteMessages(ctx, kafka.Message{
-			Value: encoded,
-		})
-
-		if err != nil {
-			return err
+		m := queue.Message{Value: encoded}
+		if s.keyByEntity {
+			m.Key = []byte(envelope.Metadata.ForeignType + ":" + envelope.Metadata.ForeignId)
+		}
+		batch = append(batch, m)
+		indexes = append(indexes, i)
+		if len(batch) >= pushBatchSize {
+			flush()
 		}
+	}
+	flush()
+
+	if out.Accepted == 0 && failed > 0 {
+		return status.Errorf(codes.Unavailable, "failed to write %d envelopes, err: %s", failed, writeErr.Error())
+	}
+	if out.Accepted == 0 && limited > 0 {
+		return ratelimit.Exhausted(client, fmt.Sprintf("%d envelopes", limited), retry)
+	}
+	if out.Accepted == 0 && len(violations) == len(out.Rejected) && len(violations) > 0 {
+		return invalidArgument(violations)
+	}
+
+	out.Success = len(out.Rejected) == 0
+	return stream.SendAndClose(out)
+}
 
+func invalidArgument(violations []*errdetails.BadRequest_FieldViolation) error {
+	st := status.New(codes.InvalidArgument, fmt.Sprintf("%d invalid envelopes, first: %s: %s", len(violations), violations[0].Field, violations[0].Description))
+	withDetails, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return st.Err()
 	}
-	return stream.SendAndClose(&spec.Success{Success: true})
+	return withDetails.Err()
 }
 
 func runProxy(bindHttp string, bindGrpc string) error {
@@ -77,7 +196,7 @@ func runProxy(bindHttp string, bindGrpc string) error {
 }
 
 func (s *server) SayHealth(ctx context.Context, in *spec.HealthRequest) (*spec.Success, error) {
-	err := depths.HealthCheckKafka(s.kafkaServers, s.topic)
+	err := s.q.Health(s.topic)
 	if err != nil {
 		return nil, err
 	}
@@ -88,33 +207,94 @@ func (s *server) SayHealth(ctx context.Context, in *spec.HealthRequest) (*spec.S
 func main() {
 	var dataTopic = flag.String("topic-data", "blackrock-data", "topic for the data")
 	var kafkaServers = flag.String("kafka", "localhost:9092", "comma separated list of kafka servers")
+	var queueUrl = flag.String("queue", "", "queue to write to, kafka://host:port,host:port or file:///path?partitions=#, nothing means kafka://<-kafka>")
+	var alwaysSync = flag.Bool("sync", false, "wait for the queue to store every push, otherwise only pushes with the blackrock-sync: true metadata wait")
+	var maxKeys = flag.Int("max-keys", 0, "max search, count and properties keys per envelope, 0 for no limit")
+	var maxKeyLength = flag.Int("max-key-length", 0, "max key length in bytes, 0 for no limit")
+	var maxValueLength = flag.Int("max-value-length", 0, "max value length in bytes, 0 for no limit")
+	var maxPayloadSize = flag.Int("max-payload-size", 0, "max payload size in bytes, 0 for no limit")
+	var eventTypes = flag.String("event-types", "", "comma separated allowed event types, empty allows all")
+	var balancer = flag.String("balancer", "least-bytes", "how to pick the partition: least-bytes, or foreign-id to keep the events of one foreign_type:foreign_id in order on one partition")
+	var rate = flag.Float64("rate", 0, "envelopes per second per api key, or per ip without -keys, 0 for no limit")
+	var burst = flag.Int("burst", 0, "envelopes a client can push at once above -rate, 0 means -rate")
 	var statSleep = flag.Int("writer-stats", 60, "print writer stats every # seconds")
+	var keysFile = flag.String("keys", "", "json file with the api keys allowed to push, nothing means no authentication")
 	var logLevel = flag.Int("log-level", 0, "log level")
 	var bindHttp = flag.String("http", ":9001", "bind http")
+	var trustedProxies = flag.String("trusted-proxies", "", "csv list of ips and cidrs of the proxies in front, only their x-forwarded-for is used to rate limit by ip")
 	var bindGrpc = flag.String("grpc", ":8001", "bind grpc")
 	flag.Parse()
 
 	LogInit(*logLevel)
+	var err error
+	ratelimit.TrustedProxies, err = ratelimit.ParseProxies(*trustedProxies)
+	if err != nil {
+		Log.Fatal(err)
+	}
+
+	var keys *auth.Keys
+	if *keysFile != "" {
+		var err error
+		keys, err = auth.Load(*keysFile)
+		if err != nil {
+			Log.Fatal(err)
+		}
+	}
+
+	if *queueUrl == "" {
+		*queueUrl = "kafka://" + *kafkaServers
+	}
+	q, err := queue.Open(*queueUrl)
+	if err != nil {
+		Log.Fatal(err.Error())
+	}
 
-	err := depths.HealthCheckKafka(*kafkaServers, *dataTopic)
+	err = q.Health(*dataTopic)
 	if err != nil {
 		Log.Fatal(err.Error())
 	}
 
-	kw := kafka.NewWriter(kafka.WriterConfig{
-		Brokers:          strings.Split(*kafkaServers, ","),
-		Topic:            *dataTopic,
-		Balancer:         &kafka.LeastBytes{},
-		BatchTimeout:     1 * time.Second,
-		CompressionCodec: snappy.NewCompressionCodec(),
-		Async:            true,
-	})
+	kw, err := q.Writer(*dataTopic, !*alwaysSync)
+	if err != nil {
+		Log.Fatal(err.Error())
+	}
 	defer kw.Close()
 
+	sw := kw
+	if !*alwaysSync {
+		sw, err = q.Writer(*dataTopic, false)
+		if err != nil {
+			Log.Fatal(err.Error())
+		}
+		defer sw.Close()
+	}
+
+	limits := &spec.Limits{
+		MaxKeys:        *maxKeys,
+		MaxKeyLength:   *maxKeyLength,
+		MaxValueLength: *maxValueLength,
+		MaxPayloadSize: *maxPayloadSize,
+	}
+	if *eventTypes != "" {
+		limits.EventTypes = map[string]bool{}
+		for _, t := range strings.Split(*eventTypes, ",") {
+			limits.EventTypes[strings.TrimSpace(t)] = true
+		}
+	}
+
+	if *balancer != "least-bytes" && *balancer != "foreign-id" {
+		Log.Fatalf("unknown balancer %s, use least-bytes or foreign-id", *balancer)
+	}
+
 	srv := &server{
-		kafkaServers: *kafkaServers,
-		kw:           kw,
-		topic:        *dataTopic,
+		q:           q,
+		w:           kw,
+		sync:        sw,
+		topic:       *dataTopic,
+		alwaysSync:  *alwaysSync,
+		limits:      limits,
+		keyByEntity: *balancer == "foreign-id",
+		limiter:     ratelimit.New(*rate, *burst),
 	}
 
 	go func() {
@@ -131,7 +311,8 @@ func main() {
 		Log.Fatalf("failed to listen: %v", err)
 	}
 
-	grpcServer := grpc.NewServer(AddLogging([]grpc.ServerOption{})...)
+	unary, stream := auth.Interceptors(keys, auth.PushMethods)
+	grpcServer := grpc.NewServer(AddLoggingWith([]grpc.ServerOption{}, unary, stream)...)
 	spec.RegisterEnqueueServer(grpcServer, srv)
 
 	sigs := make(chan os.Signal, 1)
@@ -140,6 +321,9 @@ func main() {
 		<-sigs
 		Log.Warnf("closing the writer...")
 		kw.Close()
+		if sw != kw {
+			sw.Close()
+		}
 		os.Exit(0)
 	}()
 
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	. "github.com/rekki/blackrock/pkg/logger"
+	"github.com/rekki/blackrock/pkg/queue"
+)
+
+func header(m queue.Message, key string) string {
+	for _, h := range m.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// replay writes the dead lettered messages with contains in their error
+// back to dataTopic, a dry run prints them to out instead, it returns how
+// many were replayed
+func replay(q queue.Queue, deadLetterTopic, dataTopic, contains string, fromOffset int64, dryRun bool, out io.Writer) (int, error) {
+	partitions, err := q.Partitions(deadLetterTopic)
+	if err != nil {
+		return 0, err
+	}
+
+	w, err := q.Writer(dataTopic, false)
+	if err != nil {
+		return 0, err
+	}
+	defer w.Close()
+
+	replayed := 0
+	for _, p := range partitions {
+		// messages dead lettered during the replay are not replayed again
+		first, last, err := q.Offsets(deadLetterTopic, p)
+		if err != nil {
+			return replayed, err
+		}
+		if first < fromOffset {
+			first = fromOffset
+		}
+		if first >= last {
+			continue
+		}
+
+		rd, err := q.Reader(deadLetterTopic, p)
+		if err != nil {
+			return replayed, err
+		}
+		n, err := replayPartition(rd, w, first, last, contains, dryRun, out)
+		rd.Close()
+		replayed += n
+		if err != nil {
+			return replayed, err
+		}
+		Log.Infof("partition %d replayed up to offset %d", p, last-1)
+	}
+	return replayed, nil
+}
+
+func replayPartition(rd queue.Reader, w queue.Writer, first, last int64, contains string, dryRun bool, out io.Writer) (int, error) {
+	err := rd.SetOffset(first)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	batch := []queue.Message{}
+	for {
+		m, err := rd.Fetch(context.Background())
+		if err != nil {
+			return replayed, err
+		}
+
+		reason := header(m, "blackrock-error")
+		if contains == "" || strings.Contains(reason, contains) {
+			if dryRun {
+				fmt.Fprintf(out, "partition: %d, offset: %d, from %s/%s/%s, error: %s\n", m.Partition, m.Offset, header(m, "blackrock-topic"), header(m, "blackrock-partition"), header(m, "blackrock-offset"), reason)
+			} else {
+				batch = append(batch, queue.Message{Key: m.Key, Value: m.Value})
+			}
+		}
+
+		if len(batch) >= 1000 || (m.Offset >= last-1 && len(batch) > 0) {
+			err = w.Write(context.Background(), batch...)
+			if err != nil {
+				return replayed, err
+			}
+			replayed += len(batch)
+			batch = []queue.Message{}
+		}
+		if m.Offset >= last-1 {
+			return replayed, nil
+		}
+	}
+}
+
+func main() {
+	var deadLetterTopic = flag.String("topic-dead-letter", "blackrock-dead-letter", "topic to replay")
+	var dataTopic = flag.String("topic-data", "blackrock-data", "topic to send the messages to")
+	var kafkaServers = flag.String("kafka", "localhost:9092", "comma separated list of kafka servers")
+	var queueUrl = flag.String("queue", "", "kafka://host:port,host:port or file:///path, nothing means kafka://<-kafka>")
+	var contains = flag.String("error-contains", "", "replay only messages with this text in the error")
+	var fromOffset = flag.Int64("from-offset", 0, "skip messages of the dead letter topic before this offset, in every partition")
+	var dryRun = flag.Bool("dry-run", false, "only print the messages that would be replayed")
+	var logLevel = flag.Int("log-level", 0, "log level")
+	flag.Parse()
+	LogInit(*logLevel)
+
+	if *queueUrl == "" {
+		*queueUrl = "kafka://" + *kafkaServers
+	}
+	q, err := queue.Open(*queueUrl)
+	if err != nil {
+		Log.Fatal(err)
+	}
+
+	replayed, err := replay(q, *deadLetterTopic, *dataTopic, *contains, *fromOffset, *dryRun, os.Stdout)
+	if err != nil {
+		Log.Fatal(err)
+	}
+	Log.Infof("replayed %d messages to %s", replayed, *dataTopic)
+}
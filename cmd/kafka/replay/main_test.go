@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rekki/blackrock/pkg/logger"
+	"github.com/rekki/blackrock/pkg/queue"
+)
+
+func init() {
+	logger.LogInit(3)
+}
+
+func TestReplay(t *testing.T) {
+	root, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	q, err := queue.Open("file://" + root + "?partitions=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// what the consumer writes to the dead letter topic
+	w, err := q.Writer("dead", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		reason := "bad event_type"
+		if i%2 == 0 {
+			reason = "timeout"
+		}
+		err = w.Write(context.Background(), queue.Message{
+			Key:   []byte(fmt.Sprintf("k%d", i)),
+			Value: []byte(fmt.Sprintf("v%d", i)),
+			Headers: []queue.Header{
+				{Key: "blackrock-error", Value: []byte(reason)},
+				{Key: "blackrock-topic", Value: []byte("data")},
+				{Key: "blackrock-partition", Value: []byte("0")},
+				{Key: "blackrock-offset", Value: []byte(fmt.Sprintf("%d", i))},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	data := func() map[string]string {
+		out := map[string]string{}
+		partitions, err := q.Partitions("data")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range partitions {
+			first, last, err := q.Offsets("data", p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rd, err := q.Reader("data", p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for o := first; o < last; o++ {
+				m, err := rd.Fetch(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(m.Headers) != 0 {
+					t.Fatalf("expected no headers, got %v", m.Headers)
+				}
+				out[string(m.Key)] = string(m.Value)
+			}
+			rd.Close()
+		}
+		return out
+	}
+
+	out := &bytes.Buffer{}
+	n, err := replay(q, "dead", "data", "timeout", 0, true, out)
+	if err != nil || n != 0 {
+		t.Fatalf("expected a dry run, got %d %v", n, err)
+	}
+	if lines := strings.Count(out.String(), "error: timeout\n"); lines != 5 || strings.Contains(out.String(), "event_type") {
+		t.Fatalf("expected 5 timeouts printed, got %s", out.String())
+	}
+	if got := data(); len(got) != 0 {
+		t.Fatalf("expected nothing replayed, got %v", got)
+	}
+
+	n, err = replay(q, "dead", "data", "timeout", 0, false, out)
+	if err != nil || n != 5 {
+		t.Fatalf("expected 5 replayed, got %d %v", n, err)
+	}
+	got := data()
+	if len(got) != 5 {
+		t.Fatalf("expected 5 messages, got %v", got)
+	}
+	for i := 0; i < 10; i += 2 {
+		if got[fmt.Sprintf("k%d", i)] != fmt.Sprintf("v%d", i) {
+			t.Fatalf("expected k%d, got %v", i, got)
+		}
+	}
+
+	// every partition skips what is before from_offset
+	partitions, err := q.Partitions("dead")
+	if err != nil {
+		t.Fatal(err)
+	}
+	skipped := int64(0)
+	for _, p := range partitions {
+		_, last, err := q.Offsets("dead", p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last > 2 {
+			skipped += 2
+		} else {
+			skipped += last
+		}
+	}
+	n, err = replay(q, "dead", "data", "", 2, false, out)
+	if err != nil || int64(n) != 10-skipped {
+		t.Fatalf("expected %d replayed, got %d %v", 10-skipped, n, err)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"github.com/rekki/blackrock/pkg/index"
+	. "github.com/rekki/blackrock/pkg/logger"
+	"github.com/rekki/blackrock/pkg/ratelimit"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SayForget marks every document of foreign_type:foreign_id of the tenant
+// as deleted and writes it to the audit log, with compact the segments
+// are rewritten without them right away.
+func (s *server) SayForget(ctx context.Context, in *spec.ForgetRequest) (*spec.ForgetResponse, error) {
+	if in.ForeignType == "" || in.ForeignId == "" {
+		return nil, status.Error(codes.InvalidArgument, "foreign_type and foreign_id are required")
+	}
+	hashed, err := s.audit.HashForeignId(in.ForeignId)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "%s, start the node with -forget-audit-key-file", err.Error())
+	}
+	si, err := s.index(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant := s.tenantOf(ctx)
+	n, segments, err := si.Forget(ctx, in.ForeignType, in.ForeignId)
+	// what was deleted before an error is audited too
+	aerr := s.audit.Append(&index.AuditRecord{
+		Action:        index.AuditForget,
+		Tenant:        tenant,
+		Client:        ratelimit.Client(ctx),
+		Reason:        in.Reason,
+		ForeignType:   in.ForeignType,
+		ForeignIdHmac: hashed,
+		Documents:     n,
+		Segments:      segments,
+	})
+	if err != nil {
+		return nil, queryError(err)
+	}
+	if aerr != nil {
+		return nil, status.Errorf(codes.Internal, "%d documents were forgotten but writing the audit log failed, try again: %s", n, aerr.Error())
+	}
+
+	out := &spec.ForgetResponse{Documents: uint32(n), Segments: segments}
+	if in.Compact && n > 0 && s.canCompact(tenant) {
+		compacted, err := s.compact(tenant, si)
+		if err != nil {
+			return nil, err
+		}
+		out.Compacted = uint32(compacted)
+	}
+	return out, nil
+}
+
+// compaction renumbers documents, a follower must keep the offsets of its
+// leader
+func (s *server) canCompact(tenant string) bool {
+	return tenant != "" || s.replica == nil || !s.replica.following()
+}
+
+// compact rewrites the segments with forgotten documents and writes the
+// result to the audit log, returns how many documents were removed
+func (s *server) compact(tenant string, si *index.SearchIndex) (int, error) {
+	removed, err := si.Compact()
+
+	total := 0
+	segments := []string{}
+	for id, n := range removed {
+		total += n
+		segments = append(segments, id)
+	}
+	sort.Strings(segments)
+	if total > 0 {
+		aerr := s.audit.Append(&index.AuditRecord{Action: index.AuditCompact, Tenant: tenant, Documents: total, Segments: segments})
+		if aerr != nil {
+			Log.Warnf("tenant %q: failed to write the compaction of %d documents to the audit log, err: %s", tenant, total, aerr.Error())
+		}
+	}
+	return total, err
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/rekki/blackrock/pkg/auth"
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"github.com/rekki/blackrock/pkg/ratelimit"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// limits of one query, 0 means no limit, api keys can override them with
+// their query_limits
+type queryLimits struct {
+	maxSegments int
+	maxDocs     int
+	maxTime     time.Duration
+}
+
+func (l queryLimits) forKey(key *auth.Key) queryLimits {
+	if key == nil {
+		return l
+	}
+	if key.QueryLimits.MaxSegments > 0 {
+		l.maxSegments = key.QueryLimits.MaxSegments
+	}
+	if key.QueryLimits.MaxDocs > 0 {
+		l.maxDocs = key.QueryLimits.MaxDocs
+	}
+	if key.QueryLimits.MaxTimeMs > 0 {
+		l.maxTime = time.Duration(key.QueryLimits.MaxTimeMs) * time.Millisecond
+	}
+	return l
+}
+
+// apply checks the number of segments the query spans before scanning
+// them, and lowers the max_docs and max_time_ms of the query to the
+// limits, ForEach stops at whichever is lower. The returned budget tells
+// afterwards if the query was cut off by the limits or by the budget the
+// client asked for.
+func (l queryLimits) apply(ctx context.Context, what string, segments int, qr *spec.SearchQueryRequest) (*queryBudget, error) {
+	l = l.forKey(auth.FromContext(ctx))
+	if l.maxSegments > 0 && segments > l.maxSegments {
+		return nil, status.Errorf(codes.ResourceExhausted, "%s: the query spans %d segments, the limit for %s is %d, narrow from_second and to_second", what, segments, ratelimit.Client(ctx), l.maxSegments)
+	}
+	b := &queryBudget{
+		what:    what,
+		client:  ratelimit.Client(ctx),
+		started: time.Now(),
+	}
+	maxTimeMs := int(l.maxTime / time.Millisecond)
+	b.limitDocs = clamp(qr.MaxDocs, l.maxDocs) != qr.MaxDocs
+	b.limitTime = clamp(qr.MaxTimeMs, maxTimeMs) != qr.MaxTimeMs
+	qr.MaxDocs = clamp(qr.MaxDocs, l.maxDocs)
+	qr.MaxTimeMs = clamp(qr.MaxTimeMs, maxTimeMs)
+	b.maxDocs = qr.MaxDocs
+	b.maxTime = time.Duration(qr.MaxTimeMs) * time.Millisecond
+	return b, nil
+}
+
+// the max_docs and max_time_ms a query ran with, limitDocs and limitTime
+// are set when they come from the limits and not from the client
+type queryBudget struct {
+	what      string
+	client    string
+	started   time.Time
+	maxDocs   uint32
+	maxTime   time.Duration
+	limitDocs bool
+	limitTime bool
+}
+
+// exceeded is called with what ForEach returned, a query cut off by the
+// budget the client asked for is answered as partial, one cut off by the
+// limits fails with ResourceExhausted
+func (b *queryBudget) exceeded(partial bool) error {
+	if !partial {
+		return nil
+	}
+	if b.maxTime > 0 && time.Since(b.started) >= b.maxTime {
+		if b.limitTime {
+			return status.Errorf(codes.ResourceExhausted, "%s: the query ran longer than %s, the limit for %s, narrow the query", b.what, b.maxTime, b.client)
+		}
+		return nil
+	}
+	if b.limitDocs {
+		return status.Errorf(codes.ResourceExhausted, "%s: the query matches more than %d documents, the limit for %s, narrow the query", b.what, b.maxDocs, b.client)
+	}
+	return nil
+}
+
+// the lower of the two, 0 means no limit
+func clamp(asked uint32, limit int) uint32 {
+	if limit <= 0 {
+		return asked
+	}
+	if asked == 0 || asked > uint32(limit) {
+		return uint32(limit)
+	}
+	return asked
+}
+
+// a query stopped because the client went away or its deadline passed gets
+// the matching grpc code instead of Unknown
+func queryError(err error) error {
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return status.FromContextError(err).Err()
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestQueryBudget(t *testing.T) {
+	cases := []struct {
+		limits    queryLimits
+		maxDocs   uint32
+		maxTimeMs uint32
+		elapsed   time.Duration
+		partial   bool
+		exhausted bool
+	}{
+		// not cut off
+		{queryLimits{maxDocs: 10}, 0, 0, 0, false, false},
+		// cut off by the limits
+		{queryLimits{maxDocs: 10}, 0, 0, 0, true, true},
+		{queryLimits{maxDocs: 10}, 20, 0, 0, true, true},
+		{queryLimits{maxTime: time.Second}, 0, 0, 2 * time.Second, true, true},
+		{queryLimits{maxTime: time.Second}, 0, 5000, 2 * time.Second, true, true},
+		// cut off by the budget of the client
+		{queryLimits{maxDocs: 10}, 5, 0, 0, true, false},
+		{queryLimits{}, 5, 0, 0, true, false},
+		{queryLimits{maxTime: time.Second}, 0, 500, 600 * time.Millisecond, true, false},
+		// the docs of the client before the time limit
+		{queryLimits{maxTime: time.Second}, 5, 0, 0, true, false},
+		// the docs limit before the time of the client
+		{queryLimits{maxDocs: 10}, 0, 5000, 0, true, true},
+	}
+	for i, c := range cases {
+		qr := &spec.SearchQueryRequest{MaxDocs: c.maxDocs, MaxTimeMs: c.maxTimeMs}
+		budget, err := c.limits.apply(context.Background(), "search", 1, qr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		budget.started = budget.started.Add(-c.elapsed)
+		err = budget.exceeded(c.partial)
+		if c.exhausted != (status.Code(err) == codes.ResourceExhausted) {
+			t.Fatalf("case %d: expected exhausted %v, got %v", i, c.exhausted, err)
+		}
+		if !c.exhausted && err != nil {
+			t.Fatalf("case %d: unexpected %v", i, err)
+		}
+	}
+}
@@ -1,31 +1,103 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"flag"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"os/signal"
+	"path"
 	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gogo/gateway"
 	"github.com/gogo/protobuf/proto"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/rekki/blackrock/pkg/auth"
 	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"github.com/rekki/blackrock/pkg/coordinator"
 
 	"github.com/rekki/blackrock/pkg/index"
 	. "github.com/rekki/blackrock/pkg/logger"
+	"github.com/rekki/blackrock/pkg/objectstore"
+	"github.com/rekki/blackrock/pkg/ratelimit"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type server struct {
-	si         *index.SearchIndex
-	ignoreType map[string]bool
+	tenants      *index.Tenants
+	ignoreType   map[string]bool
+	snapshotRoot string
+	backup       objectstore.Store
+	replica      *replica
+	ackEvery     int
+	limits       queryLimits
+	audit        *index.AuditLog
+	// with keys the tenant comes only from the key
+	authenticated bool
+	// replication covers only the index without tenant
+	multiTenant bool
+	// closed on shutdown, pushes are refused after that
+	stopping chan struct{}
+}
+
+var errShuttingDown = status.Error(codes.Unavailable, "shutting down, try again on another node or later")
+
+const tenantHeader = "blackrock-tenant"
+
+// the tenant of the api key, keys without a tenant are for the envelopes
+// without one. The blackrock-tenant metadata is used only when there is no
+// authentication.
+func (s *server) tenantOf(ctx context.Context) string {
+	if s.authenticated {
+		if key := auth.FromContext(ctx); key != nil {
+			return key.Tenant
+		}
+		return ""
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get(tenantHeader) {
+		return v
+	}
+	return ""
+}
+
+func (s *server) index(ctx context.Context) (*index.SearchIndex, error) {
+	si, err := s.tenants.Lookup(s.tenantOf(ctx))
+	if err != nil {
+		return nil, tenantStatus(err)
+	}
+	return si, nil
+}
+
+// envelopes without a tenant belong to the tenant of the push
+func (s *server) stampTenant(ctx context.Context, envelope *spec.Envelope) {
+	if envelope != nil && envelope.Metadata != nil && envelope.Metadata.Tenant == "" {
+		envelope.Metadata.Tenant = s.tenantOf(ctx)
+	}
+}
+
+func tenantStatus(err error) error {
+	switch err {
+	case index.ErrUnknownTenant:
+		return status.Error(codes.PermissionDenied, err.Error())
+	case index.ErrBadTenant:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case index.ErrOverQuota:
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return err
 }
 
 func (s *server) SaySearch(ctx context.Context, qr *spec.SearchQueryRequest) (*spec.SearchQueryResponse, error) {
@@ -33,8 +105,18 @@ func (s *server) SaySearch(ctx context.Context, qr *spec.SearchQueryRequest) (*s
 		Total: 0,
 	}
 
+	si, err := s.index(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err := s.limits.apply(ctx, "search", len(si.ExpandFromTo(qr.FromSecond, qr.ToSecond)), qr)
+	if err != nil {
+		return nil, err
+	}
+
 	scored := []spec.Hit{}
-	err := s.si.ForEach(qr, 0, func(segment *index.Segment, did int32, score float32) error {
+	out.Partial, err = si.ForEach(ctx, qr, 0, func(segment *index.Segment, did int32, score float32) error {
 		out.Total++
 		if qr.Limit == 0 {
 			return nil
@@ -73,6 +155,10 @@ func (s *server) SaySearch(ctx context.Context, qr *spec.SearchQueryRequest) (*s
 		}
 		return nil
 	})
+	if err != nil {
+		return nil, queryError(err)
+	}
+	err = budget.exceeded(out.Partial)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +172,17 @@ func (s *server) SaySearch(ctx context.Context, qr *spec.SearchQueryRequest) (*s
 }
 
 func (s *server) SayFetch(qr *spec.SearchQueryRequest, stream spec.Search_SayFetchServer) error {
-	err := s.si.ForEach(qr, uint32(qr.Limit), func(segment *index.Segment, did int32, score float32) error {
+	si, err := s.index(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	budget, err := s.limits.apply(stream.Context(), "fetch", len(si.ExpandFromTo(qr.FromSecond, qr.ToSecond)), qr)
+	if err != nil {
+		return err
+	}
+
+	partial, err := si.ForEach(stream.Context(), qr, uint32(qr.Limit), func(segment *index.Segment, did int32, score float32) error {
 		metadata := &spec.Metadata{}
 		err := segment.ReadForwardDecode(did, metadata)
 		if err != nil {
@@ -96,12 +192,26 @@ func (s *server) SayFetch(qr *spec.SearchQueryRequest, stream spec.Search_SayFet
 		hit := toHit(did, metadata)
 		return stream.Send(hit)
 	})
-
-	return err
+	if err != nil {
+		return queryError(err)
+	}
+	err = budget.exceeded(partial)
+	if err != nil {
+		return err
+	}
+	if partial {
+		stream.SetTrailer(metadata.Pairs(coordinator.PartialTrailer, "true"))
+	}
+	return nil
 }
 
 func (s *server) SayAggregate(ctx context.Context, qr *spec.AggregateRequest) (*spec.Aggregate, error) {
-	steps := s.si.ExpandFromTo(qr.Query.FromSecond, qr.Query.ToSecond)
+	si, err := s.index(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := si.ExpandFromTo(qr.Query.FromSecond, qr.Query.ToSecond)
 	dates := []time.Time{}
 	for _, ns := range steps {
 		dates = append(dates, time.Unix(ns/1000000000, 0))
@@ -109,6 +219,10 @@ func (s *server) SayAggregate(ctx context.Context, qr *spec.AggregateRequest) (*
 	if len(dates) == 0 {
 		return nil, errors.New("bad date range, to_second must be older than from_second")
 	}
+	budget, err := s.limits.apply(ctx, "aggregate", len(steps), qr.Query)
+	if err != nil {
+		return nil, err
+	}
 	out := &spec.Aggregate{
 		Search:    map[string]*spec.CountPerKV{},
 		Count:     map[string]*spec.CountPerKV{},
@@ -149,7 +263,7 @@ func (s *server) SayAggregate(ctx context.Context, qr *spec.AggregateRequest) (*
 		}
 	}
 
-	err := s.si.ForEach(qr.Query, 0, func(segment *index.Segment, did int32, score float32) error {
+	out.Partial, err = si.ForEach(ctx, qr.Query, 0, func(segment *index.Segment, did int32, score float32) error {
 		out.Total++
 
 		data, err := segment.ReadForward(did)
@@ -196,6 +310,10 @@ func (s *server) SayAggregate(ctx context.Context, qr *spec.AggregateRequest) (*
 		}
 		return nil
 	})
+	if err != nil {
+		return nil, queryError(err)
+	}
+	err = budget.exceeded(out.Partial)
 	if err != nil {
 		return nil, err
 	}
@@ -210,6 +328,9 @@ func (s *server) SayAggregate(ctx context.Context, qr *spec.AggregateRequest) (*
 }
 
 func (s *server) SayPush(stream spec.Search_SayPushServer) error {
+	if s.replica != nil && s.replica.following() {
+		return errFollower
+	}
 	for {
 		envelope, err := stream.Recv()
 		if err == io.EOF {
@@ -221,18 +342,181 @@ func (s *server) SayPush(stream spec.Search_SayPushServer) error {
 		if envelope.Metadata != nil && s.ignoreType[envelope.Metadata.EventType] {
 			continue
 		}
-		err = s.si.Ingest(envelope)
+		select {
+		case <-s.stopping:
+			return errShuttingDown
+		default:
+		}
+		s.stampTenant(stream.Context(), envelope)
+		err = s.tenants.Ingest(envelope)
 		if err != nil {
-			return err
+			return tenantStatus(err)
 		}
 	}
 	return stream.SendAndClose(&spec.Success{Success: true})
 }
 
+// SayPushAcked ingests a pipelined stream and acknowledges the highest
+// sequence that is fsynced, after ackEvery envelopes or as soon as the
+// client stops sending
+func (s *server) SayPushAcked(stream spec.Search_SayPushAckedServer) error {
+	if s.replica != nil && s.replica.following() {
+		return errFollower
+	}
+
+	received := make(chan *spec.SequencedEnvelope, s.ackEvery)
+	recvError := make(chan error, 1)
+	go func() {
+		defer close(received)
+		for {
+			e, err := stream.Recv()
+			if err != nil {
+				recvError <- err
+				return
+			}
+			select {
+			case received <- e:
+			case <-stream.Context().Done():
+				recvError <- status.FromContextError(stream.Context().Err()).Err()
+				return
+			}
+		}
+	}()
+
+	last := uint64(0)
+	started := false
+	unacked := 0
+	rejected := []*spec.RejectedEnvelope{}
+	ack := func() error {
+		if unacked == 0 {
+			return nil
+		}
+		err := s.tenants.Sync()
+		if err != nil {
+			return err
+		}
+		unacked = 0
+		err = stream.Send(&spec.PushAck{Sequence: last, Rejected: rejected})
+		rejected = []*spec.RejectedEnvelope{}
+		return err
+	}
+
+	// on shutdown acknowledge what is written and let the client reconnect
+	stop := func() error {
+		err := ack()
+		if err != nil {
+			return err
+		}
+		return errShuttingDown
+	}
+
+	for {
+		var e *spec.SequencedEnvelope
+		select {
+		case <-s.stopping:
+			return stop()
+		case e = <-received:
+		default:
+			// nothing waiting, ack what we have before blocking
+			err := ack()
+			if err != nil {
+				return err
+			}
+			select {
+			case <-s.stopping:
+				return stop()
+			case e = <-received:
+			}
+		}
+
+		if e == nil {
+			err := <-recvError
+			if err == io.EOF {
+				return ack()
+			}
+			return err
+		}
+
+		if started && e.Sequence <= last {
+			return status.Errorf(codes.InvalidArgument, "sequence %d is not after %d", e.Sequence, last)
+		}
+
+		envelope := e.Envelope
+		if envelope != nil && !(envelope.Metadata != nil && s.ignoreType[envelope.Metadata.EventType]) {
+			var err error
+			s.stampTenant(stream.Context(), envelope)
+			if e.PartitionId {
+				_, err = s.tenants.IngestPartitioned(envelope)
+			} else {
+				err = s.tenants.Ingest(envelope)
+			}
+			// refused for the tenant now, can be replayed from the dead letter topic later
+			if index.IsInvalidEnvelope(err) || index.IsTenantError(err) {
+				rejected = append(rejected, &spec.RejectedEnvelope{Sequence: e.Sequence, Error: err.Error()})
+			} else if err != nil {
+				return err
+			}
+		}
+
+		last = e.Sequence
+		started = true
+		unacked++
+		if unacked >= s.ackEvery {
+			err := ack()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
 func (s *server) SayHealth(context.Context, *spec.HealthRequest) (*spec.Success, error) {
 	return &spec.Success{Success: true}, nil
 }
 
+func (s *server) SayReindex(ctx context.Context, in *spec.ReindexRequest) (*spec.ReindexResponse, error) {
+	si, err := s.index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	documents, err := si.Reindex(in.FromSecond, in.ToSecond)
+	if err != nil {
+		return nil, err
+	}
+	return &spec.ReindexResponse{Documents: documents}, nil
+}
+
+func (s *server) SaySnapshot(ctx context.Context, in *spec.SnapshotRequest) (*spec.SnapshotResponse, error) {
+	if in.Upload && s.backup == nil {
+		return nil, errors.New("no backup store configured")
+	}
+
+	si, err := s.index(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name := time.Now().UTC().Format("20060102T150405Z")
+	if tenant := s.tenantOf(ctx); tenant != "" {
+		name = path.Join(tenant, name)
+	}
+	dir := path.Join(s.snapshotRoot, name)
+	manifest, err := si.Snapshot(dir, in.FromSecond, in.ToSecond)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &spec.SnapshotResponse{Name: name, Path: dir, Segments: uint32(len(manifest.Segments))}
+	if in.Upload {
+		err = index.UploadSnapshot(dir, s.backup, name)
+		if err != nil {
+			return nil, err
+		}
+		out.Uploaded = true
+	}
+	return out, nil
+}
+
 func toHit(did int32, p *spec.Metadata) *spec.Hit {
 	id := p.Id
 	if id == 0 {
@@ -257,6 +541,12 @@ func runProxy(bindHttp string, bindGrpc string) error {
 	}
 	mux := runtime.NewServeMux(
 		runtime.WithMarshalerOption(runtime.MIMEWildcard, jsonpb),
+		runtime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+			if strings.EqualFold(key, tenantHeader) {
+				return tenantHeader, true
+			}
+			return runtime.DefaultHeaderMatcher(key)
+		}),
 		// This is necessary to get error details properly
 		// marshalled in unary requests.
 		runtime.WithProtoErrorHandler(runtime.DefaultHTTPProtoErrorHandler),
@@ -278,19 +568,76 @@ func main() {
 	var bindGrpc = flag.String("grpc", ":8002", "bind to")
 
 	var logLevel = flag.Int("log-level", 0, "log level")
+	var keysFile = flag.String("keys", "", "json file with the api keys allowed to push and query, nothing means no authentication")
 	var segmentStep = flag.Int("segment-step", 3600, "segment step")
-	var maxOpenFD = flag.Int("max-open-fd", 1000, "max open file descriptors to write")
+	var maxOpenFD = flag.Int("max-open-fd", 1000, "max open file descriptors to write, shared by all tenants")
 	var pwhitelist = flag.String("whitelist", "", "csv list of indexable search terms, nothing means all")
 	var pignore = flag.String("ignore-type", "", "csv list of event types to ignore")
 	var enableSegmentCache = flag.Bool("enable-segment-cache", false, "enable memory cache")
+	var maxLoadedSegments = flag.Int("max-loaded-segments", 0, "max segments kept open, least recently used idle segments are closed, 0 means no limit")
+	var fsync = flag.String("fsync", "close", "fsync policy: close, always or every # milliseconds")
+	var evictIdle = flag.Int("evict-idle-segments", 0, "close segments not accessed in the last # seconds, 0 means never")
+	var snapshotRoot = flag.String("snapshot-root", "", "directory for snapshots, default is root/snapshots")
+	var tierStore = flag.String("tier-store", "", "object store for old segments, file:///path or s3://key:secret@host/bucket?region=")
+	var tierPrefix = flag.String("tier-prefix", "", "prefix of the segments in -tier-store")
+	var tierCacheMB = flag.Int("tier-cache-mb", 10240, "max megabytes of segments fetched from -tier-store kept on disk, 0 means no limit")
+	var offloadAfter = flag.Int("offload-after", 0, "move segments older than # seconds to -tier-store, 0 means never")
+	var pshards = flag.String("shards", "", "csv list of search nodes host:grpc_port, when set this process is a coordinator that queries them and stores nothing")
+	var shardTimeout = flag.Int("shard-timeout", 5000, "milliseconds to wait for a shard in coordinator mode, 0 means no limit")
+	var allowPartial = flag.Bool("allow-partial", true, "in coordinator mode answer with the shards that replied, marking the result as partial")
+	var dedupWindow = flag.Int("dedup-window", 0, "skip envelopes with an id seen in the last # seconds, 0 means no deduplication of client ids")
+	var ackEvery = flag.Int("ack-every", 1000, "fsync and acknowledge acked pushes at least every # envelopes")
+	var replicateFrom = flag.String("replicate-from", "", "follow the search node at host:grpc_port, pushes are refused until /api/v1/promote is called, only the data without tenant is replicated so it can not be used with tenants")
+	var replicateApiKey = flag.String("replicate-api-key", "", "api key for a leader started with -keys")
+	var replicateSince = flag.Int("replicate-since", 0, "replicate only segments after this unix second")
+	var backupStore = flag.String("backup-store", "", "object store for uploaded snapshots, file:///path or s3://key:secret@host/bucket?region=")
+	var tenantsFile = flag.String("tenants", "", "json file with the allowed tenants and their retention and quotas, nothing allows every tenant without limits")
+	var allowUnlisted = flag.Bool("allow-unlisted-tenants", false, "without -tenants accept pushes for any tenant, each new tenant gets its own index")
+	var enforceEvery = flag.Int("enforce-tenants-every", 60, "apply the tenant retention and quotas every # seconds")
+	var maxQuerySegments = flag.Int("max-query-segments", 0, "refuse queries spanning more than # segments, 0 means no limit, api keys can override it")
+	var maxQueryDocs = flag.Int("max-query-docs", 0, "fail queries matching more than # documents with ResourceExhausted, 0 means no limit, api keys can override it")
+	var maxQueryTime = flag.Int("max-query-time-ms", 0, "fail queries running longer than # milliseconds with ResourceExhausted, 0 means no limit, api keys can override it")
+	var queryRate = flag.Float64("query-rate", 0, "queries per second per api key, or per ip without -keys, 0 for no limit")
+	var trustedProxies = flag.String("trusted-proxies", "", "csv list of ips and cidrs of the proxies in front, only their x-forwarded-for is used to rate limit by ip")
+	var queryBurst = flag.Int("query-burst", 0, "queries a client can make at once above -query-rate, 0 means -query-rate")
+	var forgetAudit = flag.String("forget-audit", "", "append only log of the forgotten foreign ids and compactions, default is root/forget.log")
+	var forgetAuditKeyFile = flag.String("forget-audit-key-file", "", "file with the secret key of the hmac-sha256 of the foreign ids in -forget-audit, forget is refused without it")
+	var compactEvery = flag.Int("compact-every", 3600, "rewrite the segments with forgotten documents every # seconds, 0 means only when a forget asks for it")
+	var drainTimeout = flag.Int("drain-timeout", 30, "on SIGTERM wait # seconds for streams and writes in progress before closing")
 	flag.Parse()
 
 	LogInit(*logLevel)
+	drain := time.Duration(*drainTimeout) * time.Second
+	queryLimiter := ratelimit.New(*queryRate, *queryBurst)
+	var err error
+	ratelimit.TrustedProxies, err = ratelimit.ParseProxies(*trustedProxies)
+	if err != nil {
+		Log.Fatal(err)
+	}
+
+	var keys *auth.Keys
+	if *keysFile != "" {
+		var err error
+		keys, err = auth.Load(*keysFile)
+		if err != nil {
+			Log.Fatal(err)
+		}
+	}
 
 	go func() {
 		Log.Info(http.ListenAndServe("localhost:6060", nil))
 	}()
 
+	if *pshards != "" {
+		shards, err := coordinator.DialShards(strings.Split(*pshards, ","))
+		if err != nil {
+			Log.Fatal(err)
+		}
+		Log.Infof("coordinator for %d shards", len(shards))
+		serve(*bindHttp, *bindGrpc, &coordinator.Coordinator{Shards: shards, Timeout: time.Duration(*shardTimeout) * time.Millisecond, AllowPartial: *allowPartial}, keys, queryLimiter, drain, nil)
+		return
+	}
+
 	root := *proot
 	whitelist := map[string]bool{}
 	for _, v := range strings.Split(*pwhitelist, ",") {
@@ -306,24 +653,237 @@ func main() {
 		}
 	}
 
-	si := index.NewSearchIndex(root, *maxOpenFD, int64(*segmentStep), *enableSegmentCache, whitelist)
+	syncPolicy, syncInterval, err := index.ParseSyncPolicy(*fsync)
+	if err != nil {
+		Log.Fatal(err)
+	}
+
+	var store objectstore.Store
+	if *tierStore != "" {
+		store, err = objectstore.Open(*tierStore)
+		if err != nil {
+			Log.Fatal(err)
+		}
+	}
+
+	tenantsConfig := &index.TenantsConfig{AllowUnlisted: *allowUnlisted}
+	if *tenantsFile != "" {
+		tenantsConfig, err = index.LoadTenantsConfig(*tenantsFile)
+		if err != nil {
+			Log.Fatal(err)
+		}
+	}
+
+	// every tenant gets its own index configured the same way
+	fdc := index.NewFDCache(*maxOpenFD)
+	tenants, err := index.NewTenants(root, tenantsConfig, func(tenantRoot string) (*index.SearchIndex, error) {
+		si := index.NewSearchIndexWithFDCache(tenantRoot, fdc, int64(*segmentStep), *enableSegmentCache, whitelist)
+		si.MaxLoadedSegments = *maxLoadedSegments
+		si.DedupWindow = time.Duration(*dedupWindow) * time.Second
+		si.SetSyncPolicy(syncPolicy, syncInterval)
+		if store != nil {
+			prefix := *tierPrefix
+			if tenantRoot != root {
+				prefix = path.Join(prefix, path.Base(tenantRoot))
+			}
+			err := si.EnableTieredStorage(store, prefix, int64(*tierCacheMB)*1024*1024)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return si, nil
+	})
+	if err != nil {
+		Log.Fatal(err)
+	}
+
+	if syncPolicy == index.SyncInterval {
+		go func() {
+			for {
+				time.Sleep(syncInterval)
+				err := tenants.Sync()
+				if err != nil {
+					Log.Warnf("failed to sync, err: %s", err.Error())
+				}
+			}
+		}()
+	}
+	if *evictIdle > 0 {
+		go func() {
+			for {
+				time.Sleep(time.Duration(*evictIdle) * time.Second / 2)
+				_ = tenants.Each(func(tenant string, si *index.SearchIndex) error {
+					n := si.EvictIdle(time.Duration(*evictIdle) * time.Second)
+					if n > 0 {
+						Log.Infof("tenant %q: evicted %d idle segments", tenant, n)
+					}
+					return nil
+				})
+			}
+		}()
+	}
+	if store != nil && *offloadAfter > 0 {
+		go func() {
+			for {
+				_ = tenants.Each(func(tenant string, si *index.SearchIndex) error {
+					n, err := si.Offload(time.Duration(*offloadAfter) * time.Second)
+					if err != nil {
+						Log.Warnf("tenant %q: failed to offload segments, err: %s", tenant, err.Error())
+					} else if n > 0 {
+						Log.Infof("tenant %q: offloaded %d segments", tenant, n)
+					}
+					return nil
+				})
+				time.Sleep(10 * time.Minute)
+			}
+		}()
+	}
+	go func() {
+		for {
+			err := tenants.Enforce()
+			if err != nil {
+				Log.Warnf("failed to enforce tenant retention and quotas, err: %s", err.Error())
+			}
+			time.Sleep(time.Duration(*enforceEvery) * time.Second)
+		}
+	}()
+	if *snapshotRoot == "" {
+		*snapshotRoot = path.Join(root, "snapshots")
+	}
+	var backup objectstore.Store
+	if *backupStore != "" {
+		backup, err = objectstore.Open(*backupStore)
+		if err != nil {
+			Log.Fatal(err)
+		}
+	}
+
+	if *forgetAudit == "" {
+		*forgetAudit = path.Join(root, "forget.log")
+	}
+	var auditKey []byte
+	if *forgetAuditKeyFile != "" {
+		data, err := ioutil.ReadFile(*forgetAuditKeyFile)
+		if err != nil {
+			Log.Fatal(err)
+		}
+		auditKey = bytes.TrimSpace(data)
+		if len(auditKey) == 0 {
+			Log.Fatalf("%s is empty", *forgetAuditKeyFile)
+		}
+	}
+	audit, err := index.OpenAuditLog(*forgetAudit, auditKey)
+	if err != nil {
+		Log.Fatal(err)
+	}
+	defer audit.Close()
+
+	srv := &server{tenants: tenants, ignoreType: ignoreType, snapshotRoot: *snapshotRoot, backup: backup, ackEvery: *ackEvery, audit: audit, authenticated: keys != nil, multiTenant: *tenantsFile != "" || *allowUnlisted, stopping: make(chan struct{})}
+	srv.limits = queryLimits{
+		maxSegments: *maxQuerySegments,
+		maxDocs:     *maxQueryDocs,
+		maxTime:     time.Duration(*maxQueryTime) * time.Millisecond,
+	}
+	if *replicateFrom != "" {
+		// a promoted follower would have none of the tenant indexes
+		if srv.multiTenant {
+			Log.Fatal("-replicate-from replicates only the data without tenant, it can not be used with -tenants or -allow-unlisted-tenants")
+		}
+		_ = tenants.Each(func(tenant string, si *index.SearchIndex) error {
+			if tenant != "" {
+				Log.Fatalf("-replicate-from replicates only the data without tenant, but tenant %s is on disk", tenant)
+			}
+			return nil
+		})
+		si, err := tenants.Get("")
+		if err != nil {
+			Log.Fatal(err)
+		}
+		srv.replica = startReplica(si, *replicateFrom, *replicateApiKey, uint32(*replicateSince))
+	}
+	if *compactEvery > 0 {
+		go func() {
+			for {
+				time.Sleep(time.Duration(*compactEvery) * time.Second)
+				_ = tenants.Each(func(tenant string, si *index.SearchIndex) error {
+					if !srv.canCompact(tenant) {
+						return nil
+					}
+					n, err := srv.compact(tenant, si)
+					if err != nil {
+						Log.Warnf("tenant %q: failed to compact segments, err: %s", tenant, err.Error())
+					} else if n > 0 {
+						Log.Infof("tenant %q: compacted %d forgotten documents", tenant, n)
+					}
+					return nil
+				})
+			}
+		}()
+	}
+	serve(*bindHttp, *bindGrpc, srv, keys, queryLimiter, drain, srv.stopping)
+
+	if srv.replica != nil {
+		srv.replica.cancel()
+	}
+	err = tenants.Shutdown(drain)
+	if err != nil {
+		Log.Warnf("failed to close all segments, err: %s", err.Error())
+		os.Exit(1)
+	}
+	Log.Infof("stopped")
+}
+
+// serve returns after SIGINT or SIGTERM, stopping is closed first so
+// pushes stop, then the streams in progress get drain to finish
+func serve(bindHttp string, bindGrpc string, srv spec.SearchServer, keys *auth.Keys, queryLimiter *ratelimit.Limiter, drain time.Duration, stopping chan struct{}) {
 	go func() {
-		err := runProxy(*bindHttp, *bindGrpc)
+		err := runProxy(bindHttp, bindGrpc)
 		if err != nil {
 			Log.Warnf("failed to run the proxy, err: %s", err.Error())
 			os.Exit(0)
 		}
 	}()
 
-	lis, err := net.Listen("tcp", *bindGrpc)
+	lis, err := net.Listen("tcp", bindGrpc)
 	if err != nil {
 		Log.Fatalf("failed to listen: %v", err)
 	}
 
-	grpcServer := grpc.NewServer(AddLogging([]grpc.ServerOption{})...)
-
-	srv := &server{si: si, ignoreType: ignoreType}
+	// queries need a key too, its tenant decides what can be read
+	unary, stream := auth.Interceptors(keys, auth.Methods(auth.PushMethods, auth.QueryMethods, auth.AdminMethods))
+	// after authentication, so the limit is per key
+	unary = append(unary, ratelimit.UnaryServerInterceptor(queryLimiter, auth.QueryMethods))
+	stream = append(stream, ratelimit.StreamServerInterceptor(queryLimiter, auth.QueryMethods))
+	grpcServer := grpc.NewServer(AddLoggingWith([]grpc.ServerOption{}, unary, stream)...)
 	spec.RegisterSearchServer(grpcServer, srv)
+
+	stopped := make(chan bool)
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigs
+		Log.Warnf("received %s, shutting down...", sig)
+		if stopping != nil {
+			close(stopping)
+		}
+
+		drained := make(chan bool)
+		go func() {
+			grpcServer.GracefulStop()
+			close(drained)
+		}()
+		select {
+		case <-drained:
+		case <-time.After(drain):
+			Log.Warnf("streams still open after %s, closing them", drain)
+			grpcServer.Stop()
+		}
+		close(stopped)
+	}()
+
 	err = grpcServer.Serve(lis)
-	Log.Fatal(err)
+	if err != nil {
+		Log.Fatal(err)
+	}
+	<-stopped
 }
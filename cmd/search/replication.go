@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rekki/blackrock/pkg/auth"
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"github.com/rekki/blackrock/pkg/index"
+	. "github.com/rekki/blackrock/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// replica follows a leader until it is promoted. To fail over: make sure
+// the leader is down, call /api/v1/promote on the follower and point the
+// consumers to it. Documents the leader committed but did not send yet
+// are lost unless the consumers replay them.
+type replica struct {
+	leader     string
+	apiKey     string
+	fromSecond uint32
+	si         *index.SearchIndex
+	cancel     context.CancelFunc
+	status     spec.ReplicationStatus
+	sync.Mutex
+}
+
+func startReplica(si *index.SearchIndex, leader string, apiKey string, fromSecond uint32) *replica {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &replica{leader: leader, apiKey: apiKey, fromSecond: fromSecond, si: si, cancel: cancel}
+	r.status.Follower = true
+	r.status.Leader = leader
+
+	go func() {
+		for {
+			err := r.follow(ctx)
+			if ctx.Err() != nil {
+				Log.Infof("stopped replicating from %s", leader)
+				return
+			}
+
+			Log.Warnf("replication from %s failed, sleeping 1 second, err: %s", leader, err.Error())
+			r.Lock()
+			r.status.Connected = false
+			r.status.LastError = err.Error()
+			r.Unlock()
+			time.Sleep(1 * time.Second)
+		}
+	}()
+	return r
+}
+
+func (r *replica) follow(ctx context.Context) error {
+	opts := []grpc.DialOption{grpc.WithInsecure()}
+	if r.apiKey != "" {
+		opts = append(opts, auth.WithAPIKey(r.apiKey))
+	}
+	conn, err := grpc.Dial(r.leader, opts...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	positions, err := r.si.Positions()
+	if err != nil {
+		return err
+	}
+
+	stream, err := spec.NewSearchClient(conn).SayReplicate(ctx, &spec.ReplicateRequest{Positions: positions, FromSecond: r.fromSecond})
+	if err != nil {
+		return err
+	}
+
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(e.Data) > 0 || len(e.Tombstones) > 0 {
+			err = r.si.ApplyReplicated(e)
+			if err != nil {
+				return err
+			}
+		}
+
+		r.Lock()
+		r.status.Connected = true
+		r.status.LastReceivedNs = time.Now().UnixNano()
+		if len(e.Data) > 0 {
+			r.status.Applied++
+		} else if len(e.Tombstones) == 0 {
+			r.status.PendingBytes = e.PendingBytes
+			if e.PendingBytes == 0 {
+				r.status.CaughtUpAtNs = e.LeaderTimeNs
+			}
+		}
+		r.Unlock()
+	}
+}
+
+func (r *replica) following() bool {
+	r.Lock()
+	defer r.Unlock()
+	return r.status.Follower
+}
+
+func (r *replica) promote() {
+	r.cancel()
+
+	r.Lock()
+	defer r.Unlock()
+	r.status.Follower = false
+	r.status.Connected = false
+	Log.Warnf("promoted to leader, was following %s", r.leader)
+}
+
+func (r *replica) Status() *spec.ReplicationStatus {
+	r.Lock()
+	defer r.Unlock()
+	out := r.status
+	return &out
+}
+
+var errFollower = status.Error(codes.FailedPrecondition, "this node is a follower, push to the leader or promote it")
+var errNotFollower = status.Error(codes.FailedPrecondition, "this node is not a follower")
+
+var errMultiTenant = status.Error(codes.FailedPrecondition, "this node has tenants, replication covers only the data without tenant")
+
+func (s *server) SayReplicate(in *spec.ReplicateRequest, stream spec.Search_SayReplicateServer) error {
+	if s.multiTenant {
+		return errMultiTenant
+	}
+	si, err := s.index(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stopping:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return si.Tail(ctx, in.Positions, in.FromSecond, stream.Send)
+}
+
+func (s *server) SayReplicationStatus(ctx context.Context, in *spec.ReplicationStatusRequest) (*spec.ReplicationStatus, error) {
+	if s.replica == nil {
+		return &spec.ReplicationStatus{}, nil
+	}
+	return s.replica.Status(), nil
+}
+
+func (s *server) SayPromote(ctx context.Context, in *spec.PromoteRequest) (*spec.ReplicationStatus, error) {
+	if s.replica == nil || !s.replica.following() {
+		return nil, errNotFollower
+	}
+	s.replica.promote()
+	return s.replica.Status(), nil
+}
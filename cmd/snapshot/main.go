@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/rekki/blackrock/pkg/index"
+	. "github.com/rekki/blackrock/pkg/logger"
+	"github.com/rekki/blackrock/pkg/objectstore"
+)
+
+func main() {
+	var pstore = flag.String("store", "", "object store, file:///path or s3://key:secret@host/bucket?region=")
+	var upload = flag.String("upload", "", "upload a local snapshot directory, created with /api/v1/snapshot")
+	var prefix = flag.String("prefix", "", "name of the uploaded snapshot, default is the directory name")
+	var restore = flag.String("restore", "", "name of the snapshot to restore")
+	var into = flag.String("into", "/blackrock/data-topic", "root directory to restore into, existing segments are kept")
+	var tenant = flag.String("tenant", "", "restore into the index of this tenant, root/tenant/step, default is the tenant of the snapshot")
+	var list = flag.Bool("list", false, "list the snapshots in the store")
+	var logLevel = flag.Int("log-level", 0, "log level")
+	flag.Parse()
+
+	LogInit(*logLevel)
+
+	if *pstore == "" {
+		Log.Fatal("need -store")
+	}
+
+	store, err := objectstore.Open(*pstore)
+	if err != nil {
+		Log.Fatal(err)
+	}
+
+	switch {
+	case *list:
+		keys, err := store.List("")
+		if err != nil {
+			Log.Fatal(err)
+		}
+		for _, k := range keys {
+			if path.Base(k) == "manifest.json" {
+				fmt.Println(strings.TrimSuffix(path.Dir(k), "/"))
+			}
+		}
+
+	case *upload != "":
+		name := *prefix
+		if name == "" {
+			name = path.Base(path.Clean(*upload))
+		}
+		err = index.UploadSnapshot(*upload, store, name)
+		if err != nil {
+			Log.Fatal(err)
+		}
+		fmt.Printf("uploaded %s as %s\n", *upload, name)
+
+	case *restore != "":
+		manifest, err := index.RestoreSnapshot(store, *restore, *into, *tenant)
+		if err != nil {
+			Log.Fatal(err)
+		}
+		dir := *into
+		if *tenant != "" {
+			dir = path.Join(dir, *tenant)
+		} else if manifest.Tenant != "" {
+			dir = path.Join(dir, manifest.Tenant)
+		}
+		fmt.Printf("restored %d segments of %s into %s\n", len(manifest.Segments), *restore, dir)
+
+	default:
+		Log.Fatal("need one of -list, -upload or -restore")
+	}
+}
@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+)
+
+// Keys are loaded from a json file, a key is either an api key sent as
+//
+//	authorization: Bearer <key>
+//
+// or an id and a secret, used to sign the request:
+//
+//	authorization: HMAC <id>:<unix seconds>:<hex hmac-sha256(secret, "<id>:<unix seconds>:<method>:<hex sha256(body)>")>
+//
+// method is "POST /push/envelope" over http and the full grpc method,
+// "/blackrock.io.Search/SaySearch", over grpc. Grpc calls sign the sha256
+// of an empty body, their messages are not known when the call starts, so
+// a captured grpc signature can be replayed with other messages of the
+// same method until it is MaxSkew old, like a bearer key can.
+//
+// example:
+//
+//	{
+//	  "keys": [
+//	    {"key": "3b1f...", "tenant": "acme", "event_types": ["pageview", "click"]},
+//	    {"id": "billing", "secret": "9ac2...", "tenant": "acme", "query_limits": {"max_segments": 2160}},
+//	    {"key": "7d0e..."}
+//	  ]
+//	}
+//
+// A key without tenant is for internal services, it keeps the tenant the
+// envelopes already have. No event_types allows every event type.
+type Keys struct {
+	byKey map[string]*Key
+	byId  map[string]*Key
+
+	// how far the signed time can be from now
+	MaxSkew time.Duration
+}
+
+type Key struct {
+	Key        string   `json:"key"`
+	Id         string   `json:"id"`
+	Secret     string   `json:"secret"`
+	Tenant     string   `json:"tenant"`
+	EventTypes []string `json:"event_types"`
+	// overrides the query limits of the search server for this key
+	QueryLimits QueryLimits `json:"query_limits"`
+
+	eventTypes map[string]bool
+}
+
+// 0 keeps the limit of the server
+type QueryLimits struct {
+	MaxSegments int `json:"max_segments"`
+	MaxDocs     int `json:"max_docs"`
+	MaxTimeMs   int `json:"max_time_ms"`
+}
+
+var ErrUnauthenticated = errors.New("missing or invalid credentials")
+
+func Load(fn string) (*Keys, error) {
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	k, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", fn, err.Error())
+	}
+	return k, nil
+}
+
+func Parse(data []byte) (*Keys, error) {
+	in := struct {
+		Keys []*Key `json:"keys"`
+	}{}
+	err := json.Unmarshal(data, &in)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Keys{byKey: map[string]*Key{}, byId: map[string]*Key{}, MaxSkew: 5 * time.Minute}
+	for i, k := range in.Keys {
+		if (k.Key == "") == (k.Id == "") {
+			return nil, fmt.Errorf("key %d: needs either key, or id and secret", i)
+		}
+		if k.Id != "" && k.Secret == "" {
+			return nil, fmt.Errorf("key %d: id %s has no secret", i, k.Id)
+		}
+		if len(k.EventTypes) > 0 {
+			k.eventTypes = map[string]bool{}
+			for _, t := range k.EventTypes {
+				k.eventTypes[t] = true
+			}
+		}
+
+		if k.Key != "" {
+			if out.byKey[k.Key] != nil {
+				return nil, fmt.Errorf("key %d: duplicate key", i)
+			}
+			out.byKey[k.Key] = k
+		} else {
+			if out.byId[k.Id] != nil {
+				return nil, fmt.Errorf("key %d: duplicate id %s", i, k.Id)
+			}
+			out.byId[k.Id] = k
+		}
+	}
+	return out, nil
+}
+
+// Request is what an hmac signature covers
+type Request struct {
+	Method string
+	Body   []byte
+}
+
+// Authenticate checks the value of the authorization header, req is
+// only used by hmac signatures
+func (k *Keys) Authenticate(authorization string, req Request, now time.Time) (*Key, error) {
+	if strings.HasPrefix(authorization, "Bearer ") {
+		// constant time, do not leak how much of a key matched
+		given := strings.TrimPrefix(authorization, "Bearer ")
+		var found *Key
+		for key, v := range k.byKey {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(given)) == 1 {
+				found = v
+			}
+		}
+		if found == nil {
+			return nil, ErrUnauthenticated
+		}
+		return found, nil
+	}
+
+	if IsSigned(authorization) {
+		splitted := strings.Split(strings.TrimPrefix(authorization, "HMAC "), ":")
+		if len(splitted) != 3 {
+			return nil, ErrUnauthenticated
+		}
+		key, ok := k.byId[splitted[0]]
+		if !ok {
+			return nil, ErrUnauthenticated
+		}
+		ts, err := strconv.ParseInt(splitted[1], 10, 64)
+		if err != nil {
+			return nil, ErrUnauthenticated
+		}
+		skew := now.Sub(time.Unix(ts, 0))
+		if skew > k.MaxSkew || skew < -k.MaxSkew {
+			return nil, fmt.Errorf("signature time is %s away from now", skew)
+		}
+		given, err := hex.DecodeString(splitted[2])
+		if err != nil {
+			return nil, ErrUnauthenticated
+		}
+		if !hmac.Equal(given, signature(key.Secret, key.Id, ts, req)) {
+			return nil, ErrUnauthenticated
+		}
+		return key, nil
+	}
+
+	return nil, ErrUnauthenticated
+}
+
+func signature(secret, id string, ts int64, req Request) []byte {
+	body := sha256.Sum256(req.Body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id + ":" + strconv.FormatInt(ts, 10) + ":" + req.Method + ":" + hex.EncodeToString(body[:])))
+	return mac.Sum(nil)
+}
+
+// Sign returns the authorization header value for an id and a secret
+func Sign(id, secret string, req Request, now time.Time) string {
+	ts := now.Unix()
+	return fmt.Sprintf("HMAC %s:%d:%s", id, ts, hex.EncodeToString(signature(secret, id, ts, req)))
+}
+
+// IsSigned tells if the authorization header needs the request to be checked
+func IsSigned(authorization string) bool {
+	return strings.HasPrefix(authorization, "HMAC ")
+}
+
+// Name identifies the key in logs and limits without revealing it
+func (key *Key) Name() string {
+	if key.Id != "" {
+		return key.Id
+	}
+	sum := sha256.Sum256([]byte(key.Key))
+	return "key-" + hex.EncodeToString(sum[:4])
+}
+
+// Stamp checks that the key can push this event type and sets the tenant
+func (key *Key) Stamp(meta *spec.Metadata) error {
+	if meta == nil {
+		return nil
+	}
+	if key.eventTypes != nil && !key.eventTypes[meta.EventType] {
+		return fmt.Errorf("event_type %q is not allowed for this key", meta.EventType)
+	}
+	if key.Tenant != "" {
+		meta.Tenant = key.Tenant
+	}
+	return nil
+}
+
+type keyContext struct{}
+
+func NewContext(ctx context.Context, key *Key) context.Context {
+	return context.WithValue(ctx, keyContext{}, key)
+}
+
+// FromContext returns the key that authenticated the call, nil if
+// authentication is disabled
+func FromContext(ctx context.Context) *Key {
+	key, _ := ctx.Value(keyContext{}).(*Key)
+	return key
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var testKeys = `{
+  "keys": [
+    {"key": "abc", "tenant": "acme", "event_types": ["click"]},
+    {"id": "billing", "secret": "s3cr3t", "tenant": "acme"},
+    {"key": "internal"}
+  ]
+}`
+
+func TestAuthenticate(t *testing.T) {
+	keys, err := Parse([]byte(testKeys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1600000000, 0)
+
+	key, err := keys.Authenticate("Bearer abc", Request{}, now)
+	if err != nil || key.Tenant != "acme" {
+		t.Fatalf("expected acme, got %v %v", key, err)
+	}
+	for _, bad := range []string{"", "abc", "Bearer ab", "Bearer abcd", "HMAC billing", "HMAC billing:1:zz"} {
+		_, err = keys.Authenticate(bad, Request{}, now)
+		if err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+
+	req := Request{Method: "POST /push/envelope", Body: []byte(`{"metadata":{}}`)}
+	signed := Sign("billing", "s3cr3t", req, now)
+	key, err = keys.Authenticate(signed, req, now.Add(time.Minute))
+	if err != nil || key.Id != "billing" {
+		t.Fatalf("expected billing, got %v %v", key, err)
+	}
+	_, err = keys.Authenticate(signed, req, now.Add(time.Hour))
+	if err == nil {
+		t.Fatal("expected error for old signature")
+	}
+	_, err = keys.Authenticate(Sign("billing", "wrong", req, now), req, now)
+	if err == nil {
+		t.Fatal("expected error for wrong secret")
+	}
+	// the signature can not be replayed with another method or body
+	for _, other := range []Request{
+		{Method: "POST /push/flatten", Body: req.Body},
+		{Method: req.Method, Body: []byte(`{"metadata":{"tenant":"x"}}`)},
+		{Method: req.Method},
+	} {
+		_, err = keys.Authenticate(signed, other, now)
+		if err == nil {
+			t.Fatalf("expected error for %s %s", other.Method, other.Body)
+		}
+	}
+
+	for _, bad := range []string{
+		`{"keys": [{"tenant": "x"}]}`,
+		`{"keys": [{"id": "x"}]}`,
+		`{"keys": [{"key": "x", "id": "y", "secret": "z"}]}`,
+		`{"keys": [{"key": "x"}, {"key": "x"}]}`,
+	} {
+		_, err = Parse([]byte(bad))
+		if err == nil {
+			t.Fatalf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestStamp(t *testing.T) {
+	keys, err := Parse([]byte(testKeys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	tenant, _ := keys.Authenticate("Bearer abc", Request{}, now)
+	meta := &spec.Metadata{EventType: "click", Tenant: "other"}
+	if err := tenant.Stamp(meta); err != nil || meta.Tenant != "acme" {
+		t.Fatalf("expected acme, got %s %v", meta.Tenant, err)
+	}
+	if err := tenant.Stamp(&spec.Metadata{EventType: "view"}); err == nil {
+		t.Fatal("expected error for event type")
+	}
+
+	internal, _ := keys.Authenticate("Bearer internal", Request{}, now)
+	meta = &spec.Metadata{EventType: "view", Tenant: "other"}
+	if err := internal.Stamp(meta); err != nil || meta.Tenant != "other" {
+		t.Fatalf("expected other, got %s %v", meta.Tenant, err)
+	}
+}
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	envelopes []*spec.Envelope
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeStream) RecvMsg(m interface{}) error {
+	*m.(*spec.Envelope) = *s.envelopes[0]
+	s.envelopes = s.envelopes[1:]
+	return nil
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	keys, err := Parse([]byte(testKeys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	interceptor := StreamServerInterceptor(keys, PushMethods)
+	info := &grpc.StreamServerInfo{FullMethod: "/blackrock.io.Enqueue/SayPush"}
+
+	call := func(authorization string, eventTypes ...string) ([]*spec.Envelope, error) {
+		md := metadata.MD{}
+		if authorization != "" {
+			md = metadata.Pairs("authorization", authorization)
+		}
+		ss := &fakeStream{ctx: metadata.NewIncomingContext(context.Background(), md)}
+		for _, et := range eventTypes {
+			ss.envelopes = append(ss.envelopes, &spec.Envelope{Metadata: &spec.Metadata{EventType: et}})
+		}
+		out := []*spec.Envelope{}
+		err := interceptor(nil, ss, info, func(srv interface{}, stream grpc.ServerStream) error {
+			if FromContext(stream.Context()) == nil {
+				t.Fatal("expected the key in the context")
+			}
+			for range eventTypes {
+				e := &spec.Envelope{}
+				err := stream.RecvMsg(e)
+				if err != nil {
+					return err
+				}
+				out = append(out, e)
+			}
+			return nil
+		})
+		return out, err
+	}
+
+	_, err = call("")
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated, got %v", err)
+	}
+
+	out, err := call("Bearer abc", "click", "click")
+	if err != nil || len(out) != 2 || out[1].Metadata.Tenant != "acme" {
+		t.Fatalf("unexpected %v %v", out, err)
+	}
+
+	_, err = call("Bearer abc", "click", "view")
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied, got %v", err)
+	}
+
+	out, err = call(Sign("billing", "s3cr3t", Request{Method: info.FullMethod}, time.Now()), "click")
+	if err != nil || len(out) != 1 || out[0].Metadata.Tenant != "acme" {
+		t.Fatalf("unexpected %v %v", out, err)
+	}
+	_, err = call(Sign("billing", "s3cr3t", Request{Method: "/blackrock.io.Search/SayPush"}, time.Now()), "click")
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated for a signature of another method, got %v", err)
+	}
+
+	// only keys without a tenant act on the whole node
+	interceptor = StreamServerInterceptor(keys, AdminMethods)
+	info.FullMethod = "/blackrock.io.Search/SayReplicate"
+	_, err = call("Bearer abc")
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied, got %v", err)
+	}
+	_, err = call("Bearer internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info.FullMethod = "/blackrock.io.Search/SayForget"
+	_, err = call("Bearer abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info.FullMethod = "/blackrock.io.Search/SaySearch"
+	err = interceptor(nil, &fakeStream{ctx: context.Background()}, info, func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// PushMethods are the grpc methods that take envelopes
+var PushMethods = map[string]bool{
+	"/blackrock.io.Enqueue/SayPush":     true,
+	"/blackrock.io.Search/SayPush":      true,
+	"/blackrock.io.Search/SayPushAcked": true,
+}
+
+// QueryMethods read the events of a tenant
+var QueryMethods = map[string]bool{
+	"/blackrock.io.Search/SaySearch":    true,
+	"/blackrock.io.Search/SayFetch":     true,
+	"/blackrock.io.Search/SayAggregate": true,
+}
+
+// AdminMethods read or change the whole index of a tenant, or the node
+var AdminMethods = map[string]bool{
+	"/blackrock.io.Search/SayForget":            true,
+	"/blackrock.io.Search/SayReindex":           true,
+	"/blackrock.io.Search/SaySnapshot":          true,
+	"/blackrock.io.Search/SayReplicate":         true,
+	"/blackrock.io.Search/SayReplicationStatus": true,
+	"/blackrock.io.Search/SayPromote":           true,
+}
+
+// NodeMethods act on the whole node, only keys without a tenant can call them
+var NodeMethods = map[string]bool{
+	"/blackrock.io.Search/SayReplicate":         true,
+	"/blackrock.io.Search/SayReplicationStatus": true,
+	"/blackrock.io.Search/SayPromote":           true,
+}
+
+func Methods(sets ...map[string]bool) map[string]bool {
+	out := map[string]bool{}
+	for _, set := range sets {
+		for k, v := range set {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// a signature covers only the method, see Keys, the keys of a tenant can
+// not call NodeMethods
+func authenticate(ctx context.Context, keys *Keys, method string) (*Key, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		key, err := keys.Authenticate(v, Request{Method: method}, time.Now())
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if NodeMethods[method] && key.Tenant != "" {
+			return nil, status.Errorf(codes.PermissionDenied, "%s acts on the whole node, the key of tenant %s can not call it", method, key.Tenant)
+		}
+		return key, nil
+	}
+	return nil, status.Error(codes.Unauthenticated, ErrUnauthenticated.Error())
+}
+
+func UnaryServerInterceptor(keys *Keys, protected map[string]bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !protected[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		key, err := authenticate(ctx, keys, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(NewContext(ctx, key), req)
+	}
+}
+
+// StreamServerInterceptor also stamps every received envelope with the
+// key, an envelope with an event type the key can not push fails the
+// stream with PermissionDenied
+func StreamServerInterceptor(keys *Keys, protected map[string]bool) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !protected[info.FullMethod] {
+			return handler(srv, ss)
+		}
+		key, err := authenticate(ss.Context(), keys, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &stampingStream{ServerStream: ss, key: key, ctx: NewContext(ss.Context(), key)})
+	}
+}
+
+// Interceptors returns nothing when keys is nil, so servers can always
+// pass them to logger.AddLoggingWith
+func Interceptors(keys *Keys, protected map[string]bool) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor) {
+	if keys == nil {
+		return nil, nil
+	}
+	return []grpc.UnaryServerInterceptor{UnaryServerInterceptor(keys, protected)}, []grpc.StreamServerInterceptor{StreamServerInterceptor(keys, protected)}
+}
+
+type stampingStream struct {
+	grpc.ServerStream
+	key *Key
+	ctx context.Context
+}
+
+func (s *stampingStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *stampingStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err != nil {
+		return err
+	}
+
+	var envelope *spec.Envelope
+	switch v := m.(type) {
+	case *spec.Envelope:
+		envelope = v
+	case *spec.SequencedEnvelope:
+		envelope = v.Envelope
+	}
+	if envelope != nil {
+		err = s.key.Stamp(envelope.Metadata)
+		if err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+	return nil
+}
+
+type apiKey string
+
+func (k apiKey) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(k)}, nil
+}
+
+func (k apiKey) RequireTransportSecurity() bool {
+	return false
+}
+
+// WithAPIKey sends the api key with every call of the connection
+func WithAPIKey(key string) grpc.DialOption {
+	return grpc.WithPerRPCCredentials(apiKey(key))
+}
@@ -146,6 +146,8 @@ type Metadata struct {
 	ForeignType string            `protobuf:"bytes,10,opt,name=foreign_type,json=foreignType,proto3" json:"foreign_type,omitempty"`
 	Track       map[string]uint32 `protobuf:"bytes,11,rep,name=track,proto3" json:"track,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
 	Id          uint64            `protobuf:"fixed64,12,opt,name=id,proto3" json:"id,omitempty"`
+	// stamped from the api key of the push, see pkg/auth
+	Tenant string `protobuf:"bytes,13,opt,name=tenant,proto3" json:"tenant,omitempty"`
 }
 
 func (m *Metadata) Reset()         { *m = Metadata{} }
@@ -244,6 +246,13 @@ func (m *Metadata) GetId() uint64 {
 	return 0
 }
 
+func (m *Metadata) GetTenant() string {
+	if m != nil {
+		return m.Tenant
+	}
+	return ""
+}
+
 type SearchableMetadata struct {
 	Search      []KV              `protobuf:"bytes,1,rep,name=search,proto3" json:"search"`
 	EventType   string            `protobuf:"bytes,7,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
@@ -537,6 +546,10 @@ type SearchQueryRequest struct {
 	ToSecond   uint32                    `protobuf:"varint,2,opt,name=to_second,json=toSecond,proto3" json:"to_second,omitempty"`
 	Query      *go_query_index_dsl.Query `protobuf:"bytes,3,opt,name=query,proto3" json:"query,omitempty"`
 	Limit      int32                     `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	// stop after matching # documents or after # milliseconds and
+	// answer with what was found, marked as partial, 0 means no limit
+	MaxDocs   uint32 `protobuf:"varint,5,opt,name=max_docs,json=maxDocs,proto3" json:"max_docs,omitempty"`
+	MaxTimeMs uint32 `protobuf:"varint,6,opt,name=max_time_ms,json=maxTimeMs,proto3" json:"max_time_ms,omitempty"`
 }
 
 func (m *SearchQueryRequest) Reset()         { *m = SearchQueryRequest{} }
@@ -600,6 +613,20 @@ func (m *SearchQueryRequest) GetLimit() int32 {
 	return 0
 }
 
+func (m *SearchQueryRequest) GetMaxDocs() uint32 {
+	if m != nil {
+		return m.MaxDocs
+	}
+	return 0
+}
+
+func (m *SearchQueryRequest) GetMaxTimeMs() uint32 {
+	if m != nil {
+		return m.MaxTimeMs
+	}
+	return 0
+}
+
 type CountPerKV struct {
 	Count map[string]uint32 `protobuf:"bytes,1,rep,name=count,proto3" json:"count,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
 	Total uint32            `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
@@ -917,6 +944,10 @@ type Aggregate struct {
 	Total     uint32                 `protobuf:"varint,6,opt,name=total,proto3" json:"total,omitempty"`
 	Sample    []*Hit                 `protobuf:"bytes,7,rep,name=sample,proto3" json:"sample,omitempty"`
 	Chart     *Chart                 `protobuf:"bytes,8,opt,name=chart,proto3" json:"chart,omitempty"`
+	// set when max_docs or max_time_ms of the query stopped it, or by
+	// the coordinator when some shards did not answer
+	Partial      bool     `protobuf:"varint,9,opt,name=partial,proto3" json:"partial,omitempty"`
+	FailedShards []string `protobuf:"bytes,10,rep,name=failed_shards,json=failedShards,proto3" json:"failed_shards,omitempty"`
 }
 
 func (m *Aggregate) Reset()         { *m = Aggregate{} }
@@ -1008,9 +1039,27 @@ func (m *Aggregate) GetChart() *Chart {
 	return nil
 }
 
+func (m *Aggregate) GetPartial() bool {
+	if m != nil {
+		return m.Partial
+	}
+	return false
+}
+
+func (m *Aggregate) GetFailedShards() []string {
+	if m != nil {
+		return m.FailedShards
+	}
+	return nil
+}
+
 type SearchQueryResponse struct {
 	Hits  []*Hit `protobuf:"bytes,1,rep,name=hits,proto3" json:"hits,omitempty"`
 	Total uint64 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	// set when max_docs or max_time_ms stopped the query, or by the
+	// coordinator when some shards did not answer
+	Partial      bool     `protobuf:"varint,3,opt,name=partial,proto3" json:"partial,omitempty"`
+	FailedShards []string `protobuf:"bytes,4,rep,name=failed_shards,json=failedShards,proto3" json:"failed_shards,omitempty"`
 }
 
 func (m *SearchQueryResponse) Reset()         { *m = SearchQueryResponse{} }
@@ -1060,6 +1109,20 @@ func (m *SearchQueryResponse) GetTotal() uint64 {
 	return 0
 }
 
+func (m *SearchQueryResponse) GetPartial() bool {
+	if m != nil {
+		return m.Partial
+	}
+	return false
+}
+
+func (m *SearchQueryResponse) GetFailedShards() []string {
+	if m != nil {
+		return m.FailedShards
+	}
+	return nil
+}
+
 type Envelope struct {
 	Metadata *Metadata `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
 	Payload  []byte    `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"`
@@ -1112,15 +1175,62 @@ func (m *Envelope) GetPayload() []byte {
 	return nil
 }
 
+type EnvelopeBatch struct {
+	Envelopes []*Envelope `protobuf:"bytes,1,rep,name=envelopes,proto3" json:"envelopes,omitempty"`
+}
+
+func (m *EnvelopeBatch) Reset()         { *m = EnvelopeBatch{} }
+func (m *EnvelopeBatch) String() string { return proto.CompactTextString(m) }
+func (*EnvelopeBatch) ProtoMessage()    {}
+func (*EnvelopeBatch) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{16}
+}
+func (m *EnvelopeBatch) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *EnvelopeBatch) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_EnvelopeBatch.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *EnvelopeBatch) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EnvelopeBatch.Merge(m, src)
+}
+func (m *EnvelopeBatch) XXX_Size() int {
+	return m.Size()
+}
+func (m *EnvelopeBatch) XXX_DiscardUnknown() {
+	xxx_messageInfo_EnvelopeBatch.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EnvelopeBatch proto.InternalMessageInfo
+
+func (m *EnvelopeBatch) GetEnvelopes() []*Envelope {
+	if m != nil {
+		return m.Envelopes
+	}
+	return nil
+}
+
 type Success struct {
 	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// set by the Enqueue SayPush, success is false if any envelope is rejected
+	Accepted uint32              `protobuf:"varint,2,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected []*RejectedEnvelope `protobuf:"bytes,3,rep,name=rejected,proto3" json:"rejected,omitempty"`
 }
 
 func (m *Success) Reset()         { *m = Success{} }
 func (m *Success) String() string { return proto.CompactTextString(m) }
 func (*Success) ProtoMessage()    {}
 func (*Success) Descriptor() ([]byte, []int) {
-	return fileDescriptor_423806180556987f, []int{16}
+	return fileDescriptor_423806180556987f, []int{17}
 }
 func (m *Success) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1156,21 +1266,41 @@ func (m *Success) GetSuccess() bool {
 	return false
 }
 
-type HealthRequest struct {
+func (m *Success) GetAccepted() uint32 {
+	if m != nil {
+		return m.Accepted
+	}
+	return 0
 }
 
-func (m *HealthRequest) Reset()         { *m = HealthRequest{} }
-func (m *HealthRequest) String() string { return proto.CompactTextString(m) }
-func (*HealthRequest) ProtoMessage()    {}
-func (*HealthRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_423806180556987f, []int{17}
+func (m *Success) GetRejected() []*RejectedEnvelope {
+	if m != nil {
+		return m.Rejected
+	}
+	return nil
 }
-func (m *HealthRequest) XXX_Unmarshal(b []byte) error {
+
+type SequencedEnvelope struct {
+	// increasing per stream, e.g. the kafka offset
+	Sequence uint64    `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Envelope *Envelope `protobuf:"bytes,2,opt,name=envelope,proto3" json:"envelope,omitempty"`
+	// metadata.id is partition<<56 | offset, offsets of a partition that
+	// are already ingested are skipped
+	PartitionId bool `protobuf:"varint,3,opt,name=partition_id,json=partitionId,proto3" json:"partition_id,omitempty"`
+}
+
+func (m *SequencedEnvelope) Reset()         { *m = SequencedEnvelope{} }
+func (m *SequencedEnvelope) String() string { return proto.CompactTextString(m) }
+func (*SequencedEnvelope) ProtoMessage()    {}
+func (*SequencedEnvelope) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{18}
+}
+func (m *SequencedEnvelope) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
 }
-func (m *HealthRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+func (m *SequencedEnvelope) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	if deterministic {
-		return xxx_messageInfo_HealthRequest.Marshal(b, m, deterministic)
+		return xxx_messageInfo_SequencedEnvelope.Marshal(b, m, deterministic)
 	} else {
 		b = b[:cap(b)]
 		n, err := m.MarshalToSizedBuffer(b)
@@ -1180,2182 +1310,6735 @@ func (m *HealthRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error
 		return b[:n], nil
 	}
 }
-func (m *HealthRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_HealthRequest.Merge(m, src)
+func (m *SequencedEnvelope) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SequencedEnvelope.Merge(m, src)
 }
-func (m *HealthRequest) XXX_Size() int {
+func (m *SequencedEnvelope) XXX_Size() int {
 	return m.Size()
 }
-func (m *HealthRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_HealthRequest.DiscardUnknown(m)
+func (m *SequencedEnvelope) XXX_DiscardUnknown() {
+	xxx_messageInfo_SequencedEnvelope.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_HealthRequest proto.InternalMessageInfo
+var xxx_messageInfo_SequencedEnvelope proto.InternalMessageInfo
 
-func init() {
-	proto.RegisterType((*KV)(nil), "blackrock.io.KV")
-	golang_proto.RegisterType((*KV)(nil), "blackrock.io.KV")
-	proto.RegisterType((*KF)(nil), "blackrock.io.KF")
-	golang_proto.RegisterType((*KF)(nil), "blackrock.io.KF")
-	proto.RegisterType((*Metadata)(nil), "blackrock.io.Metadata")
-	golang_proto.RegisterType((*Metadata)(nil), "blackrock.io.Metadata")
-	proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.Metadata.TrackEntry")
-	golang_proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.Metadata.TrackEntry")
-	proto.RegisterType((*SearchableMetadata)(nil), "blackrock.io.SearchableMetadata")
-	golang_proto.RegisterType((*SearchableMetadata)(nil), "blackrock.io.SearchableMetadata")
-	proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.SearchableMetadata.TrackEntry")
-	golang_proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.SearchableMetadata.TrackEntry")
-	proto.RegisterType((*BasicMetadata)(nil), "blackrock.io.BasicMetadata")
-	golang_proto.RegisterType((*BasicMetadata)(nil), "blackrock.io.BasicMetadata")
-	proto.RegisterType((*CountableMetadata)(nil), "blackrock.io.CountableMetadata")
-	golang_proto.RegisterType((*CountableMetadata)(nil), "blackrock.io.CountableMetadata")
-	proto.RegisterType((*Hit)(nil), "blackrock.io.Hit")
-	golang_proto.RegisterType((*Hit)(nil), "blackrock.io.Hit")
-	proto.RegisterType((*SearchQueryRequest)(nil), "blackrock.io.SearchQueryRequest")
-	golang_proto.RegisterType((*SearchQueryRequest)(nil), "blackrock.io.SearchQueryRequest")
-	proto.RegisterType((*CountPerKV)(nil), "blackrock.io.CountPerKV")
-	golang_proto.RegisterType((*CountPerKV)(nil), "blackrock.io.CountPerKV")
-	proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.CountPerKV.CountEntry")
-	golang_proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.CountPerKV.CountEntry")
-	proto.RegisterType((*PointPerEventType)(nil), "blackrock.io.PointPerEventType")
-	golang_proto.RegisterType((*PointPerEventType)(nil), "blackrock.io.PointPerEventType")
-	proto.RegisterType((*ChartBucketPerTime)(nil), "blackrock.io.ChartBucketPerTime")
-	golang_proto.RegisterType((*ChartBucketPerTime)(nil), "blackrock.io.ChartBucketPerTime")
-	proto.RegisterMapType((map[string]*PointPerEventType)(nil), "blackrock.io.ChartBucketPerTime.PerTypeEntry")
-	golang_proto.RegisterMapType((map[string]*PointPerEventType)(nil), "blackrock.io.ChartBucketPerTime.PerTypeEntry")
-	proto.RegisterType((*Chart)(nil), "blackrock.io.Chart")
-	golang_proto.RegisterType((*Chart)(nil), "blackrock.io.Chart")
-	proto.RegisterMapType((map[uint32]*ChartBucketPerTime)(nil), "blackrock.io.Chart.BucketsEntry")
-	golang_proto.RegisterMapType((map[uint32]*ChartBucketPerTime)(nil), "blackrock.io.Chart.BucketsEntry")
-	proto.RegisterType((*AggregateRequest)(nil), "blackrock.io.AggregateRequest")
-	golang_proto.RegisterType((*AggregateRequest)(nil), "blackrock.io.AggregateRequest")
-	proto.RegisterMapType((map[string]bool)(nil), "blackrock.io.AggregateRequest.FieldsEntry")
-	golang_proto.RegisterMapType((map[string]bool)(nil), "blackrock.io.AggregateRequest.FieldsEntry")
-	proto.RegisterType((*Aggregate)(nil), "blackrock.io.Aggregate")
-	golang_proto.RegisterType((*Aggregate)(nil), "blackrock.io.Aggregate")
-	proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.CountEntry")
-	golang_proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.CountEntry")
-	proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.EventTypeEntry")
-	golang_proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.EventTypeEntry")
-	proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.ForeignIdEntry")
-	golang_proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.ForeignIdEntry")
-	proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.Aggregate.PossibleEntry")
-	golang_proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.Aggregate.PossibleEntry")
-	proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.SearchEntry")
-	golang_proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.SearchEntry")
-	proto.RegisterType((*SearchQueryResponse)(nil), "blackrock.io.SearchQueryResponse")
-	golang_proto.RegisterType((*SearchQueryResponse)(nil), "blackrock.io.SearchQueryResponse")
-	proto.RegisterType((*Envelope)(nil), "blackrock.io.Envelope")
-	golang_proto.RegisterType((*Envelope)(nil), "blackrock.io.Envelope")
-	proto.RegisterType((*Success)(nil), "blackrock.io.Success")
-	golang_proto.RegisterType((*Success)(nil), "blackrock.io.Success")
-	proto.RegisterType((*HealthRequest)(nil), "blackrock.io.HealthRequest")
-	golang_proto.RegisterType((*HealthRequest)(nil), "blackrock.io.HealthRequest")
+func (m *SequencedEnvelope) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
 }
 
-func init() { proto.RegisterFile("spec.proto", fileDescriptor_423806180556987f) }
-func init() { golang_proto.RegisterFile("spec.proto", fileDescriptor_423806180556987f) }
-
-var fileDescriptor_423806180556987f = []byte{
-	// 1412 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x58, 0xcd, 0x6f, 0x13, 0x47,
-	0x1b, 0xcf, 0xda, 0xf1, 0xd7, 0x63, 0x1b, 0xc8, 0xc0, 0x0b, 0x8b, 0xe1, 0x75, 0x9c, 0x45, 0x20,
-	0x93, 0x97, 0xac, 0xdf, 0xa6, 0x2a, 0x85, 0x70, 0x4a, 0x90, 0xa3, 0x54, 0xb4, 0x55, 0xba, 0xa6,
-	0x51, 0x25, 0x2a, 0x59, 0xe3, 0xf5, 0xc4, 0x5e, 0x79, 0xbd, 0xb3, 0xd9, 0x1d, 0x47, 0xf5, 0xb5,
-	0xed, 0x1f, 0x80, 0xd4, 0x1e, 0xda, 0x63, 0xb9, 0xf5, 0xc6, 0xa9, 0x97, 0x5e, 0x7a, 0xe4, 0x88,
-	0x54, 0x55, 0xea, 0xa9, 0x42, 0xa4, 0x7f, 0x48, 0xb5, 0x33, 0xb3, 0xf6, 0xae, 0x3f, 0x12, 0xa0,
-	0x41, 0xe2, 0xc4, 0xce, 0xf3, 0xfc, 0x9e, 0x8f, 0xf9, 0x3d, 0x1f, 0x63, 0x02, 0xe0, 0xbb, 0xc4,
-	0xd4, 0x5d, 0x8f, 0x32, 0x8a, 0x0a, 0x2d, 0x1b, 0x9b, 0x3d, 0x8f, 0x9a, 0x3d, 0xdd, 0xa2, 0xa5,
-	0xb5, 0x8e, 0xc5, 0xba, 0x83, 0x96, 0x6e, 0xd2, 0x7e, 0xad, 0x43, 0x3b, 0xb4, 0xc6, 0x41, 0xad,
-	0xc1, 0x3e, 0x3f, 0xf1, 0x03, 0xff, 0x12, 0xc6, 0x31, 0xb8, 0x47, 0x7a, 0x3d, 0xab, 0xd6, 0xa1,
-	0x6b, 0x07, 0x03, 0xe2, 0x0d, 0xd7, 0x2c, 0xa7, 0x4d, 0xbe, 0x5a, 0x6b, 0xfb, 0x76, 0xad, 0xed,
-	0xdb, 0x12, 0x7e, 0xb5, 0x43, 0x69, 0xc7, 0x26, 0x35, 0xec, 0x5a, 0x35, 0xec, 0x38, 0x94, 0x61,
-	0x66, 0x51, 0xc7, 0x17, 0x5a, 0xed, 0x16, 0x24, 0x1e, 0xec, 0xa1, 0x73, 0x90, 0xec, 0x91, 0xa1,
-	0xaa, 0x54, 0x94, 0x6a, 0xce, 0x08, 0x3e, 0xd1, 0x05, 0x48, 0x1d, 0x62, 0x7b, 0x40, 0xd4, 0x04,
-	0x97, 0x89, 0x03, 0x47, 0x6f, 0x9f, 0x84, 0x56, 0x42, 0xf4, 0x2f, 0x49, 0xc8, 0x7e, 0x42, 0x18,
-	0x6e, 0x63, 0x86, 0x91, 0x0e, 0x69, 0x9f, 0x60, 0xcf, 0xec, 0xaa, 0x4a, 0x25, 0x59, 0xcd, 0xaf,
-	0x9f, 0xd3, 0xa3, 0x1c, 0xe8, 0x0f, 0xf6, 0xb6, 0x16, 0x9f, 0xfd, 0xb5, 0xbc, 0x60, 0x48, 0x14,
-	0xba, 0x05, 0x29, 0x93, 0x0e, 0x1c, 0xa6, 0x26, 0x8e, 0x85, 0x0b, 0x10, 0xba, 0x0d, 0xe0, 0x7a,
-	0xd4, 0x25, 0x1e, 0xb3, 0x88, 0xaf, 0x26, 0x8f, 0x35, 0x89, 0x20, 0x91, 0x06, 0x45, 0xd3, 0x23,
-	0x98, 0x91, 0x76, 0x13, 0xb3, 0xa6, 0xe3, 0xab, 0xa9, 0x8a, 0x52, 0x4d, 0x1a, 0x79, 0x29, 0xdc,
-	0x64, 0x9f, 0xfa, 0xe8, 0xbf, 0x00, 0xe4, 0x90, 0x38, 0xac, 0xc9, 0x86, 0x2e, 0x51, 0x33, 0xfc,
-	0xd6, 0x39, 0x2e, 0x79, 0x38, 0x74, 0x49, 0xa0, 0xde, 0xa7, 0x1e, 0xb1, 0x3a, 0x4e, 0xd3, 0x6a,
-	0xab, 0x39, 0xa1, 0x96, 0x92, 0x8f, 0xda, 0x68, 0x05, 0x0a, 0xa1, 0x9a, 0xdb, 0x03, 0x07, 0xe4,
-	0xa5, 0x8c, 0x7b, 0xf8, 0x10, 0x52, 0xcc, 0xc3, 0x66, 0x4f, 0xcd, 0xf3, 0xbc, 0x57, 0xe2, 0x79,
-	0x87, 0x0c, 0xea, 0x0f, 0x03, 0x4c, 0xdd, 0x61, 0xde, 0xd0, 0x10, 0x78, 0x74, 0x06, 0x12, 0x56,
-	0x5b, 0x2d, 0x54, 0x94, 0x6a, 0xda, 0x48, 0x58, 0xed, 0xd2, 0x1d, 0x80, 0x31, 0xe8, 0xa4, 0x32,
-	0x15, 0x65, 0x99, 0x36, 0x12, 0x77, 0x94, 0x8d, 0xc2, 0xf3, 0x9f, 0x96, 0x17, 0x1e, 0x3f, 0x59,
-	0x5e, 0xf8, 0xe1, 0xc9, 0xf2, 0x82, 0xf6, 0x34, 0x01, 0xa8, 0xc1, 0xcb, 0x80, 0x5b, 0x36, 0x79,
-	0xe3, 0x12, 0xbe, 0x75, 0xe2, 0x36, 0xe3, 0xc4, 0xfd, 0x2f, 0x9e, 0xcf, 0xf4, 0x0d, 0xa6, 0x29,
-	0x3c, 0x35, 0xca, 0x9e, 0x28, 0x50, 0xdc, 0xc2, 0xbe, 0x65, 0x8e, 0xd8, 0x7a, 0x17, 0x5a, 0x6b,
-	0x22, 0xc9, 0x6f, 0x13, 0xb0, 0x74, 0x3f, 0x98, 0x97, 0x7f, 0x55, 0xd6, 0xd7, 0x9b, 0xcc, 0x77,
-	0x90, 0x86, 0x26, 0x24, 0x77, 0x2c, 0x26, 0xa7, 0x27, 0xa8, 0xf5, 0x62, 0x30, 0x3d, 0x41, 0xa9,
-	0x7d, 0x93, 0x7a, 0xa2, 0xd4, 0x09, 0x43, 0x1c, 0xd0, 0x3a, 0x64, 0xfb, 0x92, 0x29, 0x35, 0x59,
-	0x51, 0xaa, 0xf9, 0xf5, 0x8b, 0xb3, 0xe7, 0xd3, 0x18, 0xe1, 0xb4, 0x1f, 0x95, 0x70, 0x7e, 0x3e,
-	0x0b, 0xd6, 0xb2, 0x41, 0x0e, 0x06, 0xc4, 0x67, 0x68, 0x19, 0xf2, 0xfb, 0x1e, 0xed, 0x37, 0x7d,
-	0x62, 0x52, 0x47, 0x44, 0x2e, 0x1a, 0x10, 0x88, 0x1a, 0x5c, 0x82, 0xae, 0x40, 0x8e, 0xd1, 0x50,
-	0x2d, 0x1a, 0x2e, 0xcb, 0xa8, 0x54, 0xd6, 0x20, 0xc5, 0x97, 0xbc, 0xcc, 0xe2, 0xb2, 0xde, 0xa1,
-	0x3a, 0x17, 0xe8, 0x7c, 0xeb, 0xeb, 0xc1, 0xc6, 0x17, 0xe1, 0x04, 0x2e, 0xb8, 0x8f, 0x6d, 0xf5,
-	0x2d, 0xa6, 0x2e, 0x56, 0x94, 0x6a, 0xca, 0x10, 0x07, 0xed, 0x67, 0x05, 0x80, 0xf7, 0xc0, 0x2e,
-	0xf1, 0x1e, 0xec, 0xa1, 0xbb, 0x61, 0x31, 0x45, 0xed, 0xaf, 0xc5, 0xef, 0x36, 0x06, 0x8a, 0x4f,
-	0x39, 0x3a, 0xa2, 0xb2, 0x17, 0x20, 0xc5, 0x28, 0xc3, 0x76, 0x38, 0x1a, 0xfc, 0x10, 0x8e, 0x50,
-	0x72, 0x34, 0x42, 0xc1, 0x88, 0x8d, 0x8d, 0x5f, 0x67, 0xc4, 0xb4, 0x6f, 0x14, 0x58, 0xda, 0xa5,
-	0x16, 0x4f, 0xa1, 0x3e, 0x6a, 0x87, 0x0b, 0xe3, 0x94, 0x39, 0x5e, 0x64, 0xb3, 0x02, 0x05, 0xfe,
-	0xd1, 0x1c, 0x38, 0xd6, 0xc1, 0xc8, 0x59, 0x9e, 0xcb, 0x3e, 0xe7, 0x22, 0x74, 0x11, 0xd2, 0xad,
-	0x81, 0xd9, 0x23, 0x8c, 0x67, 0x57, 0x34, 0xe4, 0x69, 0xa2, 0xfd, 0x16, 0x27, 0xda, 0x4f, 0xfb,
-	0x55, 0x01, 0x74, 0xbf, 0x8b, 0x3d, 0xb6, 0xc5, 0xe1, 0xbb, 0xc4, 0x7b, 0x68, 0xf5, 0x09, 0xda,
-	0x81, 0xac, 0x4b, 0x3c, 0x61, 0x23, 0xc8, 0x5b, 0x9b, 0x20, 0x6f, 0xca, 0x46, 0x0f, 0xfe, 0x1d,
-	0xba, 0x44, 0xd0, 0x98, 0x71, 0xc5, 0xa9, 0xf4, 0x08, 0x0a, 0x51, 0xc5, 0x0c, 0x8a, 0x3e, 0x88,
-	0x52, 0x94, 0x5f, 0x5f, 0x8e, 0x07, 0x9a, 0xa2, 0x28, 0xc6, 0x61, 0x02, 0x52, 0x3c, 0x13, 0xb4,
-	0x01, 0x19, 0x71, 0x61, 0x5f, 0xe6, 0x5b, 0x99, 0x91, 0xaf, 0x2e, 0x12, 0xf6, 0x65, 0x8a, 0xd2,
-	0x20, 0xa0, 0x88, 0x59, 0x7d, 0xd2, 0xf4, 0x19, 0xf6, 0x98, 0xe4, 0x36, 0x17, 0x48, 0x1a, 0x81,
-	0x00, 0x5d, 0x86, 0x2c, 0x57, 0x13, 0xa7, 0x2d, 0xb9, 0xcd, 0x04, 0xe7, 0xba, 0xd3, 0x46, 0x37,
-	0xe0, 0x2c, 0x57, 0x09, 0x4f, 0x41, 0x73, 0x73, 0x86, 0x8b, 0x46, 0x31, 0x10, 0x8b, 0x68, 0x0d,
-	0x62, 0x96, 0xbe, 0x84, 0x42, 0x34, 0x74, 0x94, 0x84, 0xa2, 0x20, 0xe1, 0x76, 0x9c, 0x84, 0xca,
-	0x49, 0x6c, 0x47, 0x59, 0xf8, 0x3e, 0x01, 0xe7, 0x36, 0x3b, 0x1d, 0x8f, 0x74, 0x30, 0x23, 0xe1,
-	0x3c, 0xde, 0x0e, 0x27, 0x4a, 0x99, 0xe5, 0x70, 0x7a, 0x80, 0xc3, 0xc1, 0xda, 0x82, 0xf4, 0xbe,
-	0x45, 0xec, 0xb6, 0x2f, 0x37, 0xe0, 0x6a, 0xdc, 0x70, 0x32, 0x8e, 0xbe, 0xcd, 0xc1, 0x82, 0x51,
-	0x69, 0x19, 0xb4, 0xab, 0x8f, 0xfb, 0xae, 0x4d, 0x9a, 0x62, 0x46, 0x93, 0x7c, 0x46, 0xf3, 0x42,
-	0xf6, 0x71, 0x20, 0x7a, 0x65, 0xe6, 0xee, 0x42, 0x3e, 0x12, 0xe1, 0xa4, 0x01, 0xcb, 0x46, 0x69,
-	0xf9, 0x23, 0x0d, 0xb9, 0x51, 0xba, 0xe8, 0xde, 0xc4, 0x43, 0x70, 0x6d, 0xce, 0xbd, 0x24, 0x35,
-	0xf2, 0x42, 0xf2, 0x55, 0xb8, 0x13, 0x7f, 0x15, 0xb4, 0x79, 0xb6, 0xd3, 0x7b, 0xa4, 0x1e, 0x5b,
-	0xef, 0xe2, 0xb7, 0xdb, 0x8d, 0x79, 0xe6, 0xdb, 0xe1, 0xda, 0x17, 0x2e, 0x22, 0xcf, 0x40, 0x7d,
-	0x62, 0x8a, 0x8f, 0x75, 0x33, 0x1a, 0x15, 0xe9, 0x66, 0xfc, 0xd8, 0x6c, 0x42, 0xd6, 0xa5, 0xbe,
-	0x6f, 0xb5, 0x6c, 0xa2, 0xa6, 0xb8, 0x93, 0xeb, 0xf3, 0x9c, 0xec, 0x4a, 0x9c, 0xf0, 0x31, 0x32,
-	0x1b, 0x2f, 0xc6, 0x74, 0x74, 0x31, 0xde, 0x84, 0xb4, 0xa8, 0xae, 0x9a, 0xe1, 0x6e, 0x97, 0xe2,
-	0x6e, 0x77, 0x2c, 0x66, 0x48, 0x00, 0xba, 0x09, 0x29, 0x33, 0x68, 0x67, 0x35, 0xcb, 0x1b, 0xf3,
-	0xfc, 0x8c, 0x4e, 0x37, 0x04, 0xa2, 0xd4, 0x80, 0x7c, 0xa4, 0x1a, 0x33, 0x8a, 0xaf, 0xc7, 0xa7,
-	0x46, 0x9d, 0xb7, 0xe0, 0x23, 0x6d, 0x51, 0x32, 0x4e, 0xd8, 0xd8, 0x6f, 0xe2, 0x73, 0x0f, 0xce,
-	0xc4, 0x6b, 0x77, 0x7a, 0x7e, 0xe3, 0xc5, 0x3c, 0x25, 0xbf, 0xf7, 0xa0, 0x18, 0xab, 0xef, 0x6b,
-	0x3d, 0x5c, 0x06, 0x9c, 0x8f, 0xad, 0x0f, 0xdf, 0xa5, 0x8e, 0x4f, 0xd0, 0x75, 0x58, 0xec, 0x5a,
-	0xa3, 0xf5, 0x3b, 0xa3, 0x01, 0xb8, 0x3a, 0xfe, 0xb0, 0x2e, 0xca, 0xfe, 0xd1, 0xbe, 0x80, 0x6c,
-	0xdd, 0x39, 0x24, 0x36, 0x75, 0xe3, 0x3f, 0x4a, 0x94, 0x57, 0xfb, 0x51, 0x82, 0x54, 0xc8, 0xb8,
-	0x78, 0x68, 0x53, 0x2c, 0x7e, 0x5a, 0x14, 0x8c, 0xf0, 0xa8, 0x5d, 0x83, 0x4c, 0x63, 0x60, 0x9a,
-	0xc4, 0xf7, 0x03, 0x90, 0x2f, 0x3e, 0xb9, 0xdf, 0xac, 0x11, 0x1e, 0xb5, 0xb3, 0x50, 0xdc, 0x21,
-	0xd8, 0x66, 0x5d, 0xb9, 0xd5, 0xd6, 0x9f, 0x2a, 0x90, 0xa9, 0x3b, 0x07, 0x03, 0x32, 0x20, 0xa8,
-	0x01, 0x99, 0x06, 0x1e, 0xee, 0x0e, 0xfc, 0x2e, 0x9a, 0x48, 0x24, 0x4c, 0xb9, 0xf4, 0x9f, 0x89,
-	0xed, 0x2a, 0xdd, 0x5e, 0xfa, 0xfa, 0xf7, 0xbf, 0xbf, 0x4b, 0x2c, 0x69, 0x05, 0xfe, 0xff, 0xd3,
-	0xc3, 0xf7, 0x6a, 0xee, 0xc0, 0xef, 0x6e, 0x28, 0xab, 0x55, 0x05, 0xed, 0x42, 0xae, 0x81, 0x87,
-	0x22, 0x28, 0xba, 0x32, 0x41, 0x56, 0x34, 0x95, 0x79, 0xbe, 0xcf, 0x72, 0xdf, 0x39, 0x94, 0xa9,
-	0x75, 0x39, 0x7c, 0xfd, 0x45, 0x12, 0xd2, 0xa2, 0x2e, 0x6f, 0x27, 0xe3, 0x1e, 0xcf, 0x58, 0x46,
-	0x38, 0xf1, 0x39, 0x29, 0xad, 0x1c, 0x83, 0x10, 0x1d, 0xa3, 0x5d, 0xe6, 0xc1, 0xce, 0x6b, 0x67,
-	0xc2, 0x60, 0x62, 0xdb, 0x6e, 0x28, 0xab, 0xe8, 0x11, 0x64, 0x1b, 0x78, 0xb8, 0x4d, 0xd8, 0x2b,
-	0xc5, 0x9a, 0x6e, 0x36, 0x4d, 0xe5, 0xbe, 0x91, 0x56, 0x0c, 0x7d, 0xef, 0x07, 0xbe, 0x36, 0x94,
-	0xd5, 0xff, 0x2b, 0x88, 0x40, 0xa1, 0x81, 0x87, 0xe3, 0xa7, 0xa1, 0x7c, 0xfc, 0x13, 0x57, 0xba,
-	0x34, 0x47, 0xaf, 0x5d, 0xe5, 0x41, 0x2e, 0x6a, 0x4b, 0x61, 0x10, 0x1c, 0xaa, 0x82, 0x3b, 0x9c,
-	0x7a, 0x89, 0xb7, 0xae, 0x3e, 0x7b, 0x59, 0x56, 0x9e, 0xbf, 0x2c, 0x2b, 0x2f, 0x5e, 0x96, 0x95,
-	0xc7, 0x47, 0xe5, 0x85, 0xdf, 0x8e, 0xca, 0xca, 0xf3, 0xa3, 0xf2, 0xc2, 0x9f, 0x47, 0xe5, 0x85,
-	0x56, 0x9a, 0xff, 0xd1, 0xe3, 0xfd, 0x7f, 0x02, 0x00, 0x00, 0xff, 0xff, 0xf1, 0xa6, 0x60, 0xf8,
-	0x8c, 0x11, 0x00, 0x00,
+func (m *SequencedEnvelope) GetEnvelope() *Envelope {
+	if m != nil {
+		return m.Envelope
+	}
+	return nil
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
-
-// EnqueueClient is the client API for Enqueue service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type EnqueueClient interface {
-	SayPush(ctx context.Context, opts ...grpc.CallOption) (Enqueue_SayPushClient, error)
-	SayHealth(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*Success, error)
+func (m *SequencedEnvelope) GetPartitionId() bool {
+	if m != nil {
+		return m.PartitionId
+	}
+	return false
 }
 
-type enqueueClient struct {
-	cc *grpc.ClientConn
+type RejectedEnvelope struct {
+	// the sequence of an acked push or the index in an Enqueue push
+	Sequence uint64 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Error    string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	// the grpc code of the rejection, set by the Enqueue SayPush,
+	// UNAVAILABLE means the envelope is valid and can be pushed again
+	Code uint32 `protobuf:"varint,3,opt,name=code,proto3" json:"code,omitempty"`
 }
 
-func NewEnqueueClient(cc *grpc.ClientConn) EnqueueClient {
-	return &enqueueClient{cc}
+func (m *RejectedEnvelope) Reset()         { *m = RejectedEnvelope{} }
+func (m *RejectedEnvelope) String() string { return proto.CompactTextString(m) }
+func (*RejectedEnvelope) ProtoMessage()    {}
+func (*RejectedEnvelope) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{19}
 }
-
-func (c *enqueueClient) SayPush(ctx context.Context, opts ...grpc.CallOption) (Enqueue_SayPushClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Enqueue_serviceDesc.Streams[0], "/blackrock.io.Enqueue/SayPush", opts...)
-	if err != nil {
-		return nil, err
+func (m *RejectedEnvelope) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RejectedEnvelope) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RejectedEnvelope.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	x := &enqueueSayPushClient{stream}
-	return x, nil
 }
-
-type Enqueue_SayPushClient interface {
-	Send(*Envelope) error
-	CloseAndRecv() (*Success, error)
-	grpc.ClientStream
+func (m *RejectedEnvelope) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RejectedEnvelope.Merge(m, src)
 }
-
-type enqueueSayPushClient struct {
-	grpc.ClientStream
+func (m *RejectedEnvelope) XXX_Size() int {
+	return m.Size()
 }
-
-func (x *enqueueSayPushClient) Send(m *Envelope) error {
-	return x.ClientStream.SendMsg(m)
+func (m *RejectedEnvelope) XXX_DiscardUnknown() {
+	xxx_messageInfo_RejectedEnvelope.DiscardUnknown(m)
 }
 
-func (x *enqueueSayPushClient) CloseAndRecv() (*Success, error) {
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
-	}
-	m := new(Success)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+var xxx_messageInfo_RejectedEnvelope proto.InternalMessageInfo
+
+func (m *RejectedEnvelope) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
 	}
-	return m, nil
+	return 0
 }
 
-func (c *enqueueClient) SayHealth(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*Success, error) {
-	out := new(Success)
-	err := c.cc.Invoke(ctx, "/blackrock.io.Enqueue/SayHealth", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *RejectedEnvelope) GetError() string {
+	if m != nil {
+		return m.Error
 	}
-	return out, nil
+	return ""
 }
 
-// EnqueueServer is the server API for Enqueue service.
-type EnqueueServer interface {
-	SayPush(Enqueue_SayPushServer) error
-	SayHealth(context.Context, *HealthRequest) (*Success, error)
+func (m *RejectedEnvelope) GetCode() uint32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
 }
 
-// UnimplementedEnqueueServer can be embedded to have forward compatible implementations.
-type UnimplementedEnqueueServer struct {
+type PushAck struct {
+	// every envelope up to this sequence is written and fsynced
+	Sequence uint64 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// envelopes up to sequence that failed validation and were not written
+	Rejected []*RejectedEnvelope `protobuf:"bytes,2,rep,name=rejected,proto3" json:"rejected,omitempty"`
 }
 
-func (*UnimplementedEnqueueServer) SayPush(srv Enqueue_SayPushServer) error {
-	return status.Errorf(codes.Unimplemented, "method SayPush not implemented")
+func (m *PushAck) Reset()         { *m = PushAck{} }
+func (m *PushAck) String() string { return proto.CompactTextString(m) }
+func (*PushAck) ProtoMessage()    {}
+func (*PushAck) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{20}
 }
-func (*UnimplementedEnqueueServer) SayHealth(ctx context.Context, req *HealthRequest) (*Success, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method SayHealth not implemented")
+func (m *PushAck) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
 }
-
-func RegisterEnqueueServer(s *grpc.Server, srv EnqueueServer) {
-	s.RegisterService(&_Enqueue_serviceDesc, srv)
+func (m *PushAck) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PushAck.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
 }
-
-func _Enqueue_SayPush_Handler(srv interface{}, stream grpc.ServerStream) error {
-	return srv.(EnqueueServer).SayPush(&enqueueSayPushServer{stream})
+func (m *PushAck) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushAck.Merge(m, src)
 }
-
-type Enqueue_SayPushServer interface {
-	SendAndClose(*Success) error
-	Recv() (*Envelope, error)
-	grpc.ServerStream
+func (m *PushAck) XXX_Size() int {
+	return m.Size()
 }
-
-type enqueueSayPushServer struct {
-	grpc.ServerStream
+func (m *PushAck) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushAck.DiscardUnknown(m)
 }
 
-func (x *enqueueSayPushServer) SendAndClose(m *Success) error {
-	return x.ServerStream.SendMsg(m)
-}
+var xxx_messageInfo_PushAck proto.InternalMessageInfo
 
-func (x *enqueueSayPushServer) Recv() (*Envelope, error) {
-	m := new(Envelope)
-	if err := x.ServerStream.RecvMsg(m); err != nil {
-		return nil, err
+func (m *PushAck) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
 	}
-	return m, nil
+	return 0
 }
 
-func _Enqueue_SayHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(HealthRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(EnqueueServer).SayHealth(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/blackrock.io.Enqueue/SayHealth",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EnqueueServer).SayHealth(ctx, req.(*HealthRequest))
+func (m *PushAck) GetRejected() []*RejectedEnvelope {
+	if m != nil {
+		return m.Rejected
 	}
-	return interceptor(ctx, in, info, handler)
-}
-
-var _Enqueue_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "blackrock.io.Enqueue",
-	HandlerType: (*EnqueueServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "SayHealth",
-			Handler:    _Enqueue_SayHealth_Handler,
-		},
-	},
-	Streams: []grpc.StreamDesc{
-		{
-			StreamName:    "SayPush",
-			Handler:       _Enqueue_SayPush_Handler,
-			ClientStreams: true,
-		},
-	},
-	Metadata: "spec.proto",
+	return nil
 }
 
-// SearchClient is the client API for Search service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type SearchClient interface {
-	SayPush(ctx context.Context, opts ...grpc.CallOption) (Search_SayPushClient, error)
-	SaySearch(ctx context.Context, in *SearchQueryRequest, opts ...grpc.CallOption) (*SearchQueryResponse, error)
-	SayFetch(ctx context.Context, in *SearchQueryRequest, opts ...grpc.CallOption) (Search_SayFetchClient, error)
-	SayAggregate(ctx context.Context, in *AggregateRequest, opts ...grpc.CallOption) (*Aggregate, error)
-	SayHealth(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*Success, error)
+type HealthRequest struct {
 }
 
-type searchClient struct {
-	cc *grpc.ClientConn
+func (m *HealthRequest) Reset()         { *m = HealthRequest{} }
+func (m *HealthRequest) String() string { return proto.CompactTextString(m) }
+func (*HealthRequest) ProtoMessage()    {}
+func (*HealthRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{21}
 }
-
-func NewSearchClient(cc *grpc.ClientConn) SearchClient {
-	return &searchClient{cc}
+func (m *HealthRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
 }
-
-func (c *searchClient) SayPush(ctx context.Context, opts ...grpc.CallOption) (Search_SayPushClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Search_serviceDesc.Streams[0], "/blackrock.io.Search/SayPush", opts...)
-	if err != nil {
-		return nil, err
+func (m *HealthRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_HealthRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	x := &searchSayPushClient{stream}
-	return x, nil
 }
-
-type Search_SayPushClient interface {
-	Send(*Envelope) error
-	CloseAndRecv() (*Success, error)
-	grpc.ClientStream
+func (m *HealthRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HealthRequest.Merge(m, src)
 }
-
-type searchSayPushClient struct {
-	grpc.ClientStream
+func (m *HealthRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *HealthRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HealthRequest.DiscardUnknown(m)
 }
 
-func (x *searchSayPushClient) Send(m *Envelope) error {
-	return x.ClientStream.SendMsg(m)
+var xxx_messageInfo_HealthRequest proto.InternalMessageInfo
+
+type ReindexRequest struct {
+	FromSecond uint32 `protobuf:"varint,1,opt,name=from_second,json=fromSecond,proto3" json:"from_second,omitempty"`
+	ToSecond   uint32 `protobuf:"varint,2,opt,name=to_second,json=toSecond,proto3" json:"to_second,omitempty"`
 }
 
-func (x *searchSayPushClient) CloseAndRecv() (*Success, error) {
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
-	}
-	m := new(Success)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+func (m *ReindexRequest) Reset()         { *m = ReindexRequest{} }
+func (m *ReindexRequest) String() string { return proto.CompactTextString(m) }
+func (*ReindexRequest) ProtoMessage()    {}
+func (*ReindexRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{22}
+}
+func (m *ReindexRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ReindexRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ReindexRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return m, nil
+}
+func (m *ReindexRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReindexRequest.Merge(m, src)
+}
+func (m *ReindexRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ReindexRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReindexRequest.DiscardUnknown(m)
 }
 
-func (c *searchClient) SaySearch(ctx context.Context, in *SearchQueryRequest, opts ...grpc.CallOption) (*SearchQueryResponse, error) {
-	out := new(SearchQueryResponse)
-	err := c.cc.Invoke(ctx, "/blackrock.io.Search/SaySearch", in, out, opts...)
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_ReindexRequest proto.InternalMessageInfo
+
+func (m *ReindexRequest) GetFromSecond() uint32 {
+	if m != nil {
+		return m.FromSecond
 	}
-	return out, nil
+	return 0
 }
 
-func (c *searchClient) SayFetch(ctx context.Context, in *SearchQueryRequest, opts ...grpc.CallOption) (Search_SayFetchClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Search_serviceDesc.Streams[1], "/blackrock.io.Search/SayFetch", opts...)
-	if err != nil {
-		return nil, err
-	}
-	x := &searchSayFetchClient{stream}
-	if err := x.ClientStream.SendMsg(in); err != nil {
-		return nil, err
-	}
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
+func (m *ReindexRequest) GetToSecond() uint32 {
+	if m != nil {
+		return m.ToSecond
 	}
-	return x, nil
+	return 0
 }
 
-type Search_SayFetchClient interface {
-	Recv() (*Hit, error)
-	grpc.ClientStream
+type ReindexResponse struct {
+	// segment id -> number of reindexed documents
+	Documents map[string]uint32 `protobuf:"bytes,1,rep,name=documents,proto3" json:"documents,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
 }
 
-type searchSayFetchClient struct {
-	grpc.ClientStream
+func (m *ReindexResponse) Reset()         { *m = ReindexResponse{} }
+func (m *ReindexResponse) String() string { return proto.CompactTextString(m) }
+func (*ReindexResponse) ProtoMessage()    {}
+func (*ReindexResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{23}
 }
-
-func (x *searchSayFetchClient) Recv() (*Hit, error) {
-	m := new(Hit)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
-	}
-	return m, nil
+func (m *ReindexResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
 }
-
-func (c *searchClient) SayAggregate(ctx context.Context, in *AggregateRequest, opts ...grpc.CallOption) (*Aggregate, error) {
-	out := new(Aggregate)
-	err := c.cc.Invoke(ctx, "/blackrock.io.Search/SayAggregate", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *ReindexResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ReindexResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return out, nil
+}
+func (m *ReindexResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReindexResponse.Merge(m, src)
+}
+func (m *ReindexResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ReindexResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReindexResponse.DiscardUnknown(m)
 }
 
-func (c *searchClient) SayHealth(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*Success, error) {
-	out := new(Success)
-	err := c.cc.Invoke(ctx, "/blackrock.io.Search/SayHealth", in, out, opts...)
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_ReindexResponse proto.InternalMessageInfo
+
+func (m *ReindexResponse) GetDocuments() map[string]uint32 {
+	if m != nil {
+		return m.Documents
 	}
-	return out, nil
+	return nil
 }
 
-// SearchServer is the server API for Search service.
-type SearchServer interface {
-	SayPush(Search_SayPushServer) error
-	SaySearch(context.Context, *SearchQueryRequest) (*SearchQueryResponse, error)
-	SayFetch(*SearchQueryRequest, Search_SayFetchServer) error
-	SayAggregate(context.Context, *AggregateRequest) (*Aggregate, error)
-	SayHealth(context.Context, *HealthRequest) (*Success, error)
+type ForgetRequest struct {
+	ForeignType string `protobuf:"bytes,1,opt,name=foreign_type,json=foreignType,proto3" json:"foreign_type,omitempty"`
+	ForeignId   string `protobuf:"bytes,2,opt,name=foreign_id,json=foreignId,proto3" json:"foreign_id,omitempty"`
+	// why, kept in the audit log
+	Reason string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// rewrite the segments without the documents now instead of on the
+	// next periodic compaction
+	Compact bool `protobuf:"varint,4,opt,name=compact,proto3" json:"compact,omitempty"`
 }
 
-// UnimplementedSearchServer can be embedded to have forward compatible implementations.
-type UnimplementedSearchServer struct {
+func (m *ForgetRequest) Reset()         { *m = ForgetRequest{} }
+func (m *ForgetRequest) String() string { return proto.CompactTextString(m) }
+func (*ForgetRequest) ProtoMessage()    {}
+func (*ForgetRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{24}
 }
-
-func (*UnimplementedSearchServer) SayPush(srv Search_SayPushServer) error {
-	return status.Errorf(codes.Unimplemented, "method SayPush not implemented")
+func (m *ForgetRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
 }
-func (*UnimplementedSearchServer) SaySearch(ctx context.Context, req *SearchQueryRequest) (*SearchQueryResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method SaySearch not implemented")
+func (m *ForgetRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ForgetRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
 }
-func (*UnimplementedSearchServer) SayFetch(req *SearchQueryRequest, srv Search_SayFetchServer) error {
-	return status.Errorf(codes.Unimplemented, "method SayFetch not implemented")
+func (m *ForgetRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ForgetRequest.Merge(m, src)
 }
-func (*UnimplementedSearchServer) SayAggregate(ctx context.Context, req *AggregateRequest) (*Aggregate, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method SayAggregate not implemented")
+func (m *ForgetRequest) XXX_Size() int {
+	return m.Size()
 }
-func (*UnimplementedSearchServer) SayHealth(ctx context.Context, req *HealthRequest) (*Success, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method SayHealth not implemented")
+func (m *ForgetRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ForgetRequest.DiscardUnknown(m)
 }
 
-func RegisterSearchServer(s *grpc.Server, srv SearchServer) {
-	s.RegisterService(&_Search_serviceDesc, srv)
+var xxx_messageInfo_ForgetRequest proto.InternalMessageInfo
+
+func (m *ForgetRequest) GetForeignType() string {
+	if m != nil {
+		return m.ForeignType
+	}
+	return ""
 }
 
-func _Search_SayPush_Handler(srv interface{}, stream grpc.ServerStream) error {
-	return srv.(SearchServer).SayPush(&searchSayPushServer{stream})
+func (m *ForgetRequest) GetForeignId() string {
+	if m != nil {
+		return m.ForeignId
+	}
+	return ""
 }
 
-type Search_SayPushServer interface {
-	SendAndClose(*Success) error
-	Recv() (*Envelope, error)
-	grpc.ServerStream
+func (m *ForgetRequest) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
 }
 
-type searchSayPushServer struct {
-	grpc.ServerStream
+func (m *ForgetRequest) GetCompact() bool {
+	if m != nil {
+		return m.Compact
+	}
+	return false
 }
 
-func (x *searchSayPushServer) SendAndClose(m *Success) error {
-	return x.ServerStream.SendMsg(m)
+type ForgetResponse struct {
+	// documents marked as deleted
+	Documents uint32   `protobuf:"varint,1,opt,name=documents,proto3" json:"documents,omitempty"`
+	Segments  []string `protobuf:"bytes,2,rep,name=segments,proto3" json:"segments,omitempty"`
+	// documents removed from disk by the compaction
+	Compacted uint32 `protobuf:"varint,3,opt,name=compacted,proto3" json:"compacted,omitempty"`
 }
 
-func (x *searchSayPushServer) Recv() (*Envelope, error) {
-	m := new(Envelope)
-	if err := x.ServerStream.RecvMsg(m); err != nil {
-		return nil, err
+func (m *ForgetResponse) Reset()         { *m = ForgetResponse{} }
+func (m *ForgetResponse) String() string { return proto.CompactTextString(m) }
+func (*ForgetResponse) ProtoMessage()    {}
+func (*ForgetResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{25}
+}
+func (m *ForgetResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ForgetResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ForgetResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return m, nil
+}
+func (m *ForgetResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ForgetResponse.Merge(m, src)
+}
+func (m *ForgetResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ForgetResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ForgetResponse.DiscardUnknown(m)
 }
 
-func _Search_SaySearch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SearchQueryRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(SearchServer).SaySearch(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/blackrock.io.Search/SaySearch",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(SearchServer).SaySearch(ctx, req.(*SearchQueryRequest))
+var xxx_messageInfo_ForgetResponse proto.InternalMessageInfo
+
+func (m *ForgetResponse) GetDocuments() uint32 {
+	if m != nil {
+		return m.Documents
 	}
-	return interceptor(ctx, in, info, handler)
+	return 0
 }
 
-func _Search_SayFetch_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(SearchQueryRequest)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+func (m *ForgetResponse) GetSegments() []string {
+	if m != nil {
+		return m.Segments
 	}
-	return srv.(SearchServer).SayFetch(m, &searchSayFetchServer{stream})
+	return nil
 }
 
-type Search_SayFetchServer interface {
-	Send(*Hit) error
-	grpc.ServerStream
+func (m *ForgetResponse) GetCompacted() uint32 {
+	if m != nil {
+		return m.Compacted
+	}
+	return 0
 }
 
-type searchSayFetchServer struct {
-	grpc.ServerStream
+type SnapshotRequest struct {
+	FromSecond uint32 `protobuf:"varint,1,opt,name=from_second,json=fromSecond,proto3" json:"from_second,omitempty"`
+	ToSecond   uint32 `protobuf:"varint,2,opt,name=to_second,json=toSecond,proto3" json:"to_second,omitempty"`
+	// upload to the configured backup store
+	Upload bool `protobuf:"varint,3,opt,name=upload,proto3" json:"upload,omitempty"`
 }
 
-func (x *searchSayFetchServer) Send(m *Hit) error {
-	return x.ServerStream.SendMsg(m)
+func (m *SnapshotRequest) Reset()         { *m = SnapshotRequest{} }
+func (m *SnapshotRequest) String() string { return proto.CompactTextString(m) }
+func (*SnapshotRequest) ProtoMessage()    {}
+func (*SnapshotRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{26}
 }
-
-func _Search_SayAggregate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(AggregateRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(SearchServer).SayAggregate(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/blackrock.io.Search/SayAggregate",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(SearchServer).SayAggregate(ctx, req.(*AggregateRequest))
+func (m *SnapshotRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SnapshotRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SnapshotRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return interceptor(ctx, in, info, handler)
+}
+func (m *SnapshotRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SnapshotRequest.Merge(m, src)
+}
+func (m *SnapshotRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *SnapshotRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SnapshotRequest.DiscardUnknown(m)
 }
 
-func _Search_SayHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(HealthRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(SearchServer).SayHealth(ctx, in)
+var xxx_messageInfo_SnapshotRequest proto.InternalMessageInfo
+
+func (m *SnapshotRequest) GetFromSecond() uint32 {
+	if m != nil {
+		return m.FromSecond
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/blackrock.io.Search/SayHealth",
+	return 0
+}
+
+func (m *SnapshotRequest) GetToSecond() uint32 {
+	if m != nil {
+		return m.ToSecond
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(SearchServer).SayHealth(ctx, req.(*HealthRequest))
+	return 0
+}
+
+func (m *SnapshotRequest) GetUpload() bool {
+	if m != nil {
+		return m.Upload
 	}
-	return interceptor(ctx, in, info, handler)
+	return false
 }
 
-var _Search_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "blackrock.io.Search",
-	HandlerType: (*SearchServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "SaySearch",
-			Handler:    _Search_SaySearch_Handler,
-		},
-		{
-			MethodName: "SayAggregate",
-			Handler:    _Search_SayAggregate_Handler,
-		},
-		{
-			MethodName: "SayHealth",
-			Handler:    _Search_SayHealth_Handler,
-		},
-	},
-	Streams: []grpc.StreamDesc{
-		{
-			StreamName:    "SayPush",
-			Handler:       _Search_SayPush_Handler,
-			ClientStreams: true,
-		},
-		{
-			StreamName:    "SayFetch",
-			Handler:       _Search_SayFetch_Handler,
-			ServerStreams: true,
-		},
-	},
-	Metadata: "spec.proto",
+type SnapshotResponse struct {
+	Name     string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Path     string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Segments uint32 `protobuf:"varint,3,opt,name=segments,proto3" json:"segments,omitempty"`
+	Uploaded bool   `protobuf:"varint,4,opt,name=uploaded,proto3" json:"uploaded,omitempty"`
 }
 
-func (m *KV) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *SnapshotResponse) Reset()         { *m = SnapshotResponse{} }
+func (m *SnapshotResponse) String() string { return proto.CompactTextString(m) }
+func (*SnapshotResponse) ProtoMessage()    {}
+func (*SnapshotResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{27}
+}
+func (m *SnapshotResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SnapshotResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SnapshotResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return dAtA[:n], nil
+}
+func (m *SnapshotResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SnapshotResponse.Merge(m, src)
+}
+func (m *SnapshotResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *SnapshotResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SnapshotResponse.DiscardUnknown(m)
 }
 
-func (m *KV) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+var xxx_messageInfo_SnapshotResponse proto.InternalMessageInfo
+
+func (m *SnapshotResponse) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
 }
 
-func (m *KV) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Value) > 0 {
-		i -= len(m.Value)
-		copy(dAtA[i:], m.Value)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.Value)))
-		i--
-		dAtA[i] = 0x12
+func (m *SnapshotResponse) GetPath() string {
+	if m != nil {
+		return m.Path
 	}
-	if len(m.Key) > 0 {
-		i -= len(m.Key)
-		copy(dAtA[i:], m.Key)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.Key)))
-		i--
-		dAtA[i] = 0xa
+	return ""
+}
+
+func (m *SnapshotResponse) GetSegments() uint32 {
+	if m != nil {
+		return m.Segments
 	}
-	return len(dAtA) - i, nil
+	return 0
 }
 
-func (m *KF) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *SnapshotResponse) GetUploaded() bool {
+	if m != nil {
+		return m.Uploaded
 	}
-	return dAtA[:n], nil
+	return false
 }
 
-func (m *KF) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type ReplicateRequest struct {
+	// segment id -> forward index offset the follower already has
+	Positions map[string]uint32 `protobuf:"bytes,1,rep,name=positions,proto3" json:"positions,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// segments older than this are not replicated
+	FromSecond uint32 `protobuf:"varint,2,opt,name=from_second,json=fromSecond,proto3" json:"from_second,omitempty"`
 }
 
-func (m *KF) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Value != 0 {
-		i -= 8
-		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Value))))
-		i--
-		dAtA[i] = 0x11
+func (m *ReplicateRequest) Reset()         { *m = ReplicateRequest{} }
+func (m *ReplicateRequest) String() string { return proto.CompactTextString(m) }
+func (*ReplicateRequest) ProtoMessage()    {}
+func (*ReplicateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{28}
+}
+func (m *ReplicateRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ReplicateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ReplicateRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	if len(m.Key) > 0 {
-		i -= len(m.Key)
-		copy(dAtA[i:], m.Key)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.Key)))
-		i--
-		dAtA[i] = 0xa
+}
+func (m *ReplicateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicateRequest.Merge(m, src)
+}
+func (m *ReplicateRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ReplicateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicateRequest proto.InternalMessageInfo
+
+func (m *ReplicateRequest) GetPositions() map[string]uint32 {
+	if m != nil {
+		return m.Positions
 	}
-	return len(dAtA) - i, nil
+	return nil
 }
 
-func (m *Metadata) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *ReplicateRequest) GetFromSecond() uint32 {
+	if m != nil {
+		return m.FromSecond
 	}
-	return dAtA[:n], nil
+	return 0
 }
 
-func (m *Metadata) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type ReplicatedEnvelope struct {
+	Segment string `protobuf:"bytes,1,opt,name=segment,proto3" json:"segment,omitempty"`
+	Offset  uint32 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	// the record as stored in the forward index of the leader, empty for heartbeats
+	Data         []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	LeaderTimeNs int64  `protobuf:"varint,4,opt,name=leader_time_ns,json=leaderTimeNs,proto3" json:"leader_time_ns,omitempty"`
+	// committed on the leader and not yet sent, set on heartbeats
+	PendingBytes uint64 `protobuf:"varint,5,opt,name=pending_bytes,json=pendingBytes,proto3" json:"pending_bytes,omitempty"`
+	// metadata.id is partition<<56 | offset, see SequencedEnvelope
+	PartitionId bool `protobuf:"varint,6,opt,name=partition_id,json=partitionId,proto3" json:"partition_id,omitempty"`
+	// the document was forgotten on the leader, data is a placeholder
+	// of the same size with only metadata.id
+	Deleted bool `protobuf:"varint,7,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	// document ids of the segment forgotten on the leader, sent
+	// without data
+	Tombstones []uint32 `protobuf:"varint,8,rep,packed,name=tombstones,proto3" json:"tombstones,omitempty"`
+}
+
+func (m *ReplicatedEnvelope) Reset()         { *m = ReplicatedEnvelope{} }
+func (m *ReplicatedEnvelope) String() string { return proto.CompactTextString(m) }
+func (*ReplicatedEnvelope) ProtoMessage()    {}
+func (*ReplicatedEnvelope) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{29}
+}
+func (m *ReplicatedEnvelope) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
 }
-
-func (m *Metadata) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Id != 0 {
-		i -= 8
-		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(m.Id))
-		i--
-		dAtA[i] = 0x61
-	}
-	if len(m.Track) > 0 {
-		for k := range m.Track {
-			v := m.Track[k]
-			baseI := i
-			i = encodeVarintSpec(dAtA, i, uint64(v))
-			i--
-			dAtA[i] = 0x10
-			i -= len(k)
-			copy(dAtA[i:], k)
-			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
-			i--
-			dAtA[i] = 0xa
-			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0x5a
+func (m *ReplicatedEnvelope) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ReplicatedEnvelope.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if len(m.ForeignType) > 0 {
-		i -= len(m.ForeignType)
-		copy(dAtA[i:], m.ForeignType)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignType)))
-		i--
-		dAtA[i] = 0x52
+}
+func (m *ReplicatedEnvelope) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicatedEnvelope.Merge(m, src)
+}
+func (m *ReplicatedEnvelope) XXX_Size() int {
+	return m.Size()
+}
+func (m *ReplicatedEnvelope) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicatedEnvelope.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicatedEnvelope proto.InternalMessageInfo
+
+func (m *ReplicatedEnvelope) GetSegment() string {
+	if m != nil {
+		return m.Segment
 	}
-	if len(m.ForeignId) > 0 {
-		i -= len(m.ForeignId)
-		copy(dAtA[i:], m.ForeignId)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignId)))
-		i--
-		dAtA[i] = 0x4a
+	return ""
+}
+
+func (m *ReplicatedEnvelope) GetOffset() uint32 {
+	if m != nil {
+		return m.Offset
 	}
-	if len(m.EventType) > 0 {
-		i -= len(m.EventType)
-		copy(dAtA[i:], m.EventType)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.EventType)))
-		i--
-		dAtA[i] = 0x3a
+	return 0
+}
+
+func (m *ReplicatedEnvelope) GetData() []byte {
+	if m != nil {
+		return m.Data
 	}
-	if m.CreatedAtNs != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.CreatedAtNs))
-		i--
-		dAtA[i] = 0x28
+	return nil
+}
+
+func (m *ReplicatedEnvelope) GetLeaderTimeNs() int64 {
+	if m != nil {
+		return m.LeaderTimeNs
 	}
-	if len(m.Properties) > 0 {
-		for iNdEx := len(m.Properties) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Properties[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintSpec(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x1a
-		}
+	return 0
+}
+
+func (m *ReplicatedEnvelope) GetPendingBytes() uint64 {
+	if m != nil {
+		return m.PendingBytes
 	}
-	if len(m.Count) > 0 {
-		for iNdEx := len(m.Count) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Count[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintSpec(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x12
-		}
+	return 0
+}
+
+func (m *ReplicatedEnvelope) GetPartitionId() bool {
+	if m != nil {
+		return m.PartitionId
 	}
-	if len(m.Search) > 0 {
-		for iNdEx := len(m.Search) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Search[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintSpec(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
-		}
+	return false
+}
+
+func (m *ReplicatedEnvelope) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
 	}
-	return len(dAtA) - i, nil
+	return false
 }
 
-func (m *SearchableMetadata) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *ReplicatedEnvelope) GetTombstones() []uint32 {
+	if m != nil {
+		return m.Tombstones
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *SearchableMetadata) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type ReplicationStatusRequest struct {
 }
 
-func (m *SearchableMetadata) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Track) > 0 {
-		for k := range m.Track {
-			v := m.Track[k]
-			baseI := i
-			i = encodeVarintSpec(dAtA, i, uint64(v))
-			i--
-			dAtA[i] = 0x10
-			i -= len(k)
-			copy(dAtA[i:], k)
-			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
-			i--
-			dAtA[i] = 0xa
-			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0x5a
+func (m *ReplicationStatusRequest) Reset()         { *m = ReplicationStatusRequest{} }
+func (m *ReplicationStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*ReplicationStatusRequest) ProtoMessage()    {}
+func (*ReplicationStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{30}
+}
+func (m *ReplicationStatusRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ReplicationStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ReplicationStatusRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if len(m.ForeignType) > 0 {
-		i -= len(m.ForeignType)
-		copy(dAtA[i:], m.ForeignType)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignType)))
-		i--
-		dAtA[i] = 0x52
-	}
-	if len(m.ForeignId) > 0 {
-		i -= len(m.ForeignId)
-		copy(dAtA[i:], m.ForeignId)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignId)))
-		i--
-		dAtA[i] = 0x4a
+}
+func (m *ReplicationStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicationStatusRequest.Merge(m, src)
+}
+func (m *ReplicationStatusRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ReplicationStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicationStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicationStatusRequest proto.InternalMessageInfo
+
+type ReplicationStatus struct {
+	Follower  bool   `protobuf:"varint,1,opt,name=follower,proto3" json:"follower,omitempty"`
+	Leader    string `protobuf:"bytes,2,opt,name=leader,proto3" json:"leader,omitempty"`
+	Connected bool   `protobuf:"varint,3,opt,name=connected,proto3" json:"connected,omitempty"`
+	// as of the last heartbeat from the leader
+	PendingBytes uint64 `protobuf:"varint,4,opt,name=pending_bytes,json=pendingBytes,proto3" json:"pending_bytes,omitempty"`
+	// leader time of the last heartbeat with nothing pending
+	CaughtUpAtNs   int64  `protobuf:"varint,5,opt,name=caught_up_at_ns,json=caughtUpAtNs,proto3" json:"caught_up_at_ns,omitempty"`
+	LastReceivedNs int64  `protobuf:"varint,6,opt,name=last_received_ns,json=lastReceivedNs,proto3" json:"last_received_ns,omitempty"`
+	Applied        uint64 `protobuf:"varint,7,opt,name=applied,proto3" json:"applied,omitempty"`
+	LastError      string `protobuf:"bytes,8,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+}
+
+func (m *ReplicationStatus) Reset()         { *m = ReplicationStatus{} }
+func (m *ReplicationStatus) String() string { return proto.CompactTextString(m) }
+func (*ReplicationStatus) ProtoMessage()    {}
+func (*ReplicationStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{31}
+}
+func (m *ReplicationStatus) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ReplicationStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ReplicationStatus.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	if len(m.EventType) > 0 {
-		i -= len(m.EventType)
-		copy(dAtA[i:], m.EventType)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.EventType)))
-		i--
-		dAtA[i] = 0x3a
+}
+func (m *ReplicationStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicationStatus.Merge(m, src)
+}
+func (m *ReplicationStatus) XXX_Size() int {
+	return m.Size()
+}
+func (m *ReplicationStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicationStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicationStatus proto.InternalMessageInfo
+
+func (m *ReplicationStatus) GetFollower() bool {
+	if m != nil {
+		return m.Follower
 	}
-	if len(m.Search) > 0 {
-		for iNdEx := len(m.Search) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Search[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintSpec(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
-		}
+	return false
+}
+
+func (m *ReplicationStatus) GetLeader() string {
+	if m != nil {
+		return m.Leader
 	}
-	return len(dAtA) - i, nil
+	return ""
 }
 
-func (m *BasicMetadata) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *ReplicationStatus) GetConnected() bool {
+	if m != nil {
+		return m.Connected
 	}
-	return dAtA[:n], nil
+	return false
 }
 
-func (m *BasicMetadata) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *ReplicationStatus) GetPendingBytes() uint64 {
+	if m != nil {
+		return m.PendingBytes
+	}
+	return 0
 }
 
-func (m *BasicMetadata) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.ForeignType) > 0 {
-		i -= len(m.ForeignType)
-		copy(dAtA[i:], m.ForeignType)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignType)))
-		i--
-		dAtA[i] = 0x52
-	}
-	if len(m.ForeignId) > 0 {
-		i -= len(m.ForeignId)
-		copy(dAtA[i:], m.ForeignId)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignId)))
-		i--
-		dAtA[i] = 0x4a
+func (m *ReplicationStatus) GetCaughtUpAtNs() int64 {
+	if m != nil {
+		return m.CaughtUpAtNs
 	}
-	if len(m.EventType) > 0 {
-		i -= len(m.EventType)
-		copy(dAtA[i:], m.EventType)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.EventType)))
-		i--
-		dAtA[i] = 0x3a
+	return 0
+}
+
+func (m *ReplicationStatus) GetLastReceivedNs() int64 {
+	if m != nil {
+		return m.LastReceivedNs
 	}
-	if m.CreatedAtNs != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.CreatedAtNs))
-		i--
-		dAtA[i] = 0x28
+	return 0
+}
+
+func (m *ReplicationStatus) GetApplied() uint64 {
+	if m != nil {
+		return m.Applied
 	}
-	return len(dAtA) - i, nil
+	return 0
 }
 
-func (m *CountableMetadata) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *ReplicationStatus) GetLastError() string {
+	if m != nil {
+		return m.LastError
 	}
-	return dAtA[:n], nil
+	return ""
 }
 
-func (m *CountableMetadata) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type PromoteRequest struct {
 }
 
-func (m *CountableMetadata) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.ForeignType) > 0 {
-		i -= len(m.ForeignType)
-		copy(dAtA[i:], m.ForeignType)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignType)))
-		i--
-		dAtA[i] = 0x52
-	}
-	if len(m.ForeignId) > 0 {
-		i -= len(m.ForeignId)
-		copy(dAtA[i:], m.ForeignId)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignId)))
-		i--
-		dAtA[i] = 0x4a
-	}
-	if len(m.EventType) > 0 {
-		i -= len(m.EventType)
-		copy(dAtA[i:], m.EventType)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.EventType)))
-		i--
-		dAtA[i] = 0x3a
-	}
-	if m.CreatedAtNs != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.CreatedAtNs))
-		i--
-		dAtA[i] = 0x28
-	}
-	if len(m.Count) > 0 {
-		for iNdEx := len(m.Count) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Count[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintSpec(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x12
-		}
-	}
-	if len(m.Search) > 0 {
-		for iNdEx := len(m.Search) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Search[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintSpec(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
+func (m *PromoteRequest) Reset()         { *m = PromoteRequest{} }
+func (m *PromoteRequest) String() string { return proto.CompactTextString(m) }
+func (*PromoteRequest) ProtoMessage()    {}
+func (*PromoteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_423806180556987f, []int{32}
+}
+func (m *PromoteRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PromoteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PromoteRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
+}
+func (m *PromoteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PromoteRequest.Merge(m, src)
+}
+func (m *PromoteRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *PromoteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PromoteRequest.DiscardUnknown(m)
 }
 
-func (m *Hit) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
+var xxx_messageInfo_PromoteRequest proto.InternalMessageInfo
+
+func init() {
+	proto.RegisterType((*KV)(nil), "blackrock.io.KV")
+	golang_proto.RegisterType((*KV)(nil), "blackrock.io.KV")
+	proto.RegisterType((*KF)(nil), "blackrock.io.KF")
+	golang_proto.RegisterType((*KF)(nil), "blackrock.io.KF")
+	proto.RegisterType((*Metadata)(nil), "blackrock.io.Metadata")
+	golang_proto.RegisterType((*Metadata)(nil), "blackrock.io.Metadata")
+	proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.Metadata.TrackEntry")
+	golang_proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.Metadata.TrackEntry")
+	proto.RegisterType((*SearchableMetadata)(nil), "blackrock.io.SearchableMetadata")
+	golang_proto.RegisterType((*SearchableMetadata)(nil), "blackrock.io.SearchableMetadata")
+	proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.SearchableMetadata.TrackEntry")
+	golang_proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.SearchableMetadata.TrackEntry")
+	proto.RegisterType((*BasicMetadata)(nil), "blackrock.io.BasicMetadata")
+	golang_proto.RegisterType((*BasicMetadata)(nil), "blackrock.io.BasicMetadata")
+	proto.RegisterType((*CountableMetadata)(nil), "blackrock.io.CountableMetadata")
+	golang_proto.RegisterType((*CountableMetadata)(nil), "blackrock.io.CountableMetadata")
+	proto.RegisterType((*Hit)(nil), "blackrock.io.Hit")
+	golang_proto.RegisterType((*Hit)(nil), "blackrock.io.Hit")
+	proto.RegisterType((*SearchQueryRequest)(nil), "blackrock.io.SearchQueryRequest")
+	golang_proto.RegisterType((*SearchQueryRequest)(nil), "blackrock.io.SearchQueryRequest")
+	proto.RegisterType((*CountPerKV)(nil), "blackrock.io.CountPerKV")
+	golang_proto.RegisterType((*CountPerKV)(nil), "blackrock.io.CountPerKV")
+	proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.CountPerKV.CountEntry")
+	golang_proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.CountPerKV.CountEntry")
+	proto.RegisterType((*PointPerEventType)(nil), "blackrock.io.PointPerEventType")
+	golang_proto.RegisterType((*PointPerEventType)(nil), "blackrock.io.PointPerEventType")
+	proto.RegisterType((*ChartBucketPerTime)(nil), "blackrock.io.ChartBucketPerTime")
+	golang_proto.RegisterType((*ChartBucketPerTime)(nil), "blackrock.io.ChartBucketPerTime")
+	proto.RegisterMapType((map[string]*PointPerEventType)(nil), "blackrock.io.ChartBucketPerTime.PerTypeEntry")
+	golang_proto.RegisterMapType((map[string]*PointPerEventType)(nil), "blackrock.io.ChartBucketPerTime.PerTypeEntry")
+	proto.RegisterType((*Chart)(nil), "blackrock.io.Chart")
+	golang_proto.RegisterType((*Chart)(nil), "blackrock.io.Chart")
+	proto.RegisterMapType((map[uint32]*ChartBucketPerTime)(nil), "blackrock.io.Chart.BucketsEntry")
+	golang_proto.RegisterMapType((map[uint32]*ChartBucketPerTime)(nil), "blackrock.io.Chart.BucketsEntry")
+	proto.RegisterType((*AggregateRequest)(nil), "blackrock.io.AggregateRequest")
+	golang_proto.RegisterType((*AggregateRequest)(nil), "blackrock.io.AggregateRequest")
+	proto.RegisterMapType((map[string]bool)(nil), "blackrock.io.AggregateRequest.FieldsEntry")
+	golang_proto.RegisterMapType((map[string]bool)(nil), "blackrock.io.AggregateRequest.FieldsEntry")
+	proto.RegisterType((*Aggregate)(nil), "blackrock.io.Aggregate")
+	golang_proto.RegisterType((*Aggregate)(nil), "blackrock.io.Aggregate")
+	proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.CountEntry")
+	golang_proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.CountEntry")
+	proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.EventTypeEntry")
+	golang_proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.EventTypeEntry")
+	proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.ForeignIdEntry")
+	golang_proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.ForeignIdEntry")
+	proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.Aggregate.PossibleEntry")
+	golang_proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.Aggregate.PossibleEntry")
+	proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.SearchEntry")
+	golang_proto.RegisterMapType((map[string]*CountPerKV)(nil), "blackrock.io.Aggregate.SearchEntry")
+	proto.RegisterType((*SearchQueryResponse)(nil), "blackrock.io.SearchQueryResponse")
+	golang_proto.RegisterType((*SearchQueryResponse)(nil), "blackrock.io.SearchQueryResponse")
+	proto.RegisterType((*Envelope)(nil), "blackrock.io.Envelope")
+	golang_proto.RegisterType((*Envelope)(nil), "blackrock.io.Envelope")
+	proto.RegisterType((*EnvelopeBatch)(nil), "blackrock.io.EnvelopeBatch")
+	golang_proto.RegisterType((*EnvelopeBatch)(nil), "blackrock.io.EnvelopeBatch")
+	proto.RegisterType((*Success)(nil), "blackrock.io.Success")
+	golang_proto.RegisterType((*Success)(nil), "blackrock.io.Success")
+	proto.RegisterType((*SequencedEnvelope)(nil), "blackrock.io.SequencedEnvelope")
+	golang_proto.RegisterType((*SequencedEnvelope)(nil), "blackrock.io.SequencedEnvelope")
+	proto.RegisterType((*RejectedEnvelope)(nil), "blackrock.io.RejectedEnvelope")
+	golang_proto.RegisterType((*RejectedEnvelope)(nil), "blackrock.io.RejectedEnvelope")
+	proto.RegisterType((*PushAck)(nil), "blackrock.io.PushAck")
+	golang_proto.RegisterType((*PushAck)(nil), "blackrock.io.PushAck")
+	proto.RegisterType((*HealthRequest)(nil), "blackrock.io.HealthRequest")
+	golang_proto.RegisterType((*HealthRequest)(nil), "blackrock.io.HealthRequest")
+	proto.RegisterType((*ReindexRequest)(nil), "blackrock.io.ReindexRequest")
+	golang_proto.RegisterType((*ReindexRequest)(nil), "blackrock.io.ReindexRequest")
+	proto.RegisterType((*ReindexResponse)(nil), "blackrock.io.ReindexResponse")
+	golang_proto.RegisterType((*ReindexResponse)(nil), "blackrock.io.ReindexResponse")
+	proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.ReindexResponse.DocumentsEntry")
+	golang_proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.ReindexResponse.DocumentsEntry")
+	proto.RegisterType((*ForgetRequest)(nil), "blackrock.io.ForgetRequest")
+	golang_proto.RegisterType((*ForgetRequest)(nil), "blackrock.io.ForgetRequest")
+	proto.RegisterType((*ForgetResponse)(nil), "blackrock.io.ForgetResponse")
+	golang_proto.RegisterType((*ForgetResponse)(nil), "blackrock.io.ForgetResponse")
+	proto.RegisterType((*SnapshotRequest)(nil), "blackrock.io.SnapshotRequest")
+	golang_proto.RegisterType((*SnapshotRequest)(nil), "blackrock.io.SnapshotRequest")
+	proto.RegisterType((*SnapshotResponse)(nil), "blackrock.io.SnapshotResponse")
+	golang_proto.RegisterType((*SnapshotResponse)(nil), "blackrock.io.SnapshotResponse")
+	proto.RegisterType((*ReplicateRequest)(nil), "blackrock.io.ReplicateRequest")
+	golang_proto.RegisterType((*ReplicateRequest)(nil), "blackrock.io.ReplicateRequest")
+	proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.ReplicateRequest.PositionsEntry")
+	golang_proto.RegisterMapType((map[string]uint32)(nil), "blackrock.io.ReplicateRequest.PositionsEntry")
+	proto.RegisterType((*ReplicatedEnvelope)(nil), "blackrock.io.ReplicatedEnvelope")
+	golang_proto.RegisterType((*ReplicatedEnvelope)(nil), "blackrock.io.ReplicatedEnvelope")
+	proto.RegisterType((*ReplicationStatusRequest)(nil), "blackrock.io.ReplicationStatusRequest")
+	golang_proto.RegisterType((*ReplicationStatusRequest)(nil), "blackrock.io.ReplicationStatusRequest")
+	proto.RegisterType((*ReplicationStatus)(nil), "blackrock.io.ReplicationStatus")
+	golang_proto.RegisterType((*ReplicationStatus)(nil), "blackrock.io.ReplicationStatus")
+	proto.RegisterType((*PromoteRequest)(nil), "blackrock.io.PromoteRequest")
+	golang_proto.RegisterType((*PromoteRequest)(nil), "blackrock.io.PromoteRequest")
 }
 
-func (m *Hit) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func init() { proto.RegisterFile("spec.proto", fileDescriptor_423806180556987f) }
+func init() { golang_proto.RegisterFile("spec.proto", fileDescriptor_423806180556987f) }
+
+var fileDescriptor_423806180556987f = []byte{
+	// 2298 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x59, 0xcf, 0x6f, 0x1b, 0xc7,
+	0xf5, 0xd7, 0xf2, 0x37, 0x1f, 0x49, 0xfd, 0x18, 0x2b, 0xce, 0x9a, 0x56, 0x28, 0x79, 0xfd, 0xb5,
+	0xa1, 0xf8, 0x6b, 0x53, 0xae, 0xda, 0xba, 0x8e, 0xd2, 0x8b, 0x94, 0xc8, 0x70, 0xea, 0xc4, 0x50,
+	0x97, 0x8e, 0x11, 0x20, 0x05, 0x88, 0xd1, 0xee, 0x88, 0xdc, 0x6a, 0xb9, 0xb3, 0xde, 0x19, 0xba,
+	0x26, 0xda, 0x53, 0x5a, 0xf4, 0x9c, 0xa2, 0x39, 0x14, 0xe8, 0xa5, 0xf5, 0xad, 0xb7, 0xdc, 0x0b,
+	0x14, 0x3d, 0xe6, 0x54, 0x18, 0x68, 0x0f, 0x3d, 0x15, 0x85, 0xdd, 0x3f, 0xa4, 0x98, 0x1f, 0xbb,
+	0xe4, 0x2e, 0x49, 0xc9, 0x4a, 0x15, 0x20, 0x27, 0xed, 0x7b, 0xf3, 0xe6, 0xbd, 0x37, 0x9f, 0x79,
+	0xbf, 0x86, 0x02, 0x60, 0x21, 0x71, 0xda, 0x61, 0x44, 0x39, 0x45, 0xf5, 0x43, 0x1f, 0x3b, 0xc7,
+	0x11, 0x75, 0x8e, 0xdb, 0x1e, 0x6d, 0xde, 0xea, 0x79, 0xbc, 0x3f, 0x3c, 0x6c, 0x3b, 0x74, 0xb0,
+	0xd5, 0xa3, 0x3d, 0xba, 0x25, 0x85, 0x0e, 0x87, 0x47, 0x92, 0x92, 0x84, 0xfc, 0x52, 0x9b, 0x53,
+	0xe2, 0x11, 0x39, 0x3e, 0xf6, 0xb6, 0x7a, 0xf4, 0xd6, 0x93, 0x21, 0x89, 0x46, 0xb7, 0xbc, 0xc0,
+	0x25, 0xcf, 0x6e, 0xb9, 0xcc, 0xdf, 0x72, 0x99, 0xaf, 0xc5, 0xd7, 0x7a, 0x94, 0xf6, 0x7c, 0xb2,
+	0x85, 0x43, 0x6f, 0x0b, 0x07, 0x01, 0xe5, 0x98, 0x7b, 0x34, 0x60, 0x6a, 0xd5, 0xba, 0x09, 0xb9,
+	0x07, 0x8f, 0xd1, 0x32, 0xe4, 0x8f, 0xc9, 0xc8, 0x34, 0x36, 0x8c, 0xcd, 0xaa, 0x2d, 0x3e, 0xd1,
+	0x2a, 0x14, 0x9f, 0x62, 0x7f, 0x48, 0xcc, 0x9c, 0xe4, 0x29, 0x42, 0x4a, 0xdf, 0x3b, 0x4d, 0xda,
+	0x88, 0xa5, 0xff, 0x96, 0x87, 0xca, 0x47, 0x84, 0x63, 0x17, 0x73, 0x8c, 0xda, 0x50, 0x62, 0x04,
+	0x47, 0x4e, 0xdf, 0x34, 0x36, 0xf2, 0x9b, 0xb5, 0xed, 0xe5, 0xf6, 0x24, 0x06, 0xed, 0x07, 0x8f,
+	0xf7, 0x0a, 0x5f, 0xfd, 0x6b, 0x7d, 0xc1, 0xd6, 0x52, 0xe8, 0x26, 0x14, 0x1d, 0x3a, 0x0c, 0xb8,
+	0x99, 0x3b, 0x51, 0x5c, 0x09, 0xa1, 0x3b, 0x00, 0x61, 0x44, 0x43, 0x12, 0x71, 0x8f, 0x30, 0x33,
+	0x7f, 0xe2, 0x96, 0x09, 0x49, 0x64, 0x41, 0xc3, 0x89, 0x08, 0xe6, 0xc4, 0xed, 0x62, 0xde, 0x0d,
+	0x98, 0x59, 0xdc, 0x30, 0x36, 0xf3, 0x76, 0x4d, 0x33, 0x77, 0xf9, 0x43, 0x86, 0xde, 0x02, 0x20,
+	0x4f, 0x49, 0xc0, 0xbb, 0x7c, 0x14, 0x12, 0xb3, 0x2c, 0x4f, 0x5d, 0x95, 0x9c, 0x47, 0xa3, 0x90,
+	0x88, 0xe5, 0x23, 0x1a, 0x11, 0xaf, 0x17, 0x74, 0x3d, 0xd7, 0xac, 0xaa, 0x65, 0xcd, 0xf9, 0xc0,
+	0x45, 0x57, 0xa0, 0x1e, 0x2f, 0xcb, 0xfd, 0x20, 0x05, 0x6a, 0x9a, 0x27, 0x35, 0xfc, 0x00, 0x8a,
+	0x3c, 0xc2, 0xce, 0xb1, 0x59, 0x93, 0x7e, 0x5f, 0x49, 0xfb, 0x1d, 0x23, 0xd8, 0x7e, 0x24, 0x64,
+	0xf6, 0x03, 0x1e, 0x8d, 0x6c, 0x25, 0x8f, 0x16, 0x21, 0xe7, 0xb9, 0x66, 0x7d, 0xc3, 0xd8, 0x2c,
+	0xd9, 0x39, 0xcf, 0x45, 0x17, 0xa1, 0xc4, 0x49, 0x80, 0x03, 0x6e, 0x36, 0xa4, 0x15, 0x4d, 0x35,
+	0xef, 0x02, 0x8c, 0x37, 0x9f, 0x76, 0x7d, 0x0d, 0x7d, 0x7d, 0x3b, 0xb9, 0xbb, 0xc6, 0x4e, 0xfd,
+	0xc5, 0x1f, 0xd7, 0x17, 0x3e, 0x7f, 0xbe, 0xbe, 0xf0, 0xbb, 0xe7, 0xeb, 0x0b, 0xd6, 0x97, 0x39,
+	0x40, 0x1d, 0x79, 0x3d, 0xf8, 0xd0, 0x27, 0x5f, 0xfb, 0x6a, 0xbf, 0x71, 0x40, 0x77, 0xd3, 0x80,
+	0xfe, 0x7f, 0xda, 0x9f, 0xe9, 0x13, 0x4c, 0x43, 0x7b, 0x6e, 0x90, 0x3d, 0x37, 0xa0, 0xb1, 0x87,
+	0x99, 0xe7, 0x24, 0x68, 0x7d, 0x1b, 0x42, 0x2e, 0xe3, 0xe4, 0xaf, 0x72, 0xb0, 0xf2, 0x9e, 0xc8,
+	0xa3, 0xff, 0xe9, 0x5a, 0xcf, 0x96, 0xb1, 0xdf, 0x42, 0x18, 0xba, 0x90, 0xbf, 0xef, 0x71, 0x9d,
+	0x55, 0xe2, 0xae, 0x0b, 0x32, 0xab, 0x56, 0xa1, 0xc8, 0x1c, 0x1a, 0xa9, 0xab, 0xce, 0xd9, 0x8a,
+	0x40, 0xdb, 0x50, 0x19, 0x68, 0xa4, 0xcc, 0xfc, 0x86, 0xb1, 0x59, 0xdb, 0xbe, 0x38, 0x3b, 0x6f,
+	0xed, 0x44, 0xce, 0xfa, 0x87, 0x11, 0xe7, 0xcf, 0x8f, 0x45, 0xb9, 0xb6, 0xc9, 0x93, 0x21, 0x61,
+	0x1c, 0xad, 0x43, 0xed, 0x28, 0xa2, 0x83, 0x2e, 0x23, 0x0e, 0x0d, 0x94, 0xe5, 0x86, 0x0d, 0x82,
+	0xd5, 0x91, 0x1c, 0x74, 0x19, 0xaa, 0x9c, 0xc6, 0xcb, 0x2a, 0xe0, 0x2a, 0x9c, 0xea, 0xc5, 0x2d,
+	0x28, 0xca, 0xe2, 0xaf, 0xbd, 0xb8, 0xd4, 0xee, 0xd1, 0xb6, 0x64, 0xb4, 0x65, 0x37, 0x68, 0x8b,
+	0x4e, 0xa0, 0xcc, 0x29, 0x39, 0x71, 0x1e, 0xdf, 0x1b, 0x78, 0xdc, 0x2c, 0x6c, 0x18, 0x9b, 0x45,
+	0x5b, 0x11, 0xe8, 0x12, 0x54, 0x06, 0xf8, 0x59, 0xd7, 0xa5, 0x8e, 0xba, 0x8a, 0x86, 0x5d, 0x1e,
+	0xe0, 0x67, 0xef, 0x53, 0x87, 0xa1, 0x16, 0xd4, 0xc4, 0x12, 0xf7, 0x06, 0xa4, 0x3b, 0x60, 0x66,
+	0x49, 0xae, 0x56, 0x07, 0xf8, 0xd9, 0x23, 0x6f, 0x40, 0x3e, 0x62, 0xd6, 0x9f, 0x0c, 0x00, 0x19,
+	0x3e, 0x07, 0x24, 0x7a, 0xf0, 0x18, 0xbd, 0x13, 0xc7, 0x81, 0x0a, 0x9b, 0xab, 0x69, 0x58, 0xc6,
+	0x82, 0xea, 0x53, 0x67, 0x9d, 0x0a, 0x8a, 0x55, 0x28, 0x72, 0xca, 0xb1, 0x1f, 0x67, 0x95, 0x24,
+	0xe2, 0xec, 0xcb, 0x27, 0xd9, 0x27, 0xb2, 0x73, 0xbc, 0xf9, 0x2c, 0xd9, 0x69, 0xfd, 0xd2, 0x80,
+	0x95, 0x03, 0xea, 0x49, 0x17, 0xf6, 0x93, 0x48, 0x5a, 0x1d, 0xbb, 0x2c, 0xe5, 0x95, 0x37, 0x57,
+	0xa0, 0x2e, 0x3f, 0xba, 0xc3, 0xc0, 0x7b, 0x92, 0x28, 0xab, 0x49, 0xde, 0xc7, 0x92, 0x25, 0x2a,
+	0xee, 0xe1, 0xd0, 0x39, 0x26, 0x5c, 0x7a, 0xd7, 0xb0, 0x35, 0x95, 0x89, 0xdc, 0x42, 0x26, 0x72,
+	0xad, 0x3f, 0x1b, 0x80, 0xde, 0xeb, 0xe3, 0x88, 0xef, 0x49, 0xf1, 0x03, 0x12, 0x09, 0x28, 0xd1,
+	0x7d, 0xa8, 0x84, 0x24, 0x52, 0x7b, 0x14, 0x78, 0xb7, 0x32, 0xe0, 0x4d, 0xed, 0x69, 0x8b, 0xbf,
+	0xa3, 0x90, 0x28, 0x18, 0xcb, 0xa1, 0xa2, 0x9a, 0x9f, 0x42, 0x7d, 0x72, 0x61, 0x06, 0x44, 0xdf,
+	0x9f, 0x84, 0xa8, 0xb6, 0xbd, 0x9e, 0x36, 0x34, 0x05, 0x51, 0x0a, 0xc3, 0x1c, 0x14, 0xa5, 0x27,
+	0x68, 0x07, 0xca, 0xea, 0xc0, 0x4c, 0xfb, 0xbb, 0x31, 0xc3, 0xdf, 0xb6, 0x72, 0x98, 0x69, 0x17,
+	0xf5, 0x06, 0x01, 0x91, 0x8c, 0x28, 0xc6, 0x71, 0xc4, 0x35, 0xb6, 0x55, 0xc1, 0xe9, 0x08, 0x86,
+	0x88, 0x47, 0xb9, 0x4c, 0x02, 0x57, 0x63, 0x5b, 0x16, 0xf4, 0x7e, 0xe0, 0xa2, 0xeb, 0xb0, 0x24,
+	0x97, 0x94, 0x26, 0x91, 0x17, 0x12, 0xe1, 0x86, 0xdd, 0x10, 0x6c, 0x65, 0xad, 0x43, 0x9c, 0xe6,
+	0x4f, 0xa0, 0x3e, 0x69, 0x7a, 0x12, 0x84, 0x86, 0x02, 0xe1, 0x4e, 0x1a, 0x84, 0x8d, 0xd3, 0xd0,
+	0x9e, 0x44, 0xe1, 0x8b, 0x1c, 0x2c, 0xef, 0xf6, 0x7a, 0x11, 0xe9, 0x61, 0x4e, 0xe2, 0x54, 0xbe,
+	0x13, 0x27, 0xa3, 0x31, 0x4b, 0xe1, 0x74, 0xee, 0xc7, 0x39, 0xb9, 0x07, 0xa5, 0x23, 0x8f, 0xf8,
+	0x2e, 0xd3, 0xc5, 0xf3, 0x46, 0x7a, 0x63, 0xd6, 0x4e, 0xfb, 0x9e, 0x14, 0x56, 0x88, 0xea, 0x9d,
+	0x22, 0x5c, 0x19, 0x1e, 0x84, 0x3e, 0xe9, 0xaa, 0xf4, 0xce, 0xcb, 0xf4, 0xae, 0x29, 0xde, 0x87,
+	0x82, 0xf5, 0xda, 0xc8, 0xbd, 0x03, 0xb5, 0x09, 0x0b, 0xa7, 0x25, 0x58, 0x25, 0x05, 0x4b, 0x19,
+	0xaa, 0x89, 0xbb, 0xe8, 0xdd, 0x4c, 0x0f, 0xb9, 0x3a, 0xe7, 0x5c, 0x1a, 0x1a, 0x7d, 0x20, 0xb5,
+	0x05, 0xdd, 0x4d, 0x37, 0x14, 0x6b, 0xde, 0xde, 0xe9, 0x3a, 0xb2, 0x9f, 0xea, 0x0c, 0x6a, 0x1c,
+	0xbc, 0x3e, 0x6f, 0xfb, 0xbd, 0xb8, 0x63, 0x28, 0x15, 0x13, 0x1d, 0x64, 0x3f, 0x93, 0xc5, 0x27,
+	0xaa, 0x49, 0x52, 0x45, 0xab, 0x19, 0xf7, 0xa9, 0x5d, 0xa8, 0x84, 0x94, 0x31, 0xef, 0xd0, 0x27,
+	0x66, 0x51, 0x2a, 0xb9, 0x36, 0x4f, 0xc9, 0x81, 0x96, 0x53, 0x3a, 0x92, 0x6d, 0xe3, 0xc2, 0x58,
+	0x9a, 0x2c, 0x8c, 0x6f, 0x43, 0x49, 0xdd, 0xae, 0x59, 0x96, 0x6a, 0x57, 0xd2, 0x6a, 0xef, 0x7b,
+	0xdc, 0xd6, 0x02, 0xe8, 0x6d, 0x28, 0x3a, 0x22, 0x9c, 0xcd, 0x8a, 0x0c, 0xcc, 0x0b, 0x33, 0x22,
+	0xdd, 0x56, 0x12, 0xc8, 0x84, 0x72, 0x88, 0x23, 0xee, 0x61, 0x5f, 0xf6, 0xd4, 0x8a, 0x1d, 0x93,
+	0xe8, 0x2a, 0x34, 0x8e, 0xb0, 0xe7, 0x13, 0xb7, 0xcb, 0xfa, 0x38, 0x72, 0x99, 0x09, 0x1b, 0xf9,
+	0xcd, 0xaa, 0x5d, 0x57, 0xcc, 0x8e, 0xe4, 0x35, 0x3b, 0x50, 0x9b, 0xb8, 0xcc, 0x19, 0xb1, 0xd3,
+	0x4e, 0x27, 0x9d, 0x39, 0xaf, 0x3f, 0x4c, 0x44, 0x55, 0xd3, 0x3e, 0xa5, 0xe0, 0x7f, 0x1d, 0x9d,
+	0x8f, 0x61, 0x31, 0x7d, 0xf5, 0xe7, 0xa7, 0x37, 0x1d, 0x0b, 0xe7, 0xa4, 0xf7, 0x5d, 0x68, 0xa4,
+	0xc2, 0xe3, 0x4c, 0x7d, 0xef, 0x37, 0x06, 0x5c, 0x48, 0x95, 0x1f, 0x16, 0xd2, 0x80, 0x11, 0x74,
+	0x0d, 0x0a, 0x7d, 0x2f, 0x29, 0xdf, 0x33, 0x02, 0x48, 0x2e, 0xa7, 0x1b, 0x73, 0x21, 0x8e, 0xbf,
+	0x89, 0x48, 0xc9, 0x9f, 0x12, 0x29, 0x85, 0xe9, 0x48, 0xb1, 0x3e, 0x81, 0xca, 0x7e, 0xf0, 0x94,
+	0xf8, 0x34, 0x4c, 0x8f, 0x53, 0xc6, 0xeb, 0x8d, 0x53, 0xca, 0xfc, 0xc8, 0xa7, 0x58, 0x0d, 0x45,
+	0x75, 0x3b, 0x26, 0xad, 0x7d, 0x68, 0xc4, 0x9a, 0xf7, 0x30, 0x77, 0xfa, 0xe8, 0x7b, 0x50, 0x25,
+	0x9a, 0x11, 0x9f, 0x35, 0xa3, 0x3f, 0x96, 0xb7, 0xc7, 0x82, 0xd6, 0xcf, 0xa1, 0xdc, 0x19, 0x3a,
+	0x0e, 0x61, 0x4c, 0xd8, 0x62, 0xea, 0x53, 0xba, 0x57, 0xb1, 0x63, 0x12, 0x35, 0xa1, 0x82, 0x1d,
+	0x87, 0x84, 0x9c, 0x24, 0xb3, 0x59, 0x4c, 0xa3, 0x1d, 0xa8, 0x44, 0xe4, 0xa7, 0xc4, 0xe1, 0x24,
+	0xae, 0x42, 0xad, 0xb4, 0x55, 0x5b, 0xaf, 0x26, 0xd6, 0x13, 0x79, 0xeb, 0xd7, 0x06, 0xac, 0x74,
+	0x44, 0xb9, 0x0f, 0x9c, 0xf1, 0xba, 0xb0, 0xc6, 0x34, 0x53, 0x8f, 0xa8, 0x09, 0x2d, 0x30, 0x8c,
+	0x7d, 0xd7, 0x71, 0x35, 0xef, 0x8c, 0x89, 0x9c, 0x68, 0x1a, 0xf2, 0xce, 0xb8, 0x47, 0x75, 0xad,
+	0x14, 0x87, 0xab, 0x25, 0xbc, 0x0f, 0x5c, 0xeb, 0x13, 0x58, 0xce, 0xba, 0x79, 0xa2, 0x1b, 0xab,
+	0x50, 0x24, 0x51, 0x44, 0xa3, 0xf8, 0xa7, 0x03, 0x49, 0x20, 0x04, 0x05, 0x87, 0xba, 0x44, 0xf7,
+	0x72, 0xf9, 0x6d, 0x61, 0x28, 0x1f, 0x0c, 0x59, 0x7f, 0xd7, 0x39, 0x3e, 0x51, 0xe1, 0x24, 0x8a,
+	0xb9, 0x33, 0xa2, 0xb8, 0x04, 0x8d, 0xfb, 0x04, 0xfb, 0xbc, 0xaf, 0x3b, 0xa7, 0xf5, 0x10, 0x16,
+	0x6d, 0x22, 0x27, 0xe3, 0x73, 0x19, 0xbf, 0xad, 0xdf, 0x1b, 0xb0, 0x94, 0x28, 0xd4, 0x49, 0xf5,
+	0x23, 0xa8, 0xba, 0xd4, 0x19, 0x0e, 0x48, 0x90, 0x64, 0xd6, 0xcd, 0xac, 0xc7, 0xa9, 0x1d, 0xed,
+	0xf7, 0x63, 0x71, 0xdd, 0x3c, 0x92, 0xed, 0xcd, 0x1f, 0xc2, 0x62, 0x7a, 0xf1, 0xac, 0xe3, 0x6e,
+	0xe3, 0x1e, 0x8d, 0x7a, 0x84, 0xc7, 0xa7, 0xcd, 0xbe, 0x8a, 0x8c, 0xe9, 0xe7, 0x73, 0xfa, 0x5d,
+	0x95, 0xcb, 0xbe, 0xab, 0x2e, 0x42, 0x29, 0x22, 0x98, 0xd1, 0x40, 0x4f, 0xe4, 0x9a, 0x12, 0x29,
+	0xe2, 0xd0, 0x41, 0x88, 0x1d, 0xf5, 0xb2, 0xa8, 0xd8, 0x31, 0x69, 0xf5, 0x61, 0x31, 0x76, 0x42,
+	0x23, 0xb4, 0x96, 0x46, 0x48, 0xce, 0x7e, 0x09, 0x43, 0x05, 0x43, 0x4f, 0x2d, 0xe6, 0x64, 0xe1,
+	0x48, 0x68, 0xb1, 0x53, 0xab, 0x25, 0xf1, 0x60, 0x38, 0x66, 0x58, 0x3d, 0x58, 0xea, 0x04, 0x38,
+	0x64, 0x7d, 0xca, 0xcf, 0xe7, 0x75, 0x75, 0x11, 0x4a, 0xc3, 0x50, 0x96, 0x18, 0x95, 0x19, 0x9a,
+	0xb2, 0x22, 0x58, 0x1e, 0x1b, 0xd2, 0x87, 0x42, 0x50, 0x08, 0xf0, 0x20, 0x86, 0x54, 0x7e, 0x0b,
+	0x5e, 0x88, 0x79, 0x5f, 0xa3, 0x28, 0xbf, 0x53, 0xc7, 0x53, 0x27, 0x18, 0x1f, 0xaf, 0x09, 0x15,
+	0x65, 0x81, 0xb8, 0x1a, 0xc5, 0x84, 0xb6, 0xfe, 0x62, 0x88, 0x4c, 0x0c, 0x7d, 0xcf, 0x99, 0x98,
+	0x38, 0x1f, 0x40, 0x35, 0xa4, 0x4c, 0xe6, 0x2a, 0x9b, 0xfd, 0x68, 0xc8, 0x6e, 0x69, 0x1f, 0xc4,
+	0xf2, 0x3a, 0xd8, 0x92, 0xfd, 0x59, 0xac, 0x72, 0x59, 0xac, 0x44, 0x34, 0xa6, 0x77, 0x9f, 0x29,
+	0x1a, 0x3f, 0xcb, 0x01, 0x4a, 0xbc, 0x19, 0x17, 0x13, 0x51, 0x5b, 0xd5, 0xf9, 0xb5, 0x9a, 0x98,
+	0x14, 0xe8, 0xd3, 0xa3, 0x23, 0x46, 0xe2, 0xf7, 0x81, 0xa6, 0x04, 0xaa, 0xc9, 0xc3, 0xbb, 0x6e,
+	0xcb, 0x6f, 0xf4, 0x7f, 0xb0, 0xe8, 0x13, 0xec, 0x8a, 0xf7, 0x93, 0x18, 0x71, 0x03, 0x26, 0xf1,
+	0xcb, 0xdb, 0x75, 0xc5, 0x15, 0xe3, 0xfb, 0x43, 0x26, 0x1a, 0x53, 0x48, 0x02, 0xd7, 0x0b, 0x7a,
+	0xdd, 0xc3, 0x11, 0x27, 0xea, 0xad, 0x5b, 0xb0, 0xeb, 0x9a, 0xb9, 0x27, 0x78, 0x53, 0x45, 0xb1,
+	0x34, 0x55, 0x14, 0x85, 0xcf, 0x2e, 0xf1, 0x89, 0x08, 0xc2, 0xb2, 0x0a, 0x76, 0x4d, 0xa2, 0x16,
+	0x00, 0xa7, 0x83, 0x43, 0xc6, 0x69, 0x40, 0x98, 0x59, 0xd9, 0xc8, 0x0b, 0x08, 0xc7, 0x1c, 0xab,
+	0x09, 0x66, 0x8c, 0x81, 0x47, 0x83, 0x0e, 0xc7, 0x7c, 0xc8, 0xe2, 0xe2, 0xf4, 0x45, 0x0e, 0x56,
+	0xa6, 0x16, 0x45, 0x4c, 0x1c, 0x51, 0xdf, 0xa7, 0x3f, 0x23, 0x91, 0x6e, 0x3e, 0x09, 0x2d, 0x10,
+	0x52, 0xe7, 0xd3, 0x11, 0xa6, 0x29, 0x95, 0x26, 0x41, 0x40, 0x92, 0x34, 0xa9, 0xd8, 0x63, 0xc6,
+	0x34, 0x0a, 0x85, 0x19, 0x28, 0x5c, 0x83, 0x25, 0x07, 0x0f, 0x7b, 0x7d, 0xde, 0x1d, 0x86, 0xa9,
+	0xdf, 0x68, 0xea, 0x8a, 0xfd, 0x71, 0x28, 0x7f, 0xa4, 0xd9, 0x84, 0x65, 0x1f, 0x33, 0xde, 0x8d,
+	0x88, 0x43, 0xbc, 0xa7, 0xc4, 0x15, 0x72, 0x25, 0x29, 0xb7, 0x28, 0xf8, 0xb6, 0x66, 0x3f, 0x94,
+	0x3d, 0x14, 0x87, 0xa1, 0xef, 0x69, 0xcc, 0x0a, 0x76, 0x4c, 0x8a, 0x8a, 0x23, 0x75, 0xa8, 0xbe,
+	0x51, 0x51, 0x15, 0x47, 0x70, 0xf6, 0x05, 0xc3, 0x5a, 0x86, 0xc5, 0x83, 0x88, 0x0e, 0x68, 0x12,
+	0xc2, 0xdb, 0x5f, 0x1a, 0x50, 0xde, 0x0f, 0x9e, 0x0c, 0xc9, 0x90, 0xa0, 0x0e, 0x94, 0x3b, 0x78,
+	0x24, 0x1a, 0x09, 0x9a, 0xd3, 0xef, 0x9a, 0x6f, 0xa4, 0xf9, 0xba, 0xa9, 0x5b, 0x6f, 0x7e, 0xf6,
+	0xf7, 0xff, 0xfc, 0x36, 0xb7, 0x62, 0xd5, 0xe5, 0x8f, 0xe3, 0x4f, 0xbf, 0xb3, 0x15, 0x0e, 0x59,
+	0x7f, 0xc7, 0xb8, 0xb1, 0x69, 0xa0, 0x03, 0xa8, 0x76, 0xf0, 0x48, 0xb5, 0x0e, 0x74, 0x39, 0x33,
+	0x16, 0x4d, 0x36, 0x94, 0x79, 0xba, 0x97, 0xa4, 0xee, 0x2a, 0x2a, 0x6f, 0xf5, 0xa5, 0xf8, 0xf6,
+	0x1f, 0xaa, 0x50, 0x52, 0x13, 0xd8, 0x37, 0xe3, 0xf1, 0x87, 0x50, 0xd7, 0x4a, 0x77, 0x9d, 0x63,
+	0xe2, 0xa2, 0xcc, 0x8b, 0x7e, 0x6a, 0x94, 0xc8, 0x9a, 0xd0, 0x3b, 0xad, 0x85, 0x4d, 0xe3, 0xb6,
+	0x81, 0x8e, 0xe5, 0xf9, 0xb5, 0xbf, 0xa7, 0x3e, 0x63, 0x9b, 0x57, 0x4e, 0x90, 0x50, 0xd5, 0xd1,
+	0xba, 0x24, 0x5d, 0xbf, 0x60, 0x2d, 0xc6, 0xae, 0xab, 0x57, 0xde, 0x8e, 0x71, 0x03, 0x7d, 0x0a,
+	0x95, 0x0e, 0x1e, 0xdd, 0x23, 0xfc, 0xb5, 0x6c, 0x4d, 0x0f, 0xa9, 0x96, 0x29, 0x75, 0x23, 0xab,
+	0x11, 0xeb, 0x3e, 0x12, 0xba, 0x76, 0x8c, 0x1b, 0xb7, 0x0d, 0x44, 0x24, 0x2e, 0xe3, 0x27, 0x69,
+	0xeb, 0xe4, 0xa7, 0x75, 0xf3, 0xcd, 0x39, 0xeb, 0xd6, 0x9a, 0x34, 0x72, 0xd1, 0x5a, 0x89, 0x8d,
+	0xe0, 0x78, 0x49, 0x9c, 0xe1, 0xdc, 0x03, 0x06, 0x11, 0x80, 0x0e, 0x1e, 0xe9, 0x49, 0x01, 0xad,
+	0xcd, 0x19, 0x20, 0x94, 0xce, 0xb7, 0x4e, 0x1c, 0x2f, 0xac, 0xa6, 0xd4, 0xbd, 0x6a, 0x2d, 0xc5,
+	0xae, 0x47, 0x4a, 0x40, 0x38, 0xee, 0x41, 0x4d, 0xdc, 0xb4, 0x6e, 0x66, 0x28, 0xa3, 0x29, 0xd3,
+	0x4d, 0x9b, 0xad, 0x79, 0xcb, 0xda, 0xd2, 0x65, 0x69, 0xe9, 0x0d, 0x6b, 0x39, 0xb9, 0x65, 0x2d,
+	0x21, 0x4c, 0x3d, 0x92, 0x57, 0x91, 0x74, 0x00, 0xd4, 0x3a, 0xb9, 0x51, 0x35, 0x37, 0xe6, 0xac,
+	0x27, 0x31, 0x6c, 0x2d, 0xdc, 0x36, 0xd0, 0x2f, 0x60, 0x75, 0x42, 0xeb, 0xb8, 0x6c, 0x5e, 0x9f,
+	0xbd, 0x3b, 0x5b, 0x74, 0x9b, 0xeb, 0xa7, 0xc8, 0xc5, 0x67, 0x42, 0x17, 0xc6, 0xe8, 0x25, 0x22,
+	0xa8, 0x27, 0x6f, 0x49, 0x97, 0xa7, 0xec, 0x2d, 0xa5, 0xab, 0xd6, 0xe9, 0x96, 0xa6, 0xee, 0x29,
+	0x54, 0x0a, 0x04, 0x78, 0x58, 0x06, 0x98, 0x9a, 0xa3, 0xb2, 0x01, 0x96, 0x1a, 0xf1, 0x9a, 0x6b,
+	0xb3, 0x17, 0xe7, 0xe5, 0xe1, 0x91, 0x5c, 0xdf, 0x31, 0x6e, 0xec, 0xad, 0x7d, 0xf5, 0xb2, 0x65,
+	0xbc, 0x78, 0xd9, 0x32, 0xfe, 0xfd, 0xb2, 0x65, 0x7c, 0xfe, 0xaa, 0xb5, 0xf0, 0xd7, 0x57, 0x2d,
+	0xe3, 0xc5, 0xab, 0xd6, 0xc2, 0x3f, 0x5f, 0xb5, 0x16, 0x0e, 0x4b, 0xf2, 0x3f, 0x86, 0xdf, 0xfd,
+	0xef, 0x00, 0xac, 0xa8, 0x98, 0xca, 0xc9, 0x1c, 0x00, 0x00,
 }
 
-func (m *Hit) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Metadata != nil {
-		{
-			size, err := m.Metadata.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintSpec(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x1a
-	}
-	if m.Score != 0 {
-		i -= 4
-		encoding_binary.LittleEndian.PutUint32(dAtA[i:], uint32(math.Float32bits(float32(m.Score))))
-		i--
-		dAtA[i] = 0x15
-	}
-	if m.Id != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.Id))
-		i--
-		dAtA[i] = 0x8
-	}
-	return len(dAtA) - i, nil
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// EnqueueClient is the client API for Enqueue service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type EnqueueClient interface {
+	SayPush(ctx context.Context, opts ...grpc.CallOption) (Enqueue_SayPushClient, error)
+	SayHealth(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*Success, error)
 }
 
-func (m *SearchQueryRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
+type enqueueClient struct {
+	cc *grpc.ClientConn
 }
 
-func (m *SearchQueryRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func NewEnqueueClient(cc *grpc.ClientConn) EnqueueClient {
+	return &enqueueClient{cc}
 }
 
-func (m *SearchQueryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Limit != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.Limit))
-		i--
-		dAtA[i] = 0x20
-	}
-	if m.Query != nil {
-		{
-			size, err := m.Query.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintSpec(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x1a
-	}
-	if m.ToSecond != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.ToSecond))
-		i--
-		dAtA[i] = 0x10
-	}
-	if m.FromSecond != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.FromSecond))
-		i--
-		dAtA[i] = 0x8
-	}
-	return len(dAtA) - i, nil
-}
-
-func (m *CountPerKV) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *enqueueClient) SayPush(ctx context.Context, opts ...grpc.CallOption) (Enqueue_SayPushClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Enqueue_serviceDesc.Streams[0], "/blackrock.io.Enqueue/SayPush", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	x := &enqueueSayPushClient{stream}
+	return x, nil
 }
 
-func (m *CountPerKV) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type Enqueue_SayPushClient interface {
+	Send(*Envelope) error
+	CloseAndRecv() (*Success, error)
+	grpc.ClientStream
 }
 
-func (m *CountPerKV) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Key) > 0 {
-		i -= len(m.Key)
-		copy(dAtA[i:], m.Key)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.Key)))
-		i--
-		dAtA[i] = 0x1a
-	}
-	if m.Total != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.Total))
-		i--
-		dAtA[i] = 0x10
+type enqueueSayPushClient struct {
+	grpc.ClientStream
+}
+
+func (x *enqueueSayPushClient) Send(m *Envelope) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *enqueueSayPushClient) CloseAndRecv() (*Success, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
 	}
-	if len(m.Count) > 0 {
-		for k := range m.Count {
-			v := m.Count[k]
-			baseI := i
-			i = encodeVarintSpec(dAtA, i, uint64(v))
-			i--
-			dAtA[i] = 0x10
-			i -= len(k)
-			copy(dAtA[i:], k)
-			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
-			i--
-			dAtA[i] = 0xa
-			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0xa
-		}
+	m := new(Success)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
+	return m, nil
 }
 
-func (m *PointPerEventType) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *enqueueClient) SayHealth(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*Success, error) {
+	out := new(Success)
+	err := c.cc.Invoke(ctx, "/blackrock.io.Enqueue/SayHealth", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	return out, nil
 }
 
-func (m *PointPerEventType) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+// EnqueueServer is the server API for Enqueue service.
+type EnqueueServer interface {
+	SayPush(Enqueue_SayPushServer) error
+	SayHealth(context.Context, *HealthRequest) (*Success, error)
 }
 
-func (m *PointPerEventType) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.EventType) > 0 {
-		i -= len(m.EventType)
-		copy(dAtA[i:], m.EventType)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.EventType)))
-		i--
-		dAtA[i] = 0x22
-	}
-	if m.Bucket != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.Bucket))
-		i--
-		dAtA[i] = 0x18
-	}
-	if m.CountUnique != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.CountUnique))
-		i--
-		dAtA[i] = 0x10
-	}
-	if m.Count != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.Count))
-		i--
-		dAtA[i] = 0x8
-	}
-	return len(dAtA) - i, nil
+// UnimplementedEnqueueServer can be embedded to have forward compatible implementations.
+type UnimplementedEnqueueServer struct {
 }
 
-func (m *ChartBucketPerTime) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
+func (*UnimplementedEnqueueServer) SayPush(srv Enqueue_SayPushServer) error {
+	return status.Errorf(codes.Unimplemented, "method SayPush not implemented")
+}
+func (*UnimplementedEnqueueServer) SayHealth(ctx context.Context, req *HealthRequest) (*Success, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SayHealth not implemented")
 }
 
-func (m *ChartBucketPerTime) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func RegisterEnqueueServer(s *grpc.Server, srv EnqueueServer) {
+	s.RegisterService(&_Enqueue_serviceDesc, srv)
 }
 
-func (m *ChartBucketPerTime) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.PerType) > 0 {
-		for k := range m.PerType {
-			v := m.PerType[k]
-			baseI := i
-			if v != nil {
-				{
-					size, err := v.MarshalToSizedBuffer(dAtA[:i])
-					if err != nil {
-						return 0, err
-					}
-					i -= size
-					i = encodeVarintSpec(dAtA, i, uint64(size))
-				}
-				i--
-				dAtA[i] = 0x12
-			}
-			i -= len(k)
-			copy(dAtA[i:], k)
-			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
-			i--
-			dAtA[i] = 0xa
-			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0xa
-		}
-	}
-	return len(dAtA) - i, nil
+func _Enqueue_SayPush_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EnqueueServer).SayPush(&enqueueSayPushServer{stream})
 }
 
-func (m *Chart) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
+type Enqueue_SayPushServer interface {
+	SendAndClose(*Success) error
+	Recv() (*Envelope, error)
+	grpc.ServerStream
 }
 
-func (m *Chart) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type enqueueSayPushServer struct {
+	grpc.ServerStream
 }
 
-func (m *Chart) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.TimeBucketSec != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.TimeBucketSec))
-		i--
-		dAtA[i] = 0x20
+func (x *enqueueSayPushServer) SendAndClose(m *Success) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *enqueueSayPushServer) Recv() (*Envelope, error) {
+	m := new(Envelope)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	if m.TimeEnd != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.TimeEnd))
-		i--
-		dAtA[i] = 0x18
+	return m, nil
+}
+
+func _Enqueue_SayHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	if m.TimeStart != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.TimeStart))
-		i--
-		dAtA[i] = 0x10
+	if interceptor == nil {
+		return srv.(EnqueueServer).SayHealth(ctx, in)
 	}
-	if len(m.Buckets) > 0 {
-		for k := range m.Buckets {
-			v := m.Buckets[k]
-			baseI := i
-			if v != nil {
-				{
-					size, err := v.MarshalToSizedBuffer(dAtA[:i])
-					if err != nil {
-						return 0, err
-					}
-					i -= size
-					i = encodeVarintSpec(dAtA, i, uint64(size))
-				}
-				i--
-				dAtA[i] = 0x12
-			}
-			i = encodeVarintSpec(dAtA, i, uint64(k))
-			i--
-			dAtA[i] = 0x8
-			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0xa
-		}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blackrock.io.Enqueue/SayHealth",
 	}
-	return len(dAtA) - i, nil
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EnqueueServer).SayHealth(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *AggregateRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+var _Enqueue_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "blackrock.io.Enqueue",
+	HandlerType: (*EnqueueServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SayHealth",
+			Handler:    _Enqueue_SayHealth_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SayPush",
+			Handler:       _Enqueue_SayPush_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "spec.proto",
+}
+
+// SearchClient is the client API for Search service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type SearchClient interface {
+	SayPush(ctx context.Context, opts ...grpc.CallOption) (Search_SayPushClient, error)
+	SayPushAcked(ctx context.Context, opts ...grpc.CallOption) (Search_SayPushAckedClient, error)
+	SaySearch(ctx context.Context, in *SearchQueryRequest, opts ...grpc.CallOption) (*SearchQueryResponse, error)
+	SayFetch(ctx context.Context, in *SearchQueryRequest, opts ...grpc.CallOption) (Search_SayFetchClient, error)
+	SayAggregate(ctx context.Context, in *AggregateRequest, opts ...grpc.CallOption) (*Aggregate, error)
+	SayHealth(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*Success, error)
+	SayReindex(ctx context.Context, in *ReindexRequest, opts ...grpc.CallOption) (*ReindexResponse, error)
+	SaySnapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*SnapshotResponse, error)
+	SayReplicate(ctx context.Context, in *ReplicateRequest, opts ...grpc.CallOption) (Search_SayReplicateClient, error)
+	SayReplicationStatus(ctx context.Context, in *ReplicationStatusRequest, opts ...grpc.CallOption) (*ReplicationStatus, error)
+	SayPromote(ctx context.Context, in *PromoteRequest, opts ...grpc.CallOption) (*ReplicationStatus, error)
+	SayForget(ctx context.Context, in *ForgetRequest, opts ...grpc.CallOption) (*ForgetResponse, error)
+}
+
+type searchClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewSearchClient(cc *grpc.ClientConn) SearchClient {
+	return &searchClient{cc}
+}
+
+func (c *searchClient) SayPush(ctx context.Context, opts ...grpc.CallOption) (Search_SayPushClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Search_serviceDesc.Streams[0], "/blackrock.io.Search/SayPush", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	x := &searchSayPushClient{stream}
+	return x, nil
 }
 
-func (m *AggregateRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type Search_SayPushClient interface {
+	Send(*Envelope) error
+	CloseAndRecv() (*Success, error)
+	grpc.ClientStream
 }
 
-func (m *AggregateRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.TimeBucketSec != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.TimeBucketSec))
-		i--
-		dAtA[i] = 0x20
-	}
-	if m.SampleLimit != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.SampleLimit))
-		i--
-		dAtA[i] = 0x18
-	}
-	if len(m.Fields) > 0 {
-		for k := range m.Fields {
-			v := m.Fields[k]
-			baseI := i
-			i--
-			if v {
-				dAtA[i] = 1
-			} else {
-				dAtA[i] = 0
-			}
-			i--
-			dAtA[i] = 0x10
-			i -= len(k)
-			copy(dAtA[i:], k)
-			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
-			i--
-			dAtA[i] = 0xa
-			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0x12
-		}
+type searchSayPushClient struct {
+	grpc.ClientStream
+}
+
+func (x *searchSayPushClient) Send(m *Envelope) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *searchSayPushClient) CloseAndRecv() (*Success, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
 	}
-	if m.Query != nil {
-		{
-			size, err := m.Query.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintSpec(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
+	m := new(Success)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
+	return m, nil
 }
 
-func (m *Aggregate) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *searchClient) SayPushAcked(ctx context.Context, opts ...grpc.CallOption) (Search_SayPushAckedClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Search_serviceDesc.Streams[1], "/blackrock.io.Search/SayPushAcked", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	x := &searchSayPushAckedClient{stream}
+	return x, nil
 }
 
-func (m *Aggregate) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type Search_SayPushAckedClient interface {
+	Send(*SequencedEnvelope) error
+	Recv() (*PushAck, error)
+	grpc.ClientStream
 }
 
-func (m *Aggregate) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Chart != nil {
-		{
-			size, err := m.Chart.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintSpec(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x42
+type searchSayPushAckedClient struct {
+	grpc.ClientStream
+}
+
+func (x *searchSayPushAckedClient) Send(m *SequencedEnvelope) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *searchSayPushAckedClient) Recv() (*PushAck, error) {
+	m := new(PushAck)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	if len(m.Sample) > 0 {
-		for iNdEx := len(m.Sample) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Sample[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintSpec(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x3a
-		}
+	return m, nil
+}
+
+func (c *searchClient) SaySearch(ctx context.Context, in *SearchQueryRequest, opts ...grpc.CallOption) (*SearchQueryResponse, error) {
+	out := new(SearchQueryResponse)
+	err := c.cc.Invoke(ctx, "/blackrock.io.Search/SaySearch", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.Total != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.Total))
-		i--
-		dAtA[i] = 0x30
+	return out, nil
+}
+
+func (c *searchClient) SayFetch(ctx context.Context, in *SearchQueryRequest, opts ...grpc.CallOption) (Search_SayFetchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Search_serviceDesc.Streams[2], "/blackrock.io.Search/SayFetch", opts...)
+	if err != nil {
+		return nil, err
 	}
-	if len(m.Possible) > 0 {
-		for k := range m.Possible {
-			v := m.Possible[k]
-			baseI := i
-			i = encodeVarintSpec(dAtA, i, uint64(v))
-			i--
-			dAtA[i] = 0x10
-			i -= len(k)
-			copy(dAtA[i:], k)
-			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
-			i--
-			dAtA[i] = 0xa
-			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0x2a
-		}
+	x := &searchSayFetchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
 	}
-	if len(m.EventType) > 0 {
-		for k := range m.EventType {
-			v := m.EventType[k]
-			baseI := i
-			if v != nil {
-				{
-					size, err := v.MarshalToSizedBuffer(dAtA[:i])
-					if err != nil {
-						return 0, err
-					}
-					i -= size
-					i = encodeVarintSpec(dAtA, i, uint64(size))
-				}
-				i--
-				dAtA[i] = 0x12
-			}
-			i -= len(k)
-			copy(dAtA[i:], k)
-			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
-			i--
-			dAtA[i] = 0xa
-			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0x22
-		}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
 	}
-	if len(m.ForeignId) > 0 {
-		for k := range m.ForeignId {
-			v := m.ForeignId[k]
-			baseI := i
-			if v != nil {
-				{
-					size, err := v.MarshalToSizedBuffer(dAtA[:i])
-					if err != nil {
-						return 0, err
-					}
-					i -= size
-					i = encodeVarintSpec(dAtA, i, uint64(size))
-				}
-				i--
-				dAtA[i] = 0x12
-			}
-			i -= len(k)
-			copy(dAtA[i:], k)
-			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
-			i--
-			dAtA[i] = 0xa
-			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0x1a
-		}
-	}
-	if len(m.Count) > 0 {
-		for k := range m.Count {
-			v := m.Count[k]
-			baseI := i
-			if v != nil {
-				{
-					size, err := v.MarshalToSizedBuffer(dAtA[:i])
-					if err != nil {
-						return 0, err
-					}
-					i -= size
-					i = encodeVarintSpec(dAtA, i, uint64(size))
-				}
-				i--
-				dAtA[i] = 0x12
-			}
-			i -= len(k)
-			copy(dAtA[i:], k)
-			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
-			i--
-			dAtA[i] = 0xa
-			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0x12
-		}
-	}
-	if len(m.Search) > 0 {
-		for k := range m.Search {
-			v := m.Search[k]
-			baseI := i
-			if v != nil {
-				{
-					size, err := v.MarshalToSizedBuffer(dAtA[:i])
-					if err != nil {
-						return 0, err
-					}
-					i -= size
-					i = encodeVarintSpec(dAtA, i, uint64(size))
-				}
-				i--
-				dAtA[i] = 0x12
-			}
-			i -= len(k)
-			copy(dAtA[i:], k)
-			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
-			i--
-			dAtA[i] = 0xa
-			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0xa
-		}
+	return x, nil
+}
+
+type Search_SayFetchClient interface {
+	Recv() (*Hit, error)
+	grpc.ClientStream
+}
+
+type searchSayFetchClient struct {
+	grpc.ClientStream
+}
+
+func (x *searchSayFetchClient) Recv() (*Hit, error) {
+	m := new(Hit)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
+	return m, nil
 }
 
-func (m *SearchQueryResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *searchClient) SayAggregate(ctx context.Context, in *AggregateRequest, opts ...grpc.CallOption) (*Aggregate, error) {
+	out := new(Aggregate)
+	err := c.cc.Invoke(ctx, "/blackrock.io.Search/SayAggregate", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	return out, nil
 }
 
-func (m *SearchQueryResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (c *searchClient) SayHealth(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*Success, error) {
+	out := new(Success)
+	err := c.cc.Invoke(ctx, "/blackrock.io.Search/SayHealth", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func (m *SearchQueryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Total != 0 {
-		i = encodeVarintSpec(dAtA, i, uint64(m.Total))
-		i--
-		dAtA[i] = 0x10
+func (c *searchClient) SayReindex(ctx context.Context, in *ReindexRequest, opts ...grpc.CallOption) (*ReindexResponse, error) {
+	out := new(ReindexResponse)
+	err := c.cc.Invoke(ctx, "/blackrock.io.Search/SayReindex", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if len(m.Hits) > 0 {
-		for iNdEx := len(m.Hits) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Hits[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintSpec(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
-		}
+	return out, nil
+}
+
+func (c *searchClient) SaySnapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*SnapshotResponse, error) {
+	out := new(SnapshotResponse)
+	err := c.cc.Invoke(ctx, "/blackrock.io.Search/SaySnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
+	return out, nil
 }
 
-func (m *Envelope) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *searchClient) SayReplicate(ctx context.Context, in *ReplicateRequest, opts ...grpc.CallOption) (Search_SayReplicateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Search_serviceDesc.Streams[3], "/blackrock.io.Search/SayReplicate", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	x := &searchSayReplicateClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
 }
 
-func (m *Envelope) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type Search_SayReplicateClient interface {
+	Recv() (*ReplicatedEnvelope, error)
+	grpc.ClientStream
 }
 
-func (m *Envelope) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Payload) > 0 {
-		i -= len(m.Payload)
-		copy(dAtA[i:], m.Payload)
-		i = encodeVarintSpec(dAtA, i, uint64(len(m.Payload)))
-		i--
-		dAtA[i] = 0x12
-	}
-	if m.Metadata != nil {
-		{
-			size, err := m.Metadata.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintSpec(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
-	}
-	return len(dAtA) - i, nil
+type searchSayReplicateClient struct {
+	grpc.ClientStream
 }
 
-func (m *Success) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
+func (x *searchSayReplicateClient) Recv() (*ReplicatedEnvelope, error) {
+	m := new(ReplicatedEnvelope)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	return m, nil
 }
 
-func (m *Success) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (c *searchClient) SayReplicationStatus(ctx context.Context, in *ReplicationStatusRequest, opts ...grpc.CallOption) (*ReplicationStatus, error) {
+	out := new(ReplicationStatus)
+	err := c.cc.Invoke(ctx, "/blackrock.io.Search/SayReplicationStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func (m *Success) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Success {
-		i--
-		if m.Success {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i--
-		dAtA[i] = 0x8
+func (c *searchClient) SayPromote(ctx context.Context, in *PromoteRequest, opts ...grpc.CallOption) (*ReplicationStatus, error) {
+	out := new(ReplicationStatus)
+	err := c.cc.Invoke(ctx, "/blackrock.io.Search/SayPromote", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
+	return out, nil
 }
 
-func (m *HealthRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *searchClient) SayForget(ctx context.Context, in *ForgetRequest, opts ...grpc.CallOption) (*ForgetResponse, error) {
+	out := new(ForgetResponse)
+	err := c.cc.Invoke(ctx, "/blackrock.io.Search/SayForget", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	return out, nil
 }
 
-func (m *HealthRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+// SearchServer is the server API for Search service.
+type SearchServer interface {
+	SayPush(Search_SayPushServer) error
+	SayPushAcked(Search_SayPushAckedServer) error
+	SaySearch(context.Context, *SearchQueryRequest) (*SearchQueryResponse, error)
+	SayFetch(*SearchQueryRequest, Search_SayFetchServer) error
+	SayAggregate(context.Context, *AggregateRequest) (*Aggregate, error)
+	SayHealth(context.Context, *HealthRequest) (*Success, error)
+	SayReindex(context.Context, *ReindexRequest) (*ReindexResponse, error)
+	SaySnapshot(context.Context, *SnapshotRequest) (*SnapshotResponse, error)
+	SayReplicate(*ReplicateRequest, Search_SayReplicateServer) error
+	SayReplicationStatus(context.Context, *ReplicationStatusRequest) (*ReplicationStatus, error)
+	SayPromote(context.Context, *PromoteRequest) (*ReplicationStatus, error)
+	SayForget(context.Context, *ForgetRequest) (*ForgetResponse, error)
 }
 
-func (m *HealthRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	return len(dAtA) - i, nil
+// UnimplementedSearchServer can be embedded to have forward compatible implementations.
+type UnimplementedSearchServer struct {
 }
 
-func encodeVarintSpec(dAtA []byte, offset int, v uint64) int {
-	offset -= sovSpec(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
-	}
-	dAtA[offset] = uint8(v)
-	return base
+func (*UnimplementedSearchServer) SayPush(srv Search_SayPushServer) error {
+	return status.Errorf(codes.Unimplemented, "method SayPush not implemented")
 }
-func (m *KV) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	l = len(m.Key)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+func (*UnimplementedSearchServer) SayPushAcked(srv Search_SayPushAckedServer) error {
+	return status.Errorf(codes.Unimplemented, "method SayPushAcked not implemented")
+}
+func (*UnimplementedSearchServer) SaySearch(ctx context.Context, req *SearchQueryRequest) (*SearchQueryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaySearch not implemented")
+}
+func (*UnimplementedSearchServer) SayFetch(req *SearchQueryRequest, srv Search_SayFetchServer) error {
+	return status.Errorf(codes.Unimplemented, "method SayFetch not implemented")
+}
+func (*UnimplementedSearchServer) SayAggregate(ctx context.Context, req *AggregateRequest) (*Aggregate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SayAggregate not implemented")
+}
+func (*UnimplementedSearchServer) SayHealth(ctx context.Context, req *HealthRequest) (*Success, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SayHealth not implemented")
+}
+func (*UnimplementedSearchServer) SayReindex(ctx context.Context, req *ReindexRequest) (*ReindexResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SayReindex not implemented")
+}
+func (*UnimplementedSearchServer) SaySnapshot(ctx context.Context, req *SnapshotRequest) (*SnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaySnapshot not implemented")
+}
+func (*UnimplementedSearchServer) SayReplicate(req *ReplicateRequest, srv Search_SayReplicateServer) error {
+	return status.Errorf(codes.Unimplemented, "method SayReplicate not implemented")
+}
+func (*UnimplementedSearchServer) SayReplicationStatus(ctx context.Context, req *ReplicationStatusRequest) (*ReplicationStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SayReplicationStatus not implemented")
+}
+func (*UnimplementedSearchServer) SayPromote(ctx context.Context, req *PromoteRequest) (*ReplicationStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SayPromote not implemented")
+}
+func (*UnimplementedSearchServer) SayForget(ctx context.Context, req *ForgetRequest) (*ForgetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SayForget not implemented")
+}
+
+func RegisterSearchServer(s *grpc.Server, srv SearchServer) {
+	s.RegisterService(&_Search_serviceDesc, srv)
+}
+
+func _Search_SayPush_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SearchServer).SayPush(&searchSayPushServer{stream})
+}
+
+type Search_SayPushServer interface {
+	SendAndClose(*Success) error
+	Recv() (*Envelope, error)
+	grpc.ServerStream
+}
+
+type searchSayPushServer struct {
+	grpc.ServerStream
+}
+
+func (x *searchSayPushServer) SendAndClose(m *Success) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *searchSayPushServer) Recv() (*Envelope, error) {
+	m := new(Envelope)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	l = len(m.Value)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	return m, nil
+}
+
+func _Search_SayPushAcked_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SearchServer).SayPushAcked(&searchSayPushAckedServer{stream})
+}
+
+type Search_SayPushAckedServer interface {
+	Send(*PushAck) error
+	Recv() (*SequencedEnvelope, error)
+	grpc.ServerStream
+}
+
+type searchSayPushAckedServer struct {
+	grpc.ServerStream
+}
+
+func (x *searchSayPushAckedServer) Send(m *PushAck) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *searchSayPushAckedServer) Recv() (*SequencedEnvelope, error) {
+	m := new(SequencedEnvelope)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	return n
+	return m, nil
 }
 
-func (m *KF) Size() (n int) {
-	if m == nil {
-		return 0
+func _Search_SaySearch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchQueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	l = len(m.Key)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	if interceptor == nil {
+		return srv.(SearchServer).SaySearch(ctx, in)
 	}
-	if m.Value != 0 {
-		n += 9
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blackrock.io.Search/SaySearch",
 	}
-	return n
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).SaySearch(ctx, req.(*SearchQueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *Metadata) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.Search) > 0 {
-		for _, e := range m.Search {
-			l = e.Size()
-			n += 1 + l + sovSpec(uint64(l))
-		}
+func _Search_SayFetch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchQueryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
 	}
-	if len(m.Count) > 0 {
-		for _, e := range m.Count {
-			l = e.Size()
-			n += 1 + l + sovSpec(uint64(l))
-		}
+	return srv.(SearchServer).SayFetch(m, &searchSayFetchServer{stream})
+}
+
+type Search_SayFetchServer interface {
+	Send(*Hit) error
+	grpc.ServerStream
+}
+
+type searchSayFetchServer struct {
+	grpc.ServerStream
+}
+
+func (x *searchSayFetchServer) Send(m *Hit) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Search_SayAggregate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AggregateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	if len(m.Properties) > 0 {
-		for _, e := range m.Properties {
-			l = e.Size()
-			n += 1 + l + sovSpec(uint64(l))
-		}
+	if interceptor == nil {
+		return srv.(SearchServer).SayAggregate(ctx, in)
 	}
-	if m.CreatedAtNs != 0 {
-		n += 1 + sovSpec(uint64(m.CreatedAtNs))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blackrock.io.Search/SayAggregate",
 	}
-	l = len(m.EventType)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).SayAggregate(ctx, req.(*AggregateRequest))
 	}
-	l = len(m.ForeignId)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_SayHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	l = len(m.ForeignType)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	if interceptor == nil {
+		return srv.(SearchServer).SayHealth(ctx, in)
 	}
-	if len(m.Track) > 0 {
-		for k, v := range m.Track {
-			_ = k
-			_ = v
-			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + 1 + sovSpec(uint64(v))
-			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
-		}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blackrock.io.Search/SayHealth",
 	}
-	if m.Id != 0 {
-		n += 9
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).SayHealth(ctx, req.(*HealthRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *SearchableMetadata) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.Search) > 0 {
-		for _, e := range m.Search {
-			l = e.Size()
-			n += 1 + l + sovSpec(uint64(l))
-		}
-	}
-	l = len(m.EventType)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+func _Search_SayReindex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReindexRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	l = len(m.ForeignId)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	if interceptor == nil {
+		return srv.(SearchServer).SayReindex(ctx, in)
 	}
-	l = len(m.ForeignType)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blackrock.io.Search/SayReindex",
 	}
-	if len(m.Track) > 0 {
-		for k, v := range m.Track {
-			_ = k
-			_ = v
-			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + 1 + sovSpec(uint64(v))
-			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
-		}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).SayReindex(ctx, req.(*ReindexRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *BasicMetadata) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.CreatedAtNs != 0 {
-		n += 1 + sovSpec(uint64(m.CreatedAtNs))
+func _Search_SaySnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	l = len(m.EventType)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	if interceptor == nil {
+		return srv.(SearchServer).SaySnapshot(ctx, in)
 	}
-	l = len(m.ForeignId)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blackrock.io.Search/SaySnapshot",
 	}
-	l = len(m.ForeignType)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).SaySnapshot(ctx, req.(*SnapshotRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *CountableMetadata) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.Search) > 0 {
-		for _, e := range m.Search {
-			l = e.Size()
-			n += 1 + l + sovSpec(uint64(l))
-		}
+func _Search_SayReplicate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReplicateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
 	}
-	if len(m.Count) > 0 {
-		for _, e := range m.Count {
-			l = e.Size()
-			n += 1 + l + sovSpec(uint64(l))
-		}
-	}
-	if m.CreatedAtNs != 0 {
-		n += 1 + sovSpec(uint64(m.CreatedAtNs))
+	return srv.(SearchServer).SayReplicate(m, &searchSayReplicateServer{stream})
+}
+
+type Search_SayReplicateServer interface {
+	Send(*ReplicatedEnvelope) error
+	grpc.ServerStream
+}
+
+type searchSayReplicateServer struct {
+	grpc.ServerStream
+}
+
+func (x *searchSayReplicateServer) Send(m *ReplicatedEnvelope) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Search_SayReplicationStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicationStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	l = len(m.EventType)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	if interceptor == nil {
+		return srv.(SearchServer).SayReplicationStatus(ctx, in)
 	}
-	l = len(m.ForeignId)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blackrock.io.Search/SayReplicationStatus",
 	}
-	l = len(m.ForeignType)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).SayReplicationStatus(ctx, req.(*ReplicationStatusRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *Hit) Size() (n int) {
-	if m == nil {
-		return 0
+func _Search_SayPromote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PromoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.Id != 0 {
-		n += 1 + sovSpec(uint64(m.Id))
+	if interceptor == nil {
+		return srv.(SearchServer).SayPromote(ctx, in)
 	}
-	if m.Score != 0 {
-		n += 5
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blackrock.io.Search/SayPromote",
 	}
-	if m.Metadata != nil {
-		l = m.Metadata.Size()
-		n += 1 + l + sovSpec(uint64(l))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).SayPromote(ctx, req.(*PromoteRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *SearchQueryRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.FromSecond != 0 {
-		n += 1 + sovSpec(uint64(m.FromSecond))
+func _Search_SayForget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForgetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	if m.ToSecond != 0 {
-		n += 1 + sovSpec(uint64(m.ToSecond))
+	if interceptor == nil {
+		return srv.(SearchServer).SayForget(ctx, in)
 	}
-	if m.Query != nil {
-		l = m.Query.Size()
-		n += 1 + l + sovSpec(uint64(l))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blackrock.io.Search/SayForget",
 	}
-	if m.Limit != 0 {
-		n += 1 + sovSpec(uint64(m.Limit))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).SayForget(ctx, req.(*ForgetRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *CountPerKV) Size() (n int) {
-	if m == nil {
-		return 0
+var _Search_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "blackrock.io.Search",
+	HandlerType: (*SearchServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SaySearch",
+			Handler:    _Search_SaySearch_Handler,
+		},
+		{
+			MethodName: "SayAggregate",
+			Handler:    _Search_SayAggregate_Handler,
+		},
+		{
+			MethodName: "SayHealth",
+			Handler:    _Search_SayHealth_Handler,
+		},
+		{
+			MethodName: "SayReindex",
+			Handler:    _Search_SayReindex_Handler,
+		},
+		{
+			MethodName: "SaySnapshot",
+			Handler:    _Search_SaySnapshot_Handler,
+		},
+		{
+			MethodName: "SayReplicationStatus",
+			Handler:    _Search_SayReplicationStatus_Handler,
+		},
+		{
+			MethodName: "SayPromote",
+			Handler:    _Search_SayPromote_Handler,
+		},
+		{
+			MethodName: "SayForget",
+			Handler:    _Search_SayForget_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SayPush",
+			Handler:       _Search_SayPush_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "SayPushAcked",
+			Handler:       _Search_SayPushAcked_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "SayFetch",
+			Handler:       _Search_SayFetch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SayReplicate",
+			Handler:       _Search_SayReplicate_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "spec.proto",
+}
+
+func (m *KV) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *KV) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *KV) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if len(m.Count) > 0 {
-		for k, v := range m.Count {
-			_ = k
-			_ = v
-			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + 1 + sovSpec(uint64(v))
-			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
-		}
-	}
-	if m.Total != 0 {
-		n += 1 + sovSpec(uint64(m.Total))
+	if len(m.Value) > 0 {
+		i -= len(m.Value)
+		copy(dAtA[i:], m.Value)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.Value)))
+		i--
+		dAtA[i] = 0x12
 	}
-	l = len(m.Key)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0xa
 	}
-	return n
+	return len(dAtA) - i, nil
 }
 
-func (m *PointPerEventType) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *KF) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *KF) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *KF) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.Count != 0 {
-		n += 1 + sovSpec(uint64(m.Count))
-	}
-	if m.CountUnique != 0 {
-		n += 1 + sovSpec(uint64(m.CountUnique))
-	}
-	if m.Bucket != 0 {
-		n += 1 + sovSpec(uint64(m.Bucket))
+	if m.Value != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Value))))
+		i--
+		dAtA[i] = 0x11
 	}
-	l = len(m.EventType)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0xa
 	}
-	return n
+	return len(dAtA) - i, nil
 }
 
-func (m *ChartBucketPerTime) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *Metadata) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *Metadata) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Metadata) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if len(m.PerType) > 0 {
-		for k, v := range m.PerType {
-			_ = k
-			_ = v
-			l = 0
-			if v != nil {
-				l = v.Size()
-				l += 1 + sovSpec(uint64(l))
-			}
-			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + l
-			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
-		}
+	if len(m.Tenant) > 0 {
+		i -= len(m.Tenant)
+		copy(dAtA[i:], m.Tenant)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.Tenant)))
+		i--
+		dAtA[i] = 0x6a
 	}
-	return n
-}
-
-func (m *Chart) Size() (n int) {
-	if m == nil {
-		return 0
+	if m.Id != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(m.Id))
+		i--
+		dAtA[i] = 0x61
 	}
-	var l int
-	_ = l
-	if len(m.Buckets) > 0 {
-		for k, v := range m.Buckets {
-			_ = k
-			_ = v
-			l = 0
-			if v != nil {
-				l = v.Size()
-				l += 1 + sovSpec(uint64(l))
-			}
-			mapEntrySize := 1 + sovSpec(uint64(k)) + l
-			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+	if len(m.Track) > 0 {
+		for k := range m.Track {
+			v := m.Track[k]
+			baseI := i
+			i = encodeVarintSpec(dAtA, i, uint64(v))
+			i--
+			dAtA[i] = 0x10
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x5a
 		}
 	}
-	if m.TimeStart != 0 {
-		n += 1 + sovSpec(uint64(m.TimeStart))
-	}
-	if m.TimeEnd != 0 {
-		n += 1 + sovSpec(uint64(m.TimeEnd))
+	if len(m.ForeignType) > 0 {
+		i -= len(m.ForeignType)
+		copy(dAtA[i:], m.ForeignType)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignType)))
+		i--
+		dAtA[i] = 0x52
 	}
-	if m.TimeBucketSec != 0 {
-		n += 1 + sovSpec(uint64(m.TimeBucketSec))
+	if len(m.ForeignId) > 0 {
+		i -= len(m.ForeignId)
+		copy(dAtA[i:], m.ForeignId)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignId)))
+		i--
+		dAtA[i] = 0x4a
 	}
-	return n
-}
-
-func (m *AggregateRequest) Size() (n int) {
-	if m == nil {
-		return 0
+	if len(m.EventType) > 0 {
+		i -= len(m.EventType)
+		copy(dAtA[i:], m.EventType)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.EventType)))
+		i--
+		dAtA[i] = 0x3a
 	}
-	var l int
-	_ = l
-	if m.Query != nil {
-		l = m.Query.Size()
-		n += 1 + l + sovSpec(uint64(l))
+	if m.CreatedAtNs != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.CreatedAtNs))
+		i--
+		dAtA[i] = 0x28
 	}
-	if len(m.Fields) > 0 {
-		for k, v := range m.Fields {
-			_ = k
-			_ = v
-			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + 1 + 1
-			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+	if len(m.Properties) > 0 {
+		for iNdEx := len(m.Properties) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Properties[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSpec(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
 		}
 	}
-	if m.SampleLimit != 0 {
-		n += 1 + sovSpec(uint64(m.SampleLimit))
+	if len(m.Count) > 0 {
+		for iNdEx := len(m.Count) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Count[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSpec(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
 	}
-	if m.TimeBucketSec != 0 {
-		n += 1 + sovSpec(uint64(m.TimeBucketSec))
+	if len(m.Search) > 0 {
+		for iNdEx := len(m.Search) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Search[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSpec(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
 	}
-	return n
+	return len(dAtA) - i, nil
 }
 
-func (m *Aggregate) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *SearchableMetadata) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *SearchableMetadata) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SearchableMetadata) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if len(m.Search) > 0 {
-		for k, v := range m.Search {
-			_ = k
-			_ = v
-			l = 0
-			if v != nil {
-				l = v.Size()
-				l += 1 + sovSpec(uint64(l))
-			}
-			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + l
-			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+	if len(m.Track) > 0 {
+		for k := range m.Track {
+			v := m.Track[k]
+			baseI := i
+			i = encodeVarintSpec(dAtA, i, uint64(v))
+			i--
+			dAtA[i] = 0x10
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x5a
 		}
 	}
-	if len(m.Count) > 0 {
-		for k, v := range m.Count {
-			_ = k
-			_ = v
-			l = 0
-			if v != nil {
-				l = v.Size()
-				l += 1 + sovSpec(uint64(l))
+	if len(m.ForeignType) > 0 {
+		i -= len(m.ForeignType)
+		copy(dAtA[i:], m.ForeignType)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignType)))
+		i--
+		dAtA[i] = 0x52
+	}
+	if len(m.ForeignId) > 0 {
+		i -= len(m.ForeignId)
+		copy(dAtA[i:], m.ForeignId)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignId)))
+		i--
+		dAtA[i] = 0x4a
+	}
+	if len(m.EventType) > 0 {
+		i -= len(m.EventType)
+		copy(dAtA[i:], m.EventType)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.EventType)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if len(m.Search) > 0 {
+		for iNdEx := len(m.Search) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Search[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSpec(dAtA, i, uint64(size))
 			}
-			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + l
-			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+			i--
+			dAtA[i] = 0xa
 		}
 	}
+	return len(dAtA) - i, nil
+}
+
+func (m *BasicMetadata) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BasicMetadata) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BasicMetadata) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.ForeignType) > 0 {
+		i -= len(m.ForeignType)
+		copy(dAtA[i:], m.ForeignType)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignType)))
+		i--
+		dAtA[i] = 0x52
+	}
 	if len(m.ForeignId) > 0 {
-		for k, v := range m.ForeignId {
-			_ = k
-			_ = v
-			l = 0
-			if v != nil {
-				l = v.Size()
-				l += 1 + sovSpec(uint64(l))
+		i -= len(m.ForeignId)
+		copy(dAtA[i:], m.ForeignId)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignId)))
+		i--
+		dAtA[i] = 0x4a
+	}
+	if len(m.EventType) > 0 {
+		i -= len(m.EventType)
+		copy(dAtA[i:], m.EventType)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.EventType)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if m.CreatedAtNs != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.CreatedAtNs))
+		i--
+		dAtA[i] = 0x28
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CountableMetadata) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CountableMetadata) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CountableMetadata) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.ForeignType) > 0 {
+		i -= len(m.ForeignType)
+		copy(dAtA[i:], m.ForeignType)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignType)))
+		i--
+		dAtA[i] = 0x52
+	}
+	if len(m.ForeignId) > 0 {
+		i -= len(m.ForeignId)
+		copy(dAtA[i:], m.ForeignId)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignId)))
+		i--
+		dAtA[i] = 0x4a
+	}
+	if len(m.EventType) > 0 {
+		i -= len(m.EventType)
+		copy(dAtA[i:], m.EventType)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.EventType)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if m.CreatedAtNs != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.CreatedAtNs))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.Count) > 0 {
+		for iNdEx := len(m.Count) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Count[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSpec(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Search) > 0 {
+		for iNdEx := len(m.Search) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Search[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSpec(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Hit) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Hit) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Hit) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Metadata != nil {
+		{
+			size, err := m.Metadata.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSpec(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Score != 0 {
+		i -= 4
+		encoding_binary.LittleEndian.PutUint32(dAtA[i:], uint32(math.Float32bits(float32(m.Score))))
+		i--
+		dAtA[i] = 0x15
+	}
+	if m.Id != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Id))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SearchQueryRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SearchQueryRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SearchQueryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.MaxTimeMs != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.MaxTimeMs))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.MaxDocs != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.MaxDocs))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.Limit != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Query != nil {
+		{
+			size, err := m.Query.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSpec(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.ToSecond != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.ToSecond))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.FromSecond != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.FromSecond))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CountPerKV) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CountPerKV) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CountPerKV) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Total != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Total))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Count) > 0 {
+		for k := range m.Count {
+			v := m.Count[k]
+			baseI := i
+			i = encodeVarintSpec(dAtA, i, uint64(v))
+			i--
+			dAtA[i] = 0x10
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PointPerEventType) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PointPerEventType) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PointPerEventType) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.EventType) > 0 {
+		i -= len(m.EventType)
+		copy(dAtA[i:], m.EventType)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.EventType)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Bucket != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Bucket))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.CountUnique != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.CountUnique))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Count != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Count))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ChartBucketPerTime) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ChartBucketPerTime) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ChartBucketPerTime) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.PerType) > 0 {
+		for k := range m.PerType {
+			v := m.PerType[k]
+			baseI := i
+			if v != nil {
+				{
+					size, err := v.MarshalToSizedBuffer(dAtA[:i])
+					if err != nil {
+						return 0, err
+					}
+					i -= size
+					i = encodeVarintSpec(dAtA, i, uint64(size))
+				}
+				i--
+				dAtA[i] = 0x12
+			}
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Chart) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Chart) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Chart) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.TimeBucketSec != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.TimeBucketSec))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.TimeEnd != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.TimeEnd))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.TimeStart != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.TimeStart))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Buckets) > 0 {
+		for k := range m.Buckets {
+			v := m.Buckets[k]
+			baseI := i
+			if v != nil {
+				{
+					size, err := v.MarshalToSizedBuffer(dAtA[:i])
+					if err != nil {
+						return 0, err
+					}
+					i -= size
+					i = encodeVarintSpec(dAtA, i, uint64(size))
+				}
+				i--
+				dAtA[i] = 0x12
+			}
+			i = encodeVarintSpec(dAtA, i, uint64(k))
+			i--
+			dAtA[i] = 0x8
+			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *AggregateRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AggregateRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AggregateRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.TimeBucketSec != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.TimeBucketSec))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.SampleLimit != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.SampleLimit))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Fields) > 0 {
+		for k := range m.Fields {
+			v := m.Fields[k]
+			baseI := i
+			i--
+			if v {
+				dAtA[i] = 1
+			} else {
+				dAtA[i] = 0
+			}
+			i--
+			dAtA[i] = 0x10
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Query != nil {
+		{
+			size, err := m.Query.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSpec(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Aggregate) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Aggregate) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Aggregate) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.FailedShards) > 0 {
+		for iNdEx := len(m.FailedShards) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.FailedShards[iNdEx])
+			copy(dAtA[i:], m.FailedShards[iNdEx])
+			i = encodeVarintSpec(dAtA, i, uint64(len(m.FailedShards[iNdEx])))
+			i--
+			dAtA[i] = 0x52
+		}
+	}
+	if m.Partial {
+		i--
+		if m.Partial {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.Chart != nil {
+		{
+			size, err := m.Chart.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSpec(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x42
+	}
+	if len(m.Sample) > 0 {
+		for iNdEx := len(m.Sample) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Sample[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSpec(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if m.Total != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Total))
+		i--
+		dAtA[i] = 0x30
+	}
+	if len(m.Possible) > 0 {
+		for k := range m.Possible {
+			v := m.Possible[k]
+			baseI := i
+			i = encodeVarintSpec(dAtA, i, uint64(v))
+			i--
+			dAtA[i] = 0x10
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if len(m.EventType) > 0 {
+		for k := range m.EventType {
+			v := m.EventType[k]
+			baseI := i
+			if v != nil {
+				{
+					size, err := v.MarshalToSizedBuffer(dAtA[:i])
+					if err != nil {
+						return 0, err
+					}
+					i -= size
+					i = encodeVarintSpec(dAtA, i, uint64(size))
+				}
+				i--
+				dAtA[i] = 0x12
+			}
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if len(m.ForeignId) > 0 {
+		for k := range m.ForeignId {
+			v := m.ForeignId[k]
+			baseI := i
+			if v != nil {
+				{
+					size, err := v.MarshalToSizedBuffer(dAtA[:i])
+					if err != nil {
+						return 0, err
+					}
+					i -= size
+					i = encodeVarintSpec(dAtA, i, uint64(size))
+				}
+				i--
+				dAtA[i] = 0x12
+			}
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if len(m.Count) > 0 {
+		for k := range m.Count {
+			v := m.Count[k]
+			baseI := i
+			if v != nil {
+				{
+					size, err := v.MarshalToSizedBuffer(dAtA[:i])
+					if err != nil {
+						return 0, err
+					}
+					i -= size
+					i = encodeVarintSpec(dAtA, i, uint64(size))
+				}
+				i--
+				dAtA[i] = 0x12
+			}
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Search) > 0 {
+		for k := range m.Search {
+			v := m.Search[k]
+			baseI := i
+			if v != nil {
+				{
+					size, err := v.MarshalToSizedBuffer(dAtA[:i])
+					if err != nil {
+						return 0, err
+					}
+					i -= size
+					i = encodeVarintSpec(dAtA, i, uint64(size))
+				}
+				i--
+				dAtA[i] = 0x12
+			}
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SearchQueryResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SearchQueryResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SearchQueryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.FailedShards) > 0 {
+		for iNdEx := len(m.FailedShards) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.FailedShards[iNdEx])
+			copy(dAtA[i:], m.FailedShards[iNdEx])
+			i = encodeVarintSpec(dAtA, i, uint64(len(m.FailedShards[iNdEx])))
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if m.Partial {
+		i--
+		if m.Partial {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Total != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Total))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Hits) > 0 {
+		for iNdEx := len(m.Hits) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Hits[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSpec(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Envelope) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Envelope) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Envelope) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Payload) > 0 {
+		i -= len(m.Payload)
+		copy(dAtA[i:], m.Payload)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.Payload)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Metadata != nil {
+		{
+			size, err := m.Metadata.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSpec(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *EnvelopeBatch) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *EnvelopeBatch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *EnvelopeBatch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Envelopes) > 0 {
+		for iNdEx := len(m.Envelopes) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Envelopes[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSpec(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Success) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Success) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Success) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Rejected) > 0 {
+		for iNdEx := len(m.Rejected) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Rejected[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSpec(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.Accepted != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Accepted))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Success {
+		i--
+		if m.Success {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SequencedEnvelope) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SequencedEnvelope) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SequencedEnvelope) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.PartitionId {
+		i--
+		if m.PartitionId {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Envelope != nil {
+		{
+			size, err := m.Envelope.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSpec(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Sequence != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Sequence))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *RejectedEnvelope) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RejectedEnvelope) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RejectedEnvelope) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Code != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Code))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Error) > 0 {
+		i -= len(m.Error)
+		copy(dAtA[i:], m.Error)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.Error)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Sequence != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Sequence))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PushAck) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PushAck) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushAck) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Rejected) > 0 {
+		for iNdEx := len(m.Rejected) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Rejected[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSpec(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Sequence != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Sequence))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *HealthRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *HealthRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HealthRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *ReindexRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReindexRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReindexRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.ToSecond != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.ToSecond))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.FromSecond != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.FromSecond))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ReindexResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReindexResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReindexResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Documents) > 0 {
+		for k := range m.Documents {
+			v := m.Documents[k]
+			baseI := i
+			i = encodeVarintSpec(dAtA, i, uint64(v))
+			i--
+			dAtA[i] = 0x10
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ForgetRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ForgetRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ForgetRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Compact {
+		i--
+		if m.Compact {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.Reason) > 0 {
+		i -= len(m.Reason)
+		copy(dAtA[i:], m.Reason)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.Reason)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.ForeignId) > 0 {
+		i -= len(m.ForeignId)
+		copy(dAtA[i:], m.ForeignId)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignId)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.ForeignType) > 0 {
+		i -= len(m.ForeignType)
+		copy(dAtA[i:], m.ForeignType)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.ForeignType)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ForgetResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ForgetResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ForgetResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Compacted != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Compacted))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Segments) > 0 {
+		for iNdEx := len(m.Segments) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Segments[iNdEx])
+			copy(dAtA[i:], m.Segments[iNdEx])
+			i = encodeVarintSpec(dAtA, i, uint64(len(m.Segments[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Documents != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Documents))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SnapshotRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SnapshotRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SnapshotRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Upload {
+		i--
+		if m.Upload {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.ToSecond != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.ToSecond))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.FromSecond != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.FromSecond))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SnapshotResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SnapshotResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SnapshotResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Uploaded {
+		i--
+		if m.Uploaded {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Segments != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Segments))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Path) > 0 {
+		i -= len(m.Path)
+		copy(dAtA[i:], m.Path)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.Path)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ReplicateRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReplicateRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReplicateRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.FromSecond != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.FromSecond))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Positions) > 0 {
+		for k := range m.Positions {
+			v := m.Positions[k]
+			baseI := i
+			i = encodeVarintSpec(dAtA, i, uint64(v))
+			i--
+			dAtA[i] = 0x10
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintSpec(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintSpec(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ReplicatedEnvelope) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReplicatedEnvelope) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReplicatedEnvelope) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Tombstones) > 0 {
+		dAtA14 := make([]byte, len(m.Tombstones)*10)
+		var j13 int
+		for _, num := range m.Tombstones {
+			for num >= 1<<7 {
+				dAtA14[j13] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j13++
+			}
+			dAtA14[j13] = uint8(num)
+			j13++
+		}
+		i -= j13
+		copy(dAtA[i:], dAtA14[:j13])
+		i = encodeVarintSpec(dAtA, i, uint64(j13))
+		i--
+		dAtA[i] = 0x42
+	}
+	if m.Deleted {
+		i--
+		if m.Deleted {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.PartitionId {
+		i--
+		if m.PartitionId {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.PendingBytes != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.PendingBytes))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.LeaderTimeNs != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.LeaderTimeNs))
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.Data) > 0 {
+		i -= len(m.Data)
+		copy(dAtA[i:], m.Data)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.Data)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Offset != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Offset))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Segment) > 0 {
+		i -= len(m.Segment)
+		copy(dAtA[i:], m.Segment)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.Segment)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ReplicationStatusRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReplicationStatusRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReplicationStatusRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *ReplicationStatus) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReplicationStatus) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReplicationStatus) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.LastError) > 0 {
+		i -= len(m.LastError)
+		copy(dAtA[i:], m.LastError)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.LastError)))
+		i--
+		dAtA[i] = 0x42
+	}
+	if m.Applied != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.Applied))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.LastReceivedNs != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.LastReceivedNs))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.CaughtUpAtNs != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.CaughtUpAtNs))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.PendingBytes != 0 {
+		i = encodeVarintSpec(dAtA, i, uint64(m.PendingBytes))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Connected {
+		i--
+		if m.Connected {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Leader) > 0 {
+		i -= len(m.Leader)
+		copy(dAtA[i:], m.Leader)
+		i = encodeVarintSpec(dAtA, i, uint64(len(m.Leader)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Follower {
+		i--
+		if m.Follower {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PromoteRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PromoteRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PromoteRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintSpec(dAtA []byte, offset int, v uint64) int {
+	offset -= sovSpec(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *KV) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	return n
+}
+
+func (m *KF) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	if m.Value != 0 {
+		n += 9
+	}
+	return n
+}
+
+func (m *Metadata) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Search) > 0 {
+		for _, e := range m.Search {
+			l = e.Size()
+			n += 1 + l + sovSpec(uint64(l))
+		}
+	}
+	if len(m.Count) > 0 {
+		for _, e := range m.Count {
+			l = e.Size()
+			n += 1 + l + sovSpec(uint64(l))
+		}
+	}
+	if len(m.Properties) > 0 {
+		for _, e := range m.Properties {
+			l = e.Size()
+			n += 1 + l + sovSpec(uint64(l))
+		}
+	}
+	if m.CreatedAtNs != 0 {
+		n += 1 + sovSpec(uint64(m.CreatedAtNs))
+	}
+	l = len(m.EventType)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	l = len(m.ForeignId)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	l = len(m.ForeignType)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	if len(m.Track) > 0 {
+		for k, v := range m.Track {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + 1 + sovSpec(uint64(v))
+			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+		}
+	}
+	if m.Id != 0 {
+		n += 9
+	}
+	l = len(m.Tenant)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	return n
+}
+
+func (m *SearchableMetadata) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Search) > 0 {
+		for _, e := range m.Search {
+			l = e.Size()
+			n += 1 + l + sovSpec(uint64(l))
+		}
+	}
+	l = len(m.EventType)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	l = len(m.ForeignId)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	l = len(m.ForeignType)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	if len(m.Track) > 0 {
+		for k, v := range m.Track {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + 1 + sovSpec(uint64(v))
+			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func (m *BasicMetadata) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.CreatedAtNs != 0 {
+		n += 1 + sovSpec(uint64(m.CreatedAtNs))
+	}
+	l = len(m.EventType)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	l = len(m.ForeignId)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	l = len(m.ForeignType)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	return n
+}
+
+func (m *CountableMetadata) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Search) > 0 {
+		for _, e := range m.Search {
+			l = e.Size()
+			n += 1 + l + sovSpec(uint64(l))
+		}
+	}
+	if len(m.Count) > 0 {
+		for _, e := range m.Count {
+			l = e.Size()
+			n += 1 + l + sovSpec(uint64(l))
+		}
+	}
+	if m.CreatedAtNs != 0 {
+		n += 1 + sovSpec(uint64(m.CreatedAtNs))
+	}
+	l = len(m.EventType)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	l = len(m.ForeignId)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	l = len(m.ForeignType)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	return n
+}
+
+func (m *Hit) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Id != 0 {
+		n += 1 + sovSpec(uint64(m.Id))
+	}
+	if m.Score != 0 {
+		n += 5
+	}
+	if m.Metadata != nil {
+		l = m.Metadata.Size()
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	return n
+}
+
+func (m *SearchQueryRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.FromSecond != 0 {
+		n += 1 + sovSpec(uint64(m.FromSecond))
+	}
+	if m.ToSecond != 0 {
+		n += 1 + sovSpec(uint64(m.ToSecond))
+	}
+	if m.Query != nil {
+		l = m.Query.Size()
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	if m.Limit != 0 {
+		n += 1 + sovSpec(uint64(m.Limit))
+	}
+	if m.MaxDocs != 0 {
+		n += 1 + sovSpec(uint64(m.MaxDocs))
+	}
+	if m.MaxTimeMs != 0 {
+		n += 1 + sovSpec(uint64(m.MaxTimeMs))
+	}
+	return n
+}
+
+func (m *CountPerKV) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Count) > 0 {
+		for k, v := range m.Count {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + 1 + sovSpec(uint64(v))
+			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+		}
+	}
+	if m.Total != 0 {
+		n += 1 + sovSpec(uint64(m.Total))
+	}
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	return n
+}
+
+func (m *PointPerEventType) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Count != 0 {
+		n += 1 + sovSpec(uint64(m.Count))
+	}
+	if m.CountUnique != 0 {
+		n += 1 + sovSpec(uint64(m.CountUnique))
+	}
+	if m.Bucket != 0 {
+		n += 1 + sovSpec(uint64(m.Bucket))
+	}
+	l = len(m.EventType)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	return n
+}
+
+func (m *ChartBucketPerTime) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.PerType) > 0 {
+		for k, v := range m.PerType {
+			_ = k
+			_ = v
+			l = 0
+			if v != nil {
+				l = v.Size()
+				l += 1 + sovSpec(uint64(l))
+			}
+			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + l
+			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func (m *Chart) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Buckets) > 0 {
+		for k, v := range m.Buckets {
+			_ = k
+			_ = v
+			l = 0
+			if v != nil {
+				l = v.Size()
+				l += 1 + sovSpec(uint64(l))
+			}
+			mapEntrySize := 1 + sovSpec(uint64(k)) + l
+			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+		}
+	}
+	if m.TimeStart != 0 {
+		n += 1 + sovSpec(uint64(m.TimeStart))
+	}
+	if m.TimeEnd != 0 {
+		n += 1 + sovSpec(uint64(m.TimeEnd))
+	}
+	if m.TimeBucketSec != 0 {
+		n += 1 + sovSpec(uint64(m.TimeBucketSec))
+	}
+	return n
+}
+
+func (m *AggregateRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Query != nil {
+		l = m.Query.Size()
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	if len(m.Fields) > 0 {
+		for k, v := range m.Fields {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + 1 + 1
+			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+		}
+	}
+	if m.SampleLimit != 0 {
+		n += 1 + sovSpec(uint64(m.SampleLimit))
+	}
+	if m.TimeBucketSec != 0 {
+		n += 1 + sovSpec(uint64(m.TimeBucketSec))
+	}
+	return n
+}
+
+func (m *Aggregate) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Search) > 0 {
+		for k, v := range m.Search {
+			_ = k
+			_ = v
+			l = 0
+			if v != nil {
+				l = v.Size()
+				l += 1 + sovSpec(uint64(l))
+			}
+			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + l
+			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+		}
+	}
+	if len(m.Count) > 0 {
+		for k, v := range m.Count {
+			_ = k
+			_ = v
+			l = 0
+			if v != nil {
+				l = v.Size()
+				l += 1 + sovSpec(uint64(l))
+			}
+			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + l
+			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+		}
+	}
+	if len(m.ForeignId) > 0 {
+		for k, v := range m.ForeignId {
+			_ = k
+			_ = v
+			l = 0
+			if v != nil {
+				l = v.Size()
+				l += 1 + sovSpec(uint64(l))
+			}
+			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + l
+			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+		}
+	}
+	if len(m.EventType) > 0 {
+		for k, v := range m.EventType {
+			_ = k
+			_ = v
+			l = 0
+			if v != nil {
+				l = v.Size()
+				l += 1 + sovSpec(uint64(l))
+			}
+			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + l
+			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+		}
+	}
+	if len(m.Possible) > 0 {
+		for k, v := range m.Possible {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + 1 + sovSpec(uint64(v))
+			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+		}
+	}
+	if m.Total != 0 {
+		n += 1 + sovSpec(uint64(m.Total))
+	}
+	if len(m.Sample) > 0 {
+		for _, e := range m.Sample {
+			l = e.Size()
+			n += 1 + l + sovSpec(uint64(l))
+		}
+	}
+	if m.Chart != nil {
+		l = m.Chart.Size()
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	if m.Partial {
+		n += 2
+	}
+	if len(m.FailedShards) > 0 {
+		for _, s := range m.FailedShards {
+			l = len(s)
+			n += 1 + l + sovSpec(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *SearchQueryResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Hits) > 0 {
+		for _, e := range m.Hits {
+			l = e.Size()
+			n += 1 + l + sovSpec(uint64(l))
+		}
+	}
+	if m.Total != 0 {
+		n += 1 + sovSpec(uint64(m.Total))
+	}
+	if m.Partial {
+		n += 2
+	}
+	if len(m.FailedShards) > 0 {
+		for _, s := range m.FailedShards {
+			l = len(s)
+			n += 1 + l + sovSpec(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *Envelope) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Metadata != nil {
+		l = m.Metadata.Size()
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	l = len(m.Payload)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	return n
+}
+
+func (m *EnvelopeBatch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Envelopes) > 0 {
+		for _, e := range m.Envelopes {
+			l = e.Size()
+			n += 1 + l + sovSpec(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *Success) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Success {
+		n += 2
+	}
+	if m.Accepted != 0 {
+		n += 1 + sovSpec(uint64(m.Accepted))
+	}
+	if len(m.Rejected) > 0 {
+		for _, e := range m.Rejected {
+			l = e.Size()
+			n += 1 + l + sovSpec(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *SequencedEnvelope) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Sequence != 0 {
+		n += 1 + sovSpec(uint64(m.Sequence))
+	}
+	if m.Envelope != nil {
+		l = m.Envelope.Size()
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	if m.PartitionId {
+		n += 2
+	}
+	return n
+}
+
+func (m *RejectedEnvelope) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Sequence != 0 {
+		n += 1 + sovSpec(uint64(m.Sequence))
+	}
+	l = len(m.Error)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	if m.Code != 0 {
+		n += 1 + sovSpec(uint64(m.Code))
+	}
+	return n
+}
+
+func (m *PushAck) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Sequence != 0 {
+		n += 1 + sovSpec(uint64(m.Sequence))
+	}
+	if len(m.Rejected) > 0 {
+		for _, e := range m.Rejected {
+			l = e.Size()
+			n += 1 + l + sovSpec(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *HealthRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *ReindexRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.FromSecond != 0 {
+		n += 1 + sovSpec(uint64(m.FromSecond))
+	}
+	if m.ToSecond != 0 {
+		n += 1 + sovSpec(uint64(m.ToSecond))
+	}
+	return n
+}
+
+func (m *ReindexResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Documents) > 0 {
+		for k, v := range m.Documents {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + 1 + sovSpec(uint64(v))
+			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func (m *ForgetRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.ForeignType)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	l = len(m.ForeignId)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	l = len(m.Reason)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	if m.Compact {
+		n += 2
+	}
+	return n
+}
+
+func (m *ForgetResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Documents != 0 {
+		n += 1 + sovSpec(uint64(m.Documents))
+	}
+	if len(m.Segments) > 0 {
+		for _, s := range m.Segments {
+			l = len(s)
+			n += 1 + l + sovSpec(uint64(l))
+		}
+	}
+	if m.Compacted != 0 {
+		n += 1 + sovSpec(uint64(m.Compacted))
+	}
+	return n
+}
+
+func (m *SnapshotRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.FromSecond != 0 {
+		n += 1 + sovSpec(uint64(m.FromSecond))
+	}
+	if m.ToSecond != 0 {
+		n += 1 + sovSpec(uint64(m.ToSecond))
+	}
+	if m.Upload {
+		n += 2
+	}
+	return n
+}
+
+func (m *SnapshotResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	l = len(m.Path)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	if m.Segments != 0 {
+		n += 1 + sovSpec(uint64(m.Segments))
+	}
+	if m.Uploaded {
+		n += 2
+	}
+	return n
+}
+
+func (m *ReplicateRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Positions) > 0 {
+		for k, v := range m.Positions {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + 1 + sovSpec(uint64(v))
+			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
+		}
+	}
+	if m.FromSecond != 0 {
+		n += 1 + sovSpec(uint64(m.FromSecond))
+	}
+	return n
+}
+
+func (m *ReplicatedEnvelope) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Segment)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	if m.Offset != 0 {
+		n += 1 + sovSpec(uint64(m.Offset))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	if m.LeaderTimeNs != 0 {
+		n += 1 + sovSpec(uint64(m.LeaderTimeNs))
+	}
+	if m.PendingBytes != 0 {
+		n += 1 + sovSpec(uint64(m.PendingBytes))
+	}
+	if m.PartitionId {
+		n += 2
+	}
+	if m.Deleted {
+		n += 2
+	}
+	if len(m.Tombstones) > 0 {
+		l = 0
+		for _, e := range m.Tombstones {
+			l += sovSpec(uint64(e))
+		}
+		n += 1 + sovSpec(uint64(l)) + l
+	}
+	return n
+}
+
+func (m *ReplicationStatusRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *ReplicationStatus) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Follower {
+		n += 2
+	}
+	l = len(m.Leader)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	if m.Connected {
+		n += 2
+	}
+	if m.PendingBytes != 0 {
+		n += 1 + sovSpec(uint64(m.PendingBytes))
+	}
+	if m.CaughtUpAtNs != 0 {
+		n += 1 + sovSpec(uint64(m.CaughtUpAtNs))
+	}
+	if m.LastReceivedNs != 0 {
+		n += 1 + sovSpec(uint64(m.LastReceivedNs))
+	}
+	if m.Applied != 0 {
+		n += 1 + sovSpec(uint64(m.Applied))
+	}
+	l = len(m.LastError)
+	if l > 0 {
+		n += 1 + l + sovSpec(uint64(l))
+	}
+	return n
+}
+
+func (m *PromoteRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func sovSpec(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozSpec(x uint64) (n int) {
+	return sovSpec(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *KV) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: KV: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: KV: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *KF) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: KF: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: KF: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Value = float64(math.Float64frombits(v))
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Metadata) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Metadata: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Metadata: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Search", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Search = append(m.Search, KV{})
+			if err := m.Search[len(m.Search)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Count = append(m.Count, KV{})
+			if err := m.Count[len(m.Count)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Properties", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Properties = append(m.Properties, KV{})
+			if err := m.Properties[len(m.Properties)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreatedAtNs", wireType)
+			}
+			m.CreatedAtNs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CreatedAtNs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EventType", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.EventType = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ForeignId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ForeignId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ForeignType", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ForeignType = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Track", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Track == nil {
+				m.Track = make(map[string]uint32)
+			}
+			var mapkey string
+			var mapvalue uint32
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSpec
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapvalue |= uint32(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipSpec(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Track[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 12:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			m.Id = 0
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Id = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Tenant", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Tenant = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SearchableMetadata) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SearchableMetadata: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SearchableMetadata: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Search", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Search = append(m.Search, KV{})
+			if err := m.Search[len(m.Search)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EventType", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.EventType = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ForeignId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ForeignId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ForeignType", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ForeignType = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Track", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Track == nil {
+				m.Track = make(map[string]uint32)
+			}
+			var mapkey string
+			var mapvalue uint32
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSpec
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapvalue |= uint32(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipSpec(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Track[mapkey] = mapvalue
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BasicMetadata) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BasicMetadata: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BasicMetadata: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreatedAtNs", wireType)
+			}
+			m.CreatedAtNs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CreatedAtNs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EventType", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.EventType = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ForeignId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ForeignId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ForeignType", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ForeignType = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CountableMetadata) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CountableMetadata: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CountableMetadata: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Search", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Search = append(m.Search, KV{})
+			if err := m.Search[len(m.Search)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Count = append(m.Count, KV{})
+			if err := m.Count[len(m.Count)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreatedAtNs", wireType)
+			}
+			m.CreatedAtNs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CreatedAtNs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EventType", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.EventType = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ForeignId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ForeignId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ForeignType", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ForeignType = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Hit) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Hit: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Hit: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			m.Id = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Id |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 5 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Score", wireType)
+			}
+			var v uint32
+			if (iNdEx + 4) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint32(encoding_binary.LittleEndian.Uint32(dAtA[iNdEx:]))
+			iNdEx += 4
+			m.Score = float32(math.Float32frombits(v))
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Metadata == nil {
+				m.Metadata = &Metadata{}
+			}
+			if err := m.Metadata.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SearchQueryRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SearchQueryRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SearchQueryRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FromSecond", wireType)
+			}
+			m.FromSecond = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FromSecond |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ToSecond", wireType)
+			}
+			m.ToSecond = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ToSecond |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Query", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Query == nil {
+				m.Query = &go_query_index_dsl.Query{}
+			}
+			if err := m.Query.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxDocs", wireType)
+			}
+			m.MaxDocs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxDocs |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxTimeMs", wireType)
+			}
+			m.MaxTimeMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxTimeMs |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CountPerKV) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CountPerKV: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CountPerKV: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Count == nil {
+				m.Count = make(map[string]uint32)
+			}
+			var mapkey string
+			var mapvalue uint32
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSpec
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapvalue |= uint32(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipSpec(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Count[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Total", wireType)
+			}
+			m.Total = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Total |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PointPerEventType) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PointPerEventType: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PointPerEventType: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+			}
+			m.Count = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Count |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CountUnique", wireType)
+			}
+			m.CountUnique = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CountUnique |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Bucket", wireType)
+			}
+			m.Bucket = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Bucket |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EventType", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.EventType = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ChartBucketPerTime) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ChartBucketPerTime: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ChartBucketPerTime: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PerType", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.PerType == nil {
+				m.PerType = make(map[string]*PointPerEventType)
+			}
+			var mapkey string
+			var mapvalue *PointPerEventType
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSpec
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var mapmsglen int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapmsglen |= int(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					if mapmsglen < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postmsgIndex := iNdEx + mapmsglen
+					if postmsgIndex < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postmsgIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = &PointPerEventType{}
+					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
+						return err
+					}
+					iNdEx = postmsgIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipSpec(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.PerType[mapkey] = mapvalue
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + l
-			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
-		}
-	}
-	if len(m.EventType) > 0 {
-		for k, v := range m.EventType {
-			_ = k
-			_ = v
-			l = 0
-			if v != nil {
-				l = v.Size()
-				l += 1 + sovSpec(uint64(l))
+			if skippy < 0 {
+				return ErrInvalidLengthSpec
 			}
-			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + l
-			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
-		}
-	}
-	if len(m.Possible) > 0 {
-		for k, v := range m.Possible {
-			_ = k
-			_ = v
-			mapEntrySize := 1 + len(k) + sovSpec(uint64(len(k))) + 1 + sovSpec(uint64(v))
-			n += mapEntrySize + 1 + sovSpec(uint64(mapEntrySize))
-		}
-	}
-	if m.Total != 0 {
-		n += 1 + sovSpec(uint64(m.Total))
-	}
-	if len(m.Sample) > 0 {
-		for _, e := range m.Sample {
-			l = e.Size()
-			n += 1 + l + sovSpec(uint64(l))
-		}
-	}
-	if m.Chart != nil {
-		l = m.Chart.Size()
-		n += 1 + l + sovSpec(uint64(l))
-	}
-	return n
-}
-
-func (m *SearchQueryResponse) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.Hits) > 0 {
-		for _, e := range m.Hits {
-			l = e.Size()
-			n += 1 + l + sovSpec(uint64(l))
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
 		}
 	}
-	if m.Total != 0 {
-		n += 1 + sovSpec(uint64(m.Total))
-	}
-	return n
-}
-
-func (m *Envelope) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.Metadata != nil {
-		l = m.Metadata.Size()
-		n += 1 + l + sovSpec(uint64(l))
-	}
-	l = len(m.Payload)
-	if l > 0 {
-		n += 1 + l + sovSpec(uint64(l))
-	}
-	return n
-}
-
-func (m *Success) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.Success {
-		n += 2
-	}
-	return n
-}
 
-func (m *HealthRequest) Size() (n int) {
-	if m == nil {
-		return 0
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	var l int
-	_ = l
-	return n
-}
-
-func sovSpec(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozSpec(x uint64) (n int) {
-	return sovSpec(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	return nil
 }
-func (m *KV) Unmarshal(dAtA []byte) error {
+func (m *Chart) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3378,17 +8061,17 @@ func (m *KV) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: KV: wiretype end group for non-group")
+			return fmt.Errorf("proto: Chart: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: KV: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Chart: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Buckets", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -3398,29 +8081,150 @@ func (m *KV) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthSpec
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Key = string(dAtA[iNdEx:postIndex])
+			if m.Buckets == nil {
+				m.Buckets = make(map[uint32]*ChartBucketPerTime)
+			}
+			var mapkey uint32
+			var mapvalue *ChartBucketPerTime
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSpec
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapkey |= uint32(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else if fieldNum == 2 {
+					var mapmsglen int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapmsglen |= int(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					if mapmsglen < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postmsgIndex := iNdEx + mapmsglen
+					if postmsgIndex < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postmsgIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = &ChartBucketPerTime{}
+					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
+						return err
+					}
+					iNdEx = postmsgIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipSpec(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Buckets[mapkey] = mapvalue
 			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TimeStart", wireType)
 			}
-			var stringLen uint64
+			m.TimeStart = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TimeStart |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TimeEnd", wireType)
+			}
+			m.TimeEnd = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TimeEnd |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TimeBucketSec", wireType)
+			}
+			m.TimeBucketSec = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -3430,24 +8234,11 @@ func (m *KV) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.TimeBucketSec |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Value = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSpec(dAtA[iNdEx:])
@@ -3472,7 +8263,7 @@ func (m *KV) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *KF) Unmarshal(dAtA []byte) error {
+func (m *AggregateRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3495,17 +8286,17 @@ func (m *KF) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: KF: wiretype end group for non-group")
+			return fmt.Errorf("proto: AggregateRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: KF: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: AggregateRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Query", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -3515,35 +8306,181 @@ func (m *KF) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthSpec
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Key = string(dAtA[iNdEx:postIndex])
+			if m.Query == nil {
+				m.Query = &SearchQueryRequest{}
+			}
+			if err := m.Query.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
 		case 2:
-			if wireType != 1 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Fields", wireType)
 			}
-			var v uint64
-			if (iNdEx + 8) > l {
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-			iNdEx += 8
-			m.Value = float64(math.Float64frombits(v))
+			if m.Fields == nil {
+				m.Fields = make(map[string]bool)
+			}
+			var mapkey string
+			var mapvalue bool
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSpec
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var mapvaluetemp int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapvaluetemp |= int(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					mapvalue = bool(mapvaluetemp != 0)
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipSpec(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Fields[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SampleLimit", wireType)
+			}
+			m.SampleLimit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SampleLimit |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TimeBucketSec", wireType)
+			}
+			m.TimeBucketSec = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TimeBucketSec |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSpec(dAtA[iNdEx:])
@@ -3568,7 +8505,7 @@ func (m *KF) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Metadata) Unmarshal(dAtA []byte) error {
+func (m *Aggregate) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3591,10 +8528,10 @@ func (m *Metadata) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Metadata: wiretype end group for non-group")
+			return fmt.Errorf("proto: Aggregate: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Metadata: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Aggregate: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -3616,20 +8553,115 @@ func (m *Metadata) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Search = append(m.Search, KV{})
-			if err := m.Search[len(m.Search)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			if msglen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Search == nil {
+				m.Search = make(map[string]*CountPerKV)
+			}
+			var mapkey string
+			var mapvalue *CountPerKV
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSpec
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var mapmsglen int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapmsglen |= int(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					if mapmsglen < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postmsgIndex := iNdEx + mapmsglen
+					if postmsgIndex < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postmsgIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = &CountPerKV{}
+					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
+						return err
+					}
+					iNdEx = postmsgIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipSpec(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Search[mapkey] = mapvalue
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
@@ -3660,14 +8692,109 @@ func (m *Metadata) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Count = append(m.Count, KV{})
-			if err := m.Count[len(m.Count)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			if m.Count == nil {
+				m.Count = make(map[string]*CountPerKV)
+			}
+			var mapkey string
+			var mapvalue *CountPerKV
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSpec
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var mapmsglen int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapmsglen |= int(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					if mapmsglen < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postmsgIndex := iNdEx + mapmsglen
+					if postmsgIndex < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postmsgIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = &CountPerKV{}
+					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
+						return err
+					}
+					iNdEx = postmsgIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipSpec(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
 			}
+			m.Count[mapkey] = mapvalue
 			iNdEx = postIndex
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Properties", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ForeignId", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3694,67 +8821,111 @@ func (m *Metadata) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Properties = append(m.Properties, KV{})
-			if err := m.Properties[len(m.Properties)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		case 5:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field CreatedAtNs", wireType)
-			}
-			m.CreatedAtNs = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.CreatedAtNs |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 7:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EventType", wireType)
+			if m.ForeignId == nil {
+				m.ForeignId = make(map[string]*CountPerKV)
 			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
+			var mapkey string
+			var mapvalue *CountPerKV
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSpec
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
 				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var mapmsglen int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapmsglen |= int(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					if mapmsglen < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postmsgIndex := iNdEx + mapmsglen
+					if postmsgIndex < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postmsgIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = &CountPerKV{}
+					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
+						return err
+					}
+					iNdEx = postmsgIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipSpec(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.EventType = string(dAtA[iNdEx:postIndex])
+			m.ForeignId[mapkey] = mapvalue
 			iNdEx = postIndex
-		case 9:
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ForeignId", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field EventType", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -3764,59 +8935,124 @@ func (m *Metadata) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthSpec
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.ForeignId = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 10:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ForeignType", wireType)
+			if m.EventType == nil {
+				m.EventType = make(map[string]*CountPerKV)
 			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
+			var mapkey string
+			var mapvalue *CountPerKV
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSpec
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
 				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var mapmsglen int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapmsglen |= int(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					if mapmsglen < 0 {
+						return ErrInvalidLengthSpec
+					}
+					postmsgIndex := iNdEx + mapmsglen
+					if postmsgIndex < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if postmsgIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = &CountPerKV{}
+					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
+						return err
+					}
+					iNdEx = postmsgIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipSpec(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthSpec
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.ForeignType = string(dAtA[iNdEx:postIndex])
+			m.EventType[mapkey] = mapvalue
 			iNdEx = postIndex
-		case 11:
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Track", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Possible", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3843,8 +9079,8 @@ func (m *Metadata) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Track == nil {
-				m.Track = make(map[string]uint32)
+			if m.Possible == nil {
+				m.Possible = make(map[string]uint32)
 			}
 			var mapkey string
 			var mapvalue uint32
@@ -3925,74 +9161,30 @@ func (m *Metadata) Unmarshal(dAtA []byte) error {
 					iNdEx += skippy
 				}
 			}
-			m.Track[mapkey] = mapvalue
+			m.Possible[mapkey] = mapvalue
 			iNdEx = postIndex
-		case 12:
-			if wireType != 1 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
-			}
-			m.Id = 0
-			if (iNdEx + 8) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Id = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-			iNdEx += 8
-		default:
-			iNdEx = preIndex
-			skippy, err := skipSpec(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *SearchableMetadata) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowSpec
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Total", wireType)
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+			m.Total = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Total |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: SearchableMetadata: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SearchableMetadata: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+		case 7:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Search", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Sample", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4019,16 +9211,16 @@ func (m *SearchableMetadata) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Search = append(m.Search, KV{})
-			if err := m.Search[len(m.Search)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Sample = append(m.Sample, &Hit{})
+			if err := m.Sample[len(m.Sample)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 7:
+		case 8:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EventType", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Chart", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -4038,29 +9230,33 @@ func (m *SearchableMetadata) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthSpec
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.EventType = string(dAtA[iNdEx:postIndex])
+			if m.Chart == nil {
+				m.Chart = &Chart{}
+			}
+			if err := m.Chart.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
 		case 9:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ForeignId", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Partial", wireType)
 			}
-			var stringLen uint64
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -4070,27 +9266,15 @@ func (m *SearchableMetadata) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.ForeignId = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
+			m.Partial = bool(v != 0)
 		case 10:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ForeignType", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field FailedShards", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -4118,11 +9302,64 @@ func (m *SearchableMetadata) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.ForeignType = string(dAtA[iNdEx:postIndex])
+			m.FailedShards = append(m.FailedShards, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
-		case 11:
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SearchQueryResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SearchQueryResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SearchQueryResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Track", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Hits", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4149,89 +9386,81 @@ func (m *SearchableMetadata) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Track == nil {
-				m.Track = make(map[string]uint32)
+			m.Hits = append(m.Hits, &Hit{})
+			if err := m.Hits[len(m.Hits)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-			var mapkey string
-			var mapvalue uint32
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowSpec
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Total", wireType)
+			}
+			m.Total = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
 				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					var stringLenmapkey uint64
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						stringLenmapkey |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					intStringLenmapkey := int(stringLenmapkey)
-					if intStringLenmapkey < 0 {
-						return ErrInvalidLengthSpec
-					}
-					postStringIndexmapkey := iNdEx + intStringLenmapkey
-					if postStringIndexmapkey < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if postStringIndexmapkey > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
-					iNdEx = postStringIndexmapkey
-				} else if fieldNum == 2 {
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapvalue |= uint32(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipSpec(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if skippy < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Total |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Partial", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Partial = bool(v != 0)
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FailedShards", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
 				}
 			}
-			m.Track[mapkey] = mapvalue
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FailedShards = append(m.FailedShards, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -4257,7 +9486,7 @@ func (m *SearchableMetadata) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *BasicMetadata) Unmarshal(dAtA []byte) error {
+func (m *Envelope) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4280,36 +9509,17 @@ func (m *BasicMetadata) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: BasicMetadata: wiretype end group for non-group")
+			return fmt.Errorf("proto: Envelope: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: BasicMetadata: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Envelope: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 5:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field CreatedAtNs", wireType)
-			}
-			m.CreatedAtNs = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.CreatedAtNs |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 7:
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EventType", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -4319,61 +9529,33 @@ func (m *BasicMetadata) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthSpec
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.EventType = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 9:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ForeignId", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
+			if m.Metadata == nil {
+				m.Metadata = &Metadata{}
 			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+			if err := m.Metadata.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-			m.ForeignId = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 10:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ForeignType", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Payload", wireType)
 			}
-			var stringLen uint64
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -4383,23 +9565,25 @@ func (m *BasicMetadata) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + byteLen
 			if postIndex < 0 {
 				return ErrInvalidLengthSpec
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.ForeignType = string(dAtA[iNdEx:postIndex])
+			m.Payload = append(m.Payload[:0], dAtA[iNdEx:postIndex]...)
+			if m.Payload == nil {
+				m.Payload = []byte{}
+			}
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -4425,7 +9609,7 @@ func (m *BasicMetadata) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *CountableMetadata) Unmarshal(dAtA []byte) error {
+func (m *EnvelopeBatch) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4448,15 +9632,15 @@ func (m *CountableMetadata) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: CountableMetadata: wiretype end group for non-group")
+			return fmt.Errorf("proto: EnvelopeBatch: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: CountableMetadata: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: EnvelopeBatch: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Search", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Envelopes", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4483,69 +9667,69 @@ func (m *CountableMetadata) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Search = append(m.Search, KV{})
-			if err := m.Search[len(m.Search)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Envelopes = append(m.Envelopes, &Envelope{})
+			if err := m.Envelopes[len(m.Envelopes)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			if msglen < 0 {
+			if skippy < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
+			if (iNdEx + skippy) < 0 {
 				return ErrInvalidLengthSpec
 			}
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Count = append(m.Count, KV{})
-			if err := m.Count[len(m.Count)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Success) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
 			}
-			iNdEx = postIndex
-		case 5:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field CreatedAtNs", wireType)
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
 			}
-			m.CreatedAtNs = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.CreatedAtNs |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
 			}
-		case 7:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EventType", wireType)
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Success: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Success: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Success", wireType)
 			}
-			var stringLen uint64
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -4555,29 +9739,17 @@ func (m *CountableMetadata) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.EventType = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 9:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ForeignId", wireType)
+			m.Success = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Accepted", wireType)
 			}
-			var stringLen uint64
+			m.Accepted = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -4587,29 +9759,16 @@ func (m *CountableMetadata) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+				m.Accepted |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			m.ForeignId = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 10:
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ForeignType", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Rejected", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -4619,23 +9778,25 @@ func (m *CountableMetadata) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthSpec
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.ForeignType = string(dAtA[iNdEx:postIndex])
+			m.Rejected = append(m.Rejected, &RejectedEnvelope{})
+			if err := m.Rejected[len(m.Rejected)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -4661,7 +9822,7 @@ func (m *CountableMetadata) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Hit) Unmarshal(dAtA []byte) error {
+func (m *SequencedEnvelope) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4684,17 +9845,17 @@ func (m *Hit) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Hit: wiretype end group for non-group")
+			return fmt.Errorf("proto: SequencedEnvelope: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Hit: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SequencedEnvelope: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Sequence", wireType)
 			}
-			m.Id = 0
+			m.Sequence = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -4704,25 +9865,14 @@ func (m *Hit) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Id |= uint64(b&0x7F) << shift
+				m.Sequence |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
-			if wireType != 5 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Score", wireType)
-			}
-			var v uint32
-			if (iNdEx + 4) > l {
-				return io.ErrUnexpectedEOF
-			}
-			v = uint32(encoding_binary.LittleEndian.Uint32(dAtA[iNdEx:]))
-			iNdEx += 4
-			m.Score = float32(math.Float32frombits(v))
-		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Envelope", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4749,13 +9899,33 @@ func (m *Hit) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Metadata == nil {
-				m.Metadata = &Metadata{}
+			if m.Envelope == nil {
+				m.Envelope = &Envelope{}
 			}
-			if err := m.Metadata.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Envelope.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PartitionId", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.PartitionId = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSpec(dAtA[iNdEx:])
@@ -4780,7 +9950,7 @@ func (m *Hit) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SearchQueryRequest) Unmarshal(dAtA []byte) error {
+func (m *RejectedEnvelope) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4803,17 +9973,17 @@ func (m *SearchQueryRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SearchQueryRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: RejectedEnvelope: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SearchQueryRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: RejectedEnvelope: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FromSecond", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Sequence", wireType)
 			}
-			m.FromSecond = 0
+			m.Sequence = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -4823,35 +9993,16 @@ func (m *SearchQueryRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.FromSecond |= uint32(b&0x7F) << shift
+				m.Sequence |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ToSecond", wireType)
-			}
-			m.ToSecond = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.ToSecond |= uint32(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Query", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -4861,33 +10012,29 @@ func (m *SearchQueryRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthSpec
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Query == nil {
-				m.Query = &go_query_index_dsl.Query{}
-			}
-			if err := m.Query.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Error = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 4:
+		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Code", wireType)
 			}
-			m.Limit = 0
+			m.Code = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -4897,7 +10044,7 @@ func (m *SearchQueryRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Limit |= int32(b&0x7F) << shift
+				m.Code |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -4926,7 +10073,7 @@ func (m *SearchQueryRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *CountPerKV) Unmarshal(dAtA []byte) error {
+func (m *PushAck) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4949,130 +10096,17 @@ func (m *CountPerKV) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: CountPerKV: wiretype end group for non-group")
+			return fmt.Errorf("proto: PushAck: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: CountPerKV: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PushAck: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if msglen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Count == nil {
-				m.Count = make(map[string]uint32)
-			}
-			var mapkey string
-			var mapvalue uint32
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowSpec
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					var stringLenmapkey uint64
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						stringLenmapkey |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					intStringLenmapkey := int(stringLenmapkey)
-					if intStringLenmapkey < 0 {
-						return ErrInvalidLengthSpec
-					}
-					postStringIndexmapkey := iNdEx + intStringLenmapkey
-					if postStringIndexmapkey < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if postStringIndexmapkey > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
-					iNdEx = postStringIndexmapkey
-				} else if fieldNum == 2 {
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapvalue |= uint32(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipSpec(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if skippy < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
-				}
-			}
-			m.Count[mapkey] = mapvalue
-			iNdEx = postIndex
-		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Total", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Sequence", wireType)
 			}
-			m.Total = 0
+			m.Sequence = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -5082,16 +10116,16 @@ func (m *CountPerKV) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Total |= uint32(b&0x7F) << shift
+				m.Sequence |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 3:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Rejected", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -5101,24 +10135,79 @@ func (m *CountPerKV) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthSpec
 			}
-			if postIndex > l {
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Rejected = append(m.Rejected, &RejectedEnvelope{})
+			if err := m.Rejected[len(m.Rejected)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *HealthRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
+			}
+			if iNdEx >= l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Key = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: HealthRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: HealthRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSpec(dAtA[iNdEx:])
@@ -5143,7 +10232,7 @@ func (m *CountPerKV) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *PointPerEventType) Unmarshal(dAtA []byte) error {
+func (m *ReindexRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5166,17 +10255,17 @@ func (m *PointPerEventType) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: PointPerEventType: wiretype end group for non-group")
+			return fmt.Errorf("proto: ReindexRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: PointPerEventType: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ReindexRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field FromSecond", wireType)
 			}
-			m.Count = 0
+			m.FromSecond = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -5186,54 +10275,16 @@ func (m *PointPerEventType) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Count |= uint32(b&0x7F) << shift
+				m.FromSecond |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field CountUnique", wireType)
-			}
-			m.CountUnique = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.CountUnique |= uint32(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Bucket", wireType)
-			}
-			m.Bucket = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Bucket |= uint32(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 4:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EventType", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ToSecond", wireType)
 			}
-			var stringLen uint64
+			m.ToSecond = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -5243,24 +10294,11 @@ func (m *PointPerEventType) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.ToSecond |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.EventType = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSpec(dAtA[iNdEx:])
@@ -5285,7 +10323,7 @@ func (m *PointPerEventType) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ChartBucketPerTime) Unmarshal(dAtA []byte) error {
+func (m *ReindexResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5308,15 +10346,15 @@ func (m *ChartBucketPerTime) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ChartBucketPerTime: wiretype end group for non-group")
+			return fmt.Errorf("proto: ReindexResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ChartBucketPerTime: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ReindexResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PerType", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Documents", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -5343,11 +10381,11 @@ func (m *ChartBucketPerTime) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.PerType == nil {
-				m.PerType = make(map[string]*PointPerEventType)
+			if m.Documents == nil {
+				m.Documents = make(map[string]uint32)
 			}
 			var mapkey string
-			var mapvalue *PointPerEventType
+			var mapvalue uint32
 			for iNdEx < postIndex {
 				entryPreIndex := iNdEx
 				var wire uint64
@@ -5396,7 +10434,6 @@ func (m *ChartBucketPerTime) Unmarshal(dAtA []byte) error {
 					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
 					iNdEx = postStringIndexmapkey
 				} else if fieldNum == 2 {
-					var mapmsglen int
 					for shift := uint(0); ; shift += 7 {
 						if shift >= 64 {
 							return ErrIntOverflowSpec
@@ -5406,26 +10443,11 @@ func (m *ChartBucketPerTime) Unmarshal(dAtA []byte) error {
 						}
 						b := dAtA[iNdEx]
 						iNdEx++
-						mapmsglen |= int(b&0x7F) << shift
+						mapvalue |= uint32(b&0x7F) << shift
 						if b < 0x80 {
 							break
 						}
 					}
-					if mapmsglen < 0 {
-						return ErrInvalidLengthSpec
-					}
-					postmsgIndex := iNdEx + mapmsglen
-					if postmsgIndex < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if postmsgIndex > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapvalue = &PointPerEventType{}
-					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
-						return err
-					}
-					iNdEx = postmsgIndex
 				} else {
 					iNdEx = entryPreIndex
 					skippy, err := skipSpec(dAtA[iNdEx:])
@@ -5441,7 +10463,7 @@ func (m *ChartBucketPerTime) Unmarshal(dAtA []byte) error {
 					iNdEx += skippy
 				}
 			}
-			m.PerType[mapkey] = mapvalue
+			m.Documents[mapkey] = mapvalue
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -5467,7 +10489,7 @@ func (m *ChartBucketPerTime) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Chart) Unmarshal(dAtA []byte) error {
+func (m *ForgetRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5485,137 +10507,54 @@ func (m *Chart) Unmarshal(dAtA []byte) error {
 			wire |= uint64(b&0x7F) << shift
 			if b < 0x80 {
 				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: Chart: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Chart: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Buckets", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if msglen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Buckets == nil {
-				m.Buckets = make(map[uint32]*ChartBucketPerTime)
-			}
-			var mapkey uint32
-			var mapvalue *ChartBucketPerTime
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowSpec
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapkey |= uint32(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-				} else if fieldNum == 2 {
-					var mapmsglen int
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapmsglen |= int(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					if mapmsglen < 0 {
-						return ErrInvalidLengthSpec
-					}
-					postmsgIndex := iNdEx + mapmsglen
-					if postmsgIndex < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if postmsgIndex > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapvalue = &ChartBucketPerTime{}
-					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
-						return err
-					}
-					iNdEx = postmsgIndex
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipSpec(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if skippy < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ForgetRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ForgetRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ForeignType", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
 				}
 			}
-			m.Buckets[mapkey] = mapvalue
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ForeignType = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TimeStart", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ForeignId", wireType)
 			}
-			m.TimeStart = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -5625,16 +10564,29 @@ func (m *Chart) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.TimeStart |= uint32(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ForeignId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TimeEnd", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Reason", wireType)
 			}
-			m.TimeEnd = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -5644,16 +10596,29 @@ func (m *Chart) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.TimeEnd |= uint32(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Reason = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 4:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TimeBucketSec", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Compact", wireType)
 			}
-			m.TimeBucketSec = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -5663,11 +10628,12 @@ func (m *Chart) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.TimeBucketSec |= uint32(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			m.Compact = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSpec(dAtA[iNdEx:])
@@ -5692,7 +10658,7 @@ func (m *Chart) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *AggregateRequest) Unmarshal(dAtA []byte) error {
+func (m *ForgetResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5715,17 +10681,36 @@ func (m *AggregateRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: AggregateRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: ForgetResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: AggregateRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ForgetResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Documents", wireType)
+			}
+			m.Documents = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Documents |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Query", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Segments", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -5735,33 +10720,29 @@ func (m *AggregateRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthSpec
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Query == nil {
-				m.Query = &SearchQueryRequest{}
-			}
-			if err := m.Query.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Segments = append(m.Segments, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Fields", wireType)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Compacted", wireType)
 			}
-			var msglen int
+			m.Compacted = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -5771,112 +10752,88 @@ func (m *AggregateRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.Compacted |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
+			if (iNdEx + skippy) < 0 {
 				return ErrInvalidLengthSpec
 			}
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Fields == nil {
-				m.Fields = make(map[string]bool)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SnapshotRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
 			}
-			var mapkey string
-			var mapvalue bool
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowSpec
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					var stringLenmapkey uint64
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						stringLenmapkey |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					intStringLenmapkey := int(stringLenmapkey)
-					if intStringLenmapkey < 0 {
-						return ErrInvalidLengthSpec
-					}
-					postStringIndexmapkey := iNdEx + intStringLenmapkey
-					if postStringIndexmapkey < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if postStringIndexmapkey > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
-					iNdEx = postStringIndexmapkey
-				} else if fieldNum == 2 {
-					var mapvaluetemp int
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapvaluetemp |= int(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					mapvalue = bool(mapvaluetemp != 0)
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipSpec(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if skippy < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SnapshotRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SnapshotRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FromSecond", wireType)
+			}
+			m.FromSecond = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FromSecond |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
 				}
 			}
-			m.Fields[mapkey] = mapvalue
-			iNdEx = postIndex
-		case 3:
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SampleLimit", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ToSecond", wireType)
 			}
-			m.SampleLimit = 0
+			m.ToSecond = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -5886,16 +10843,16 @@ func (m *AggregateRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.SampleLimit |= int32(b&0x7F) << shift
+				m.ToSecond |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 4:
+		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TimeBucketSec", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Upload", wireType)
 			}
-			m.TimeBucketSec = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -5905,11 +10862,12 @@ func (m *AggregateRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.TimeBucketSec |= uint32(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			m.Upload = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSpec(dAtA[iNdEx:])
@@ -5934,7 +10892,7 @@ func (m *AggregateRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Aggregate) Unmarshal(dAtA []byte) error {
+func (m *SnapshotResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -5957,17 +10915,17 @@ func (m *Aggregate) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Aggregate: wiretype end group for non-group")
+			return fmt.Errorf("proto: SnapshotResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Aggregate: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SnapshotResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Search", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -5977,126 +10935,29 @@ func (m *Aggregate) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthSpec
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Search == nil {
-				m.Search = make(map[string]*CountPerKV)
-			}
-			var mapkey string
-			var mapvalue *CountPerKV
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowSpec
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					var stringLenmapkey uint64
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						stringLenmapkey |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					intStringLenmapkey := int(stringLenmapkey)
-					if intStringLenmapkey < 0 {
-						return ErrInvalidLengthSpec
-					}
-					postStringIndexmapkey := iNdEx + intStringLenmapkey
-					if postStringIndexmapkey < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if postStringIndexmapkey > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
-					iNdEx = postStringIndexmapkey
-				} else if fieldNum == 2 {
-					var mapmsglen int
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapmsglen |= int(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					if mapmsglen < 0 {
-						return ErrInvalidLengthSpec
-					}
-					postmsgIndex := iNdEx + mapmsglen
-					if postmsgIndex < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if postmsgIndex > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapvalue = &CountPerKV{}
-					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
-						return err
-					}
-					iNdEx = postmsgIndex
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipSpec(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if skippy < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
-				}
-			}
-			m.Search[mapkey] = mapvalue
+			m.Name = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Path", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -6106,126 +10967,29 @@ func (m *Aggregate) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthSpec
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Count == nil {
-				m.Count = make(map[string]*CountPerKV)
-			}
-			var mapkey string
-			var mapvalue *CountPerKV
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowSpec
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					var stringLenmapkey uint64
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						stringLenmapkey |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					intStringLenmapkey := int(stringLenmapkey)
-					if intStringLenmapkey < 0 {
-						return ErrInvalidLengthSpec
-					}
-					postStringIndexmapkey := iNdEx + intStringLenmapkey
-					if postStringIndexmapkey < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if postStringIndexmapkey > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
-					iNdEx = postStringIndexmapkey
-				} else if fieldNum == 2 {
-					var mapmsglen int
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapmsglen |= int(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					if mapmsglen < 0 {
-						return ErrInvalidLengthSpec
-					}
-					postmsgIndex := iNdEx + mapmsglen
-					if postmsgIndex < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if postmsgIndex > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapvalue = &CountPerKV{}
-					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
-						return err
-					}
-					iNdEx = postmsgIndex
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipSpec(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if skippy < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
-				}
-			}
-			m.Count[mapkey] = mapvalue
+			m.Path = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ForeignId", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Segments", wireType)
 			}
-			var msglen int
+			m.Segments = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -6235,126 +10999,16 @@ func (m *Aggregate) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.Segments |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.ForeignId == nil {
-				m.ForeignId = make(map[string]*CountPerKV)
-			}
-			var mapkey string
-			var mapvalue *CountPerKV
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowSpec
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					var stringLenmapkey uint64
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						stringLenmapkey |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					intStringLenmapkey := int(stringLenmapkey)
-					if intStringLenmapkey < 0 {
-						return ErrInvalidLengthSpec
-					}
-					postStringIndexmapkey := iNdEx + intStringLenmapkey
-					if postStringIndexmapkey < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if postStringIndexmapkey > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
-					iNdEx = postStringIndexmapkey
-				} else if fieldNum == 2 {
-					var mapmsglen int
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapmsglen |= int(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					if mapmsglen < 0 {
-						return ErrInvalidLengthSpec
-					}
-					postmsgIndex := iNdEx + mapmsglen
-					if postmsgIndex < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if postmsgIndex > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapvalue = &CountPerKV{}
-					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
-						return err
-					}
-					iNdEx = postmsgIndex
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipSpec(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if skippy < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
-				}
-			}
-			m.ForeignId[mapkey] = mapvalue
-			iNdEx = postIndex
 		case 4:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EventType", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Uploaded", wireType)
 			}
-			var msglen int
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -6364,124 +11018,68 @@ func (m *Aggregate) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			m.Uploaded = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSpec(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
+			if (iNdEx + skippy) < 0 {
 				return ErrInvalidLengthSpec
 			}
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.EventType == nil {
-				m.EventType = make(map[string]*CountPerKV)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ReplicateRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSpec
 			}
-			var mapkey string
-			var mapvalue *CountPerKV
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowSpec
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					var stringLenmapkey uint64
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						stringLenmapkey |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					intStringLenmapkey := int(stringLenmapkey)
-					if intStringLenmapkey < 0 {
-						return ErrInvalidLengthSpec
-					}
-					postStringIndexmapkey := iNdEx + intStringLenmapkey
-					if postStringIndexmapkey < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if postStringIndexmapkey > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
-					iNdEx = postStringIndexmapkey
-				} else if fieldNum == 2 {
-					var mapmsglen int
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowSpec
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapmsglen |= int(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					if mapmsglen < 0 {
-						return ErrInvalidLengthSpec
-					}
-					postmsgIndex := iNdEx + mapmsglen
-					if postmsgIndex < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if postmsgIndex > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapvalue = &CountPerKV{}
-					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
-						return err
-					}
-					iNdEx = postmsgIndex
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipSpec(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if skippy < 0 {
-						return ErrInvalidLengthSpec
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
-				}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
 			}
-			m.EventType[mapkey] = mapvalue
-			iNdEx = postIndex
-		case 5:
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReplicateRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReplicateRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Possible", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Positions", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -6508,8 +11106,8 @@ func (m *Aggregate) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Possible == nil {
-				m.Possible = make(map[string]uint32)
+			if m.Positions == nil {
+				m.Positions = make(map[string]uint32)
 			}
 			var mapkey string
 			var mapvalue uint32
@@ -6590,66 +11188,13 @@ func (m *Aggregate) Unmarshal(dAtA []byte) error {
 					iNdEx += skippy
 				}
 			}
-			m.Possible[mapkey] = mapvalue
+			m.Positions[mapkey] = mapvalue
 			iNdEx = postIndex
-		case 6:
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Total", wireType)
-			}
-			m.Total = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Total |= uint32(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 7:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Sample", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if msglen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Sample = append(m.Sample, &Hit{})
-			if err := m.Sample[len(m.Sample)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		case 8:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Chart", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field FromSecond", wireType)
 			}
-			var msglen int
+			m.FromSecond = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -6659,28 +11204,11 @@ func (m *Aggregate) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.FromSecond |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Chart == nil {
-				m.Chart = &Chart{}
-			}
-			if err := m.Chart.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSpec(dAtA[iNdEx:])
@@ -6705,7 +11233,7 @@ func (m *Aggregate) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SearchQueryResponse) Unmarshal(dAtA []byte) error {
+func (m *ReplicatedEnvelope) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6728,17 +11256,17 @@ func (m *SearchQueryResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SearchQueryResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: ReplicatedEnvelope: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SearchQueryResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ReplicatedEnvelope: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Hits", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Segment", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -6748,31 +11276,29 @@ func (m *SearchQueryResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthSpec
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthSpec
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Hits = append(m.Hits, &Hit{})
-			if err := m.Hits[len(m.Hits)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Segment = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Total", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
 			}
-			m.Total = 0
+			m.Offset = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSpec
@@ -6782,11 +11308,199 @@ func (m *SearchQueryResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Total |= uint64(b&0x7F) << shift
+				m.Offset |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LeaderTimeNs", wireType)
+			}
+			m.LeaderTimeNs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LeaderTimeNs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PendingBytes", wireType)
+			}
+			m.PendingBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PendingBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PartitionId", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.PartitionId = bool(v != 0)
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Deleted", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			m.Deleted = bool(v != 0)
+		case 8:
+			if wireType == 0 {
+				var v uint32
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSpec
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint32(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.Tombstones = append(m.Tombstones, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSpec
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthSpec
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthSpec
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.Tombstones) == 0 {
+					m.Tombstones = make([]uint32, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint32
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSpec
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint32(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.Tombstones = append(m.Tombstones, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field Tombstones", wireType)
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSpec(dAtA[iNdEx:])
@@ -6811,7 +11525,7 @@ func (m *SearchQueryResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Envelope) Unmarshal(dAtA []byte) error {
+func (m *ReplicationStatusRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6834,82 +11548,12 @@ func (m *Envelope) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Envelope: wiretype end group for non-group")
+			return fmt.Errorf("proto: ReplicationStatusRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Envelope: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ReplicationStatusRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if msglen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Metadata == nil {
-				m.Metadata = &Metadata{}
-			}
-			if err := m.Metadata.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Payload", wireType)
-			}
-			var byteLen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSpec
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				byteLen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if byteLen < 0 {
-				return ErrInvalidLengthSpec
-			}
-			postIndex := iNdEx + byteLen
-			if postIndex < 0 {
-				return ErrInvalidLengthSpec
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Payload = append(m.Payload[:0], dAtA[iNdEx:postIndex]...)
-			if m.Payload == nil {
-				m.Payload = []byte{}
-			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSpec(dAtA[iNdEx:])
@@ -6934,7 +11578,7 @@ func (m *Envelope) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Success) Unmarshal(dAtA []byte) error {
+func (m *ReplicationStatus) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6957,15 +11601,15 @@ func (m *Success) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Success: wiretype end group for non-group")
+			return fmt.Errorf("proto: ReplicationStatus: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Success: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ReplicationStatus: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Success", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Follower", wireType)
 			}
 			var v int
 			for shift := uint(0); ; shift += 7 {
@@ -6982,7 +11626,167 @@ func (m *Success) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-			m.Success = bool(v != 0)
+			m.Follower = bool(v != 0)
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Leader", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Leader = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Connected", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Connected = bool(v != 0)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PendingBytes", wireType)
+			}
+			m.PendingBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PendingBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CaughtUpAtNs", wireType)
+			}
+			m.CaughtUpAtNs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CaughtUpAtNs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LastReceivedNs", wireType)
+			}
+			m.LastReceivedNs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LastReceivedNs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Applied", wireType)
+			}
+			m.Applied = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Applied |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LastError", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpec
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSpec
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSpec
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LastError = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSpec(dAtA[iNdEx:])
@@ -7007,7 +11811,7 @@ func (m *Success) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *HealthRequest) Unmarshal(dAtA []byte) error {
+func (m *PromoteRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7030,10 +11834,10 @@ func (m *HealthRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: HealthRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: PromoteRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: HealthRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PromoteRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		default:
@@ -7063,6 +11867,7 @@ func (m *HealthRequest) Unmarshal(dAtA []byte) error {
 func skipSpec(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
+	depth := 0
 	for iNdEx < l {
 		var wire uint64
 		for shift := uint(0); ; shift += 7 {
@@ -7094,10 +11899,8 @@ func skipSpec(dAtA []byte) (n int, err error) {
 					break
 				}
 			}
-			return iNdEx, nil
 		case 1:
 			iNdEx += 8
-			return iNdEx, nil
 		case 2:
 			var length int
 			for shift := uint(0); ; shift += 7 {
@@ -7118,55 +11921,30 @@ func skipSpec(dAtA []byte) (n int, err error) {
 				return 0, ErrInvalidLengthSpec
 			}
 			iNdEx += length
-			if iNdEx < 0 {
-				return 0, ErrInvalidLengthSpec
-			}
-			return iNdEx, nil
 		case 3:
-			for {
-				var innerWire uint64
-				var start int = iNdEx
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return 0, ErrIntOverflowSpec
-					}
-					if iNdEx >= l {
-						return 0, io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					innerWire |= (uint64(b) & 0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				innerWireType := int(innerWire & 0x7)
-				if innerWireType == 4 {
-					break
-				}
-				next, err := skipSpec(dAtA[start:])
-				if err != nil {
-					return 0, err
-				}
-				iNdEx = start + next
-				if iNdEx < 0 {
-					return 0, ErrInvalidLengthSpec
-				}
-			}
-			return iNdEx, nil
+			depth++
 		case 4:
-			return iNdEx, nil
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupSpec
+			}
+			depth--
 		case 5:
 			iNdEx += 4
-			return iNdEx, nil
 		default:
 			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
 		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthSpec
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
 	}
-	panic("unreachable")
+	return 0, io.ErrUnexpectedEOF
 }
 
 var (
-	ErrInvalidLengthSpec = fmt.Errorf("proto: negative length found during unmarshaling")
-	ErrIntOverflowSpec   = fmt.Errorf("proto: integer overflow")
+	ErrInvalidLengthSpec        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowSpec          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupSpec = fmt.Errorf("proto: unexpected end of group")
 )
@@ -1,6 +1,7 @@
 package blackrock_io
 
 import (
+	"bytes"
 	"encoding/json"
 	fmt "fmt"
 	io "io"
@@ -168,6 +169,46 @@ func DecodeAndFlatten(body io.Reader) (*Envelope, error) {
 		return nil, err
 	}
 
+	return Flatten(&metadata)
+}
+
+// SplitFrames splits a json array or newline delimited json into the raw
+// items, blank lines are skipped
+func SplitFrames(data []byte) ([]json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		out := []json.RawMessage{}
+		err := json.Unmarshal(trimmed, &out)
+		if err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+
+	out := []json.RawMessage{}
+	for _, line := range bytes.Split(trimmed, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		out = append(out, json.RawMessage(line))
+	}
+	return out, nil
+}
+
+// DecodeAndFlattenItem flattens one item of SplitFrames, the error is
+// about this item only
+func DecodeAndFlattenItem(item json.RawMessage) (*Envelope, error) {
+	var metadata JsonFrame
+	err := json.Unmarshal(item, &metadata)
+	if err != nil {
+		return nil, err
+	}
+	return Flatten(&metadata)
+}
+
+func Flatten(metadata *JsonFrame) (*Envelope, error) {
+	var err error
 	search := []KV{}
 	if metadata.Search != nil {
 		search, err = Transform(metadata.Search, true)
@@ -16,3 +16,32 @@ func TestTransform(t *testing.T) {
 		t.Fatal("expected book_id:123")
 	}
 }
+
+func TestSplitFrames(t *testing.T) {
+	for _, in := range []string{
+		`[{"foreign_id":"1"}, {"foreign_id":"2"}, {"foreign_id":""}]`,
+		"{\"foreign_id\":\"1\"}\n\n{\"foreign_id\":\"2\"}\r\n{\"foreign_id\":\"\"}\n",
+	} {
+		items, err := SplitFrames([]byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 3 {
+			t.Fatalf("expected 3 items, got %d", len(items))
+		}
+		for i, item := range items[:2] {
+			e, err := DecodeAndFlattenItem(item)
+			if err == nil {
+				t.Fatalf("expected missing foreign_type error")
+			}
+			if e.Metadata.ForeignId != string('1'+rune(i)) {
+				t.Fatalf("unexpected foreign_id %s", e.Metadata.ForeignId)
+			}
+		}
+	}
+
+	_, err := SplitFrames([]byte(`[{"foreign_id":"1"}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
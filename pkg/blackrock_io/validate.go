@@ -1,24 +1,88 @@
 package blackrock_io
 
 import (
-	"errors"
+	"fmt"
 )
 
+// ValidationError names the envelope field that is invalid
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Reason
+}
+
+func invalid(field, format string, args ...interface{}) error {
+	return &ValidationError{Field: field, Reason: fmt.Sprintf(format, args...)}
+}
+
 func ValidateEnvelope(envelope *Envelope) error {
 	if envelope.Metadata == nil {
-		return errors.New("need metadata key")
+		return invalid("metadata", "need metadata key")
 	}
 	if envelope.Metadata.ForeignId == "" {
-		return errors.New("need foreign_id in metadata")
+		return invalid("metadata.foreign_id", "need foreign_id in metadata")
 	}
 
 	if envelope.Metadata.ForeignType == "" {
-		return errors.New("need foreign_type in metadata")
+		return invalid("metadata.foreign_type", "need foreign_type in metadata")
 	}
 
 	if envelope.Metadata.EventType == "" {
-		return errors.New("need event_type in metadata")
+		return invalid("metadata.event_type", "need event_type in metadata")
+
+	}
+	return nil
+}
 
+// Limits bound what an envelope can carry, zero means no limit and nil
+// EventTypes allows every event type
+type Limits struct {
+	MaxKeys        int
+	MaxKeyLength   int
+	MaxValueLength int
+	MaxPayloadSize int
+	EventTypes     map[string]bool
+}
+
+// Validate runs ValidateEnvelope and then checks the limits, keys are
+// counted across search, count and properties
+func (l *Limits) Validate(envelope *Envelope) error {
+	err := ValidateEnvelope(envelope)
+	if err != nil {
+		return err
+	}
+
+	meta := envelope.Metadata
+	if l.EventTypes != nil && !l.EventTypes[meta.EventType] {
+		return invalid("metadata.event_type", "event_type %q is not allowed", meta.EventType)
+	}
+
+	if l.MaxPayloadSize > 0 && len(envelope.Payload) > l.MaxPayloadSize {
+		return invalid("payload", "payload has %d bytes, max is %d", len(envelope.Payload), l.MaxPayloadSize)
+	}
+
+	keys := 0
+	for _, s := range []struct {
+		name string
+		kvs  []KV
+	}{{"search", meta.Search}, {"count", meta.Count}, {"properties", meta.Properties}} {
+		for i, kv := range s.kvs {
+			field := fmt.Sprintf("metadata.%s[%d]", s.name, i)
+			if l.MaxKeyLength > 0 && len(kv.Key) > l.MaxKeyLength {
+				return invalid(field+".key", "key has %d bytes, max is %d", len(kv.Key), l.MaxKeyLength)
+			}
+			if l.MaxValueLength > 0 && len(kv.Value) > l.MaxValueLength {
+				return invalid(field+".value", "value of %q has %d bytes, max is %d", kv.Key, len(kv.Value), l.MaxValueLength)
+			}
+		}
+		keys += len(s.kvs)
 	}
+	if l.MaxKeys > 0 && keys > l.MaxKeys {
+		return invalid("metadata", "metadata has %d keys, max is %d", keys, l.MaxKeys)
+	}
+
 	return nil
 }
@@ -0,0 +1,59 @@
+package blackrock_io
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	valid := func() *Envelope {
+		return &Envelope{
+			Metadata: &Metadata{
+				ForeignId:   "1",
+				ForeignType: "user",
+				EventType:   "click",
+				Search:      []KV{{Key: "a", Value: "b"}},
+				Count:       []KV{{Key: "c", Value: "d"}},
+			},
+			Payload: []byte("abc"),
+		}
+	}
+
+	l := &Limits{MaxKeys: 2, MaxKeyLength: 3, MaxValueLength: 3, MaxPayloadSize: 3, EventTypes: map[string]bool{"click": true}}
+	if err := l.Validate(valid()); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		field  string
+		modify func(e *Envelope)
+	}{
+		{"metadata", func(e *Envelope) { e.Metadata = nil }},
+		{"metadata.foreign_id", func(e *Envelope) { e.Metadata.ForeignId = "" }},
+		{"metadata.event_type", func(e *Envelope) { e.Metadata.EventType = "view" }},
+		{"payload", func(e *Envelope) { e.Payload = []byte("abcd") }},
+		{"metadata.search[0].key", func(e *Envelope) { e.Metadata.Search[0].Key = "abcd" }},
+		{"metadata.count[0].value", func(e *Envelope) { e.Metadata.Count[0].Value = "abcd" }},
+		{"metadata", func(e *Envelope) { e.Metadata.Properties = []KV{{Key: "e", Value: "f"}} }},
+	}
+	for _, c := range cases {
+		e := valid()
+		c.modify(e)
+		err := l.Validate(e)
+		if err == nil {
+			t.Fatalf("expected error for %s", c.field)
+		}
+		ve, ok := err.(*ValidationError)
+		if !ok || ve.Field != c.field {
+			t.Fatalf("expected error for %s, got %v", c.field, err)
+		}
+	}
+
+	unlimited := &Limits{}
+	e := valid()
+	e.Metadata.EventType = "view"
+	e.Payload = []byte(strings.Repeat("x", 1000))
+	if err := unlimited.Validate(e); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,441 @@
+package coordinator
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	. "github.com/rekki/blackrock/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type Shard struct {
+	Name   string
+	Client spec.SearchClient
+}
+
+// Coordinator implements the search service by forwarding every query to
+// all shards and merging the results, shards that fail or do not answer
+// within Timeout are reported in failed_shards
+type Coordinator struct {
+	Shards []Shard
+	// per shard deadline, 0 means no deadline
+	Timeout time.Duration
+	// when false any failed shard fails the whole request
+	AllowPartial bool
+}
+
+// DialShards connects to a list of search nodes, the connections are lazy
+// so nodes that are down do not prevent starting
+func DialShards(addresses []string) ([]Shard, error) {
+	shards := []Shard{}
+	for _, address := range addresses {
+		conn, err := grpc.Dial(address, grpc.WithInsecure())
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, Shard{Name: address, Client: spec.NewSearchClient(conn)})
+	}
+	return shards, nil
+}
+
+// the shards see the same credentials and tenant as the coordinator
+var forwardedMetadata = []string{"authorization", "blackrock-tenant"}
+
+func forward(ctx context.Context) context.Context {
+	in, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	for _, k := range forwardedMetadata {
+		for _, v := range in.Get(k) {
+			ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+		}
+	}
+	return ctx
+}
+
+func (c *Coordinator) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = forward(ctx)
+	if c.Timeout > 0 {
+		return context.WithTimeout(ctx, c.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// call cb on every shard in parallel, returns the names of the failed ones
+func (c *Coordinator) scatter(ctx context.Context, cb func(ctx context.Context, i int, shard Shard) error) ([]string, error) {
+	errs := make([]error, len(c.Shards))
+	var wg sync.WaitGroup
+	for i, shard := range c.Shards {
+		wg.Add(1)
+		go func(i int, shard Shard) {
+			defer wg.Done()
+			ctx, cancel := c.withTimeout(ctx)
+			defer cancel()
+			errs[i] = cb(ctx, i, shard)
+		}(i, shard)
+	}
+	wg.Wait()
+
+	return c.gather(errs)
+}
+
+func (c *Coordinator) gather(errs []error) ([]string, error) {
+	failed := []string{}
+	var lastError error
+	for i, err := range errs {
+		if err != nil {
+			Log.Warnf("shard %s failed, err: %s", c.Shards[i].Name, err.Error())
+			failed = append(failed, c.Shards[i].Name)
+			lastError = err
+		}
+	}
+
+	if len(failed) > 0 && (!c.AllowPartial || len(failed) == len(c.Shards)) {
+		return nil, status.Errorf(codes.Unavailable, "shards failed: %s, last error: %s", strings.Join(failed, ","), lastError.Error())
+	}
+	return failed, nil
+}
+
+func (c *Coordinator) SaySearch(ctx context.Context, qr *spec.SearchQueryRequest) (*spec.SearchQueryResponse, error) {
+	responses := make([]*spec.SearchQueryResponse, len(c.Shards))
+	failed, err := c.scatter(ctx, func(ctx context.Context, i int, shard Shard) error {
+		res, err := shard.Client.SaySearch(ctx, qr)
+		responses[i] = res
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := MergeSearch(responses, int(qr.Limit))
+	out.Partial = out.Partial || len(failed) > 0
+	out.FailedShards = failed
+	return out, nil
+}
+
+// MergeSearch sums the totals and keeps the top limit hits by score, nil
+// responses are skipped, the result is partial if any response is
+func MergeSearch(responses []*spec.SearchQueryResponse, limit int) *spec.SearchQueryResponse {
+	out := &spec.SearchQueryResponse{Hits: []*spec.Hit{}}
+	for _, res := range responses {
+		if res == nil {
+			continue
+		}
+		out.Total += res.Total
+		out.Hits = append(out.Hits, res.Hits...)
+		out.Partial = out.Partial || res.Partial
+	}
+
+	sort.SliceStable(out.Hits, func(i, j int) bool {
+		return out.Hits[i].Score > out.Hits[j].Score
+	})
+	if len(out.Hits) > limit {
+		out.Hits = out.Hits[:limit]
+	}
+	return out
+}
+
+// PartialTrailer is set to true on fetch streams with missing hits, because
+// of the budget of the query or because shards failed
+const PartialTrailer = "blackrock-partial"
+
+func (c *Coordinator) SayFetch(qr *spec.SearchQueryRequest, stream spec.Search_SayFetchServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	hits := make(chan *spec.Hit)
+	errs := make([]error, len(c.Shards))
+	var partial int32
+	var wg sync.WaitGroup
+	for i, shard := range c.Shards {
+		wg.Add(1)
+		go func(i int, shard Shard) {
+			defer wg.Done()
+			ctx, cancel := c.withTimeout(ctx)
+			defer cancel()
+
+			client, err := shard.Client.SayFetch(ctx, qr)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			for {
+				hit, err := client.Recv()
+				if err == io.EOF {
+					if len(client.Trailer().Get(PartialTrailer)) > 0 {
+						atomic.StoreInt32(&partial, 1)
+					}
+					return
+				}
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				select {
+				case hits <- hit:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(i, shard)
+	}
+	go func() {
+		wg.Wait()
+		close(hits)
+	}()
+
+	sent := int32(0)
+	var sendError error
+	for hit := range hits {
+		sendError = stream.Send(hit)
+		if sendError != nil {
+			break
+		}
+		sent++
+		if qr.Limit > 0 && sent >= qr.Limit {
+			break
+		}
+	}
+	// stop the shards that are still streaming
+	cancel()
+	for range hits {
+	}
+
+	if sendError != nil {
+		return sendError
+	}
+	if qr.Limit > 0 && sent >= qr.Limit {
+		// the remaining shards were canceled on purpose
+		return nil
+	}
+
+	failed, err := c.gather(errs)
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		stream.SetTrailer(metadata.Pairs(PartialTrailer, "true", "blackrock-failed-shards", strings.Join(failed, ",")))
+	} else if atomic.LoadInt32(&partial) == 1 {
+		stream.SetTrailer(metadata.Pairs(PartialTrailer, "true"))
+	}
+	return nil
+}
+
+func (c *Coordinator) SayAggregate(ctx context.Context, qr *spec.AggregateRequest) (*spec.Aggregate, error) {
+	responses := make([]*spec.Aggregate, len(c.Shards))
+	failed, err := c.scatter(ctx, func(ctx context.Context, i int, shard Shard) error {
+		res, err := shard.Client.SayAggregate(ctx, qr)
+		responses[i] = res
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := MergeAggregate(responses, int(qr.SampleLimit))
+	out.Partial = out.Partial || len(failed) > 0
+	out.FailedShards = failed
+	return out, nil
+}
+
+func mergeCountPerKV(into map[string]*spec.CountPerKV, from map[string]*spec.CountPerKV) {
+	for k, v := range from {
+		m, ok := into[k]
+		if !ok {
+			m = &spec.CountPerKV{Count: map[string]uint32{}, Key: v.Key}
+			into[k] = m
+		}
+		for value, count := range v.Count {
+			m.Count[value] += count
+		}
+		m.Total += v.Total
+	}
+}
+
+// MergeAggregate sums the counts of all responses, nil responses are skipped,
+// the result is partial if any response is.
+// count_unique in the chart is summed as well, which is exact only when
+// the same foreign id never lands on two shards.
+func MergeAggregate(responses []*spec.Aggregate, sampleLimit int) *spec.Aggregate {
+	out := &spec.Aggregate{
+		Search:    map[string]*spec.CountPerKV{},
+		Count:     map[string]*spec.CountPerKV{},
+		EventType: map[string]*spec.CountPerKV{},
+		ForeignId: map[string]*spec.CountPerKV{},
+		Possible:  map[string]uint32{},
+	}
+
+	for _, res := range responses {
+		if res == nil {
+			continue
+		}
+		mergeCountPerKV(out.Search, res.Search)
+		mergeCountPerKV(out.Count, res.Count)
+		mergeCountPerKV(out.EventType, res.EventType)
+		mergeCountPerKV(out.ForeignId, res.ForeignId)
+		for k, v := range res.Possible {
+			out.Possible[k] += v
+		}
+		out.Total += res.Total
+		out.Sample = append(out.Sample, res.Sample...)
+		out.Partial = out.Partial || res.Partial
+
+		if res.Chart != nil {
+			if out.Chart == nil {
+				out.Chart = &spec.Chart{Buckets: map[uint32]*spec.ChartBucketPerTime{}, TimeStart: res.Chart.TimeStart, TimeEnd: res.Chart.TimeEnd, TimeBucketSec: res.Chart.TimeBucketSec}
+			}
+			mergeChart(out.Chart, res.Chart)
+		}
+	}
+
+	sort.SliceStable(out.Sample, func(i, j int) bool {
+		return out.Sample[i].Metadata.CreatedAtNs < out.Sample[j].Metadata.CreatedAtNs
+	})
+	if len(out.Sample) > sampleLimit {
+		out.Sample = out.Sample[:sampleLimit]
+	}
+	return out
+}
+
+func mergeChart(into *spec.Chart, from *spec.Chart) {
+	for bucket, v := range from.Buckets {
+		b, ok := into.Buckets[bucket]
+		if !ok {
+			b = &spec.ChartBucketPerTime{PerType: map[string]*spec.PointPerEventType{}}
+			into.Buckets[bucket] = b
+		}
+		for eventType, point := range v.PerType {
+			p, ok := b.PerType[eventType]
+			if !ok {
+				p = &spec.PointPerEventType{Bucket: point.Bucket, EventType: point.EventType}
+				b.PerType[eventType] = p
+			}
+			p.Count += point.Count
+			p.CountUnique += point.CountUnique
+		}
+	}
+}
+
+var errNoPush = status.Error(codes.Unimplemented, "the coordinator does not store data, push to the shards")
+
+func (c *Coordinator) SayPush(stream spec.Search_SayPushServer) error {
+	return errNoPush
+}
+
+func (c *Coordinator) SayPushAcked(stream spec.Search_SayPushAckedServer) error {
+	return errNoPush
+}
+
+func (c *Coordinator) SayHealth(ctx context.Context, in *spec.HealthRequest) (*spec.Success, error) {
+	_, err := c.scatter(ctx, func(ctx context.Context, i int, shard Shard) error {
+		_, err := shard.Client.SayHealth(ctx, in)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &spec.Success{Success: true}, nil
+}
+
+func (c *Coordinator) SayReindex(ctx context.Context, in *spec.ReindexRequest) (*spec.ReindexResponse, error) {
+	out := &spec.ReindexResponse{Documents: map[string]uint32{}}
+	var lock sync.Mutex
+
+	// reindexing takes long, no deadline and no partial results
+	ctx = forward(ctx)
+	errs := make([]error, len(c.Shards))
+	var wg sync.WaitGroup
+	for i, shard := range c.Shards {
+		wg.Add(1)
+		go func(i int, shard Shard) {
+			defer wg.Done()
+			res, err := shard.Client.SayReindex(ctx, in)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			lock.Lock()
+			for k, v := range res.Documents {
+				out.Documents[k] += v
+			}
+			lock.Unlock()
+		}(i, shard)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "shard %s failed: %s", c.Shards[i].Name, err.Error())
+		}
+	}
+	return out, nil
+}
+
+// SayForget has to reach every shard, a shard that fails fails the whole
+// request so it can be retried, forgetting twice is harmless
+func (c *Coordinator) SayForget(ctx context.Context, in *spec.ForgetRequest) (*spec.ForgetResponse, error) {
+	out := &spec.ForgetResponse{Segments: []string{}}
+	var lock sync.Mutex
+
+	// forgetting fetches and compacts segments, no deadline
+	ctx = forward(ctx)
+	errs := make([]error, len(c.Shards))
+	var wg sync.WaitGroup
+	for i, shard := range c.Shards {
+		wg.Add(1)
+		go func(i int, shard Shard) {
+			defer wg.Done()
+			res, err := shard.Client.SayForget(ctx, in)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			lock.Lock()
+			out.Documents += res.Documents
+			out.Compacted += res.Compacted
+			for _, segment := range res.Segments {
+				out.Segments = append(out.Segments, shard.Name+"/"+segment)
+			}
+			lock.Unlock()
+		}(i, shard)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "shard %s failed: %s", c.Shards[i].Name, err.Error())
+		}
+	}
+	sort.Strings(out.Segments)
+	return out, nil
+}
+
+var errNoSnapshot = status.Error(codes.Unimplemented, "snapshots are per node, call the shards directly")
+
+func (c *Coordinator) SaySnapshot(ctx context.Context, in *spec.SnapshotRequest) (*spec.SnapshotResponse, error) {
+	return nil, errNoSnapshot
+}
+
+var errNoReplication = status.Error(codes.Unimplemented, "replication is per node, call the shards directly")
+
+func (c *Coordinator) SayReplicate(in *spec.ReplicateRequest, stream spec.Search_SayReplicateServer) error {
+	return errNoReplication
+}
+
+func (c *Coordinator) SayReplicationStatus(ctx context.Context, in *spec.ReplicationStatusRequest) (*spec.ReplicationStatus, error) {
+	return nil, errNoReplication
+}
+
+func (c *Coordinator) SayPromote(ctx context.Context, in *spec.PromoteRequest) (*spec.ReplicationStatus, error) {
+	return nil, errNoReplication
+}
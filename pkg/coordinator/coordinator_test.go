@@ -0,0 +1,140 @@
+package coordinator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"github.com/rekki/blackrock/pkg/logger"
+	"google.golang.org/grpc"
+)
+
+func init() {
+	logger.LogInit(3)
+}
+
+type fakeShard struct {
+	spec.SearchClient
+	search    *spec.SearchQueryResponse
+	aggregate *spec.Aggregate
+	delay     time.Duration
+	err       error
+}
+
+func (f *fakeShard) wait(ctx context.Context) error {
+	if f.err != nil {
+		return f.err
+	}
+	select {
+	case <-time.After(f.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *fakeShard) SaySearch(ctx context.Context, in *spec.SearchQueryRequest, opts ...grpc.CallOption) (*spec.SearchQueryResponse, error) {
+	err := f.wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return f.search, nil
+}
+
+func (f *fakeShard) SayAggregate(ctx context.Context, in *spec.AggregateRequest, opts ...grpc.CallOption) (*spec.Aggregate, error) {
+	err := f.wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return f.aggregate, nil
+}
+
+func hit(id uint64, score float32) *spec.Hit {
+	return &spec.Hit{Id: id, Score: score, Metadata: &spec.Metadata{CreatedAtNs: int64(id)}}
+}
+
+func TestSearchMergesTopHits(t *testing.T) {
+	c := &Coordinator{
+		Timeout:      100 * time.Millisecond,
+		AllowPartial: true,
+		Shards: []Shard{
+			{Name: "a", Client: &fakeShard{search: &spec.SearchQueryResponse{Total: 10, Hits: []*spec.Hit{hit(1, 5), hit(2, 1)}}}},
+			{Name: "b", Client: &fakeShard{search: &spec.SearchQueryResponse{Total: 20, Hits: []*spec.Hit{hit(3, 3), hit(4, 2)}}}},
+			{Name: "slow", Client: &fakeShard{delay: time.Second, search: &spec.SearchQueryResponse{Total: 100}}},
+			{Name: "broken", Client: &fakeShard{err: errors.New("down")}},
+		},
+	}
+
+	res, err := c.SaySearch(context.Background(), &spec.SearchQueryRequest{Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 30 {
+		t.Fatalf("expected total 30, got %d", res.Total)
+	}
+	if len(res.Hits) != 3 || res.Hits[0].Id != 1 || res.Hits[1].Id != 3 || res.Hits[2].Id != 4 {
+		t.Fatalf("unexpected hits %v", res.Hits)
+	}
+	if !res.Partial || len(res.FailedShards) != 2 || res.FailedShards[0] != "slow" || res.FailedShards[1] != "broken" {
+		t.Fatalf("expected partial result, got %v %v", res.Partial, res.FailedShards)
+	}
+
+	c.AllowPartial = false
+	_, err = c.SaySearch(context.Background(), &spec.SearchQueryRequest{Limit: 3})
+	if err == nil {
+		t.Fatal("expected error without partial results")
+	}
+
+	// a shard stopped by the budget of the query makes the result partial
+	c.Shards = c.Shards[:2]
+	c.Shards[1].Client.(*fakeShard).search.Partial = true
+	res, err = c.SaySearch(context.Background(), &spec.SearchQueryRequest{Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Partial || len(res.FailedShards) != 0 {
+		t.Fatalf("expected partial result without failed shards, got %v %v", res.Partial, res.FailedShards)
+	}
+}
+
+func TestAggregateMergesCountsAndCharts(t *testing.T) {
+	shard := func(n uint32, sample uint64) *fakeShard {
+		return &fakeShard{aggregate: &spec.Aggregate{
+			Search:    map[string]*spec.CountPerKV{"country": {Key: "country", Total: n, Count: map[string]uint32{"nl": n}}},
+			Count:     map[string]*spec.CountPerKV{},
+			EventType: map[string]*spec.CountPerKV{"event_type": {Key: "event_type", Total: n, Count: map[string]uint32{"click": n}}},
+			ForeignId: map[string]*spec.CountPerKV{},
+			Possible:  map[string]uint32{"country": n},
+			Total:     n,
+			Sample:    []*spec.Hit{hit(sample, 0)},
+			Chart: &spec.Chart{
+				TimeBucketSec: 60,
+				Buckets: map[uint32]*spec.ChartBucketPerTime{
+					1: {PerType: map[string]*spec.PointPerEventType{"click": {Bucket: 1, EventType: "click", Count: n, CountUnique: 1}}},
+				},
+			},
+		}}
+	}
+
+	c := &Coordinator{AllowPartial: true, Shards: []Shard{{Name: "a", Client: shard(2, 20)}, {Name: "b", Client: shard(3, 10)}}}
+	res, err := c.SayAggregate(context.Background(), &spec.AggregateRequest{SampleLimit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Total != 5 || res.Possible["country"] != 5 || res.Partial {
+		t.Fatalf("unexpected totals %v", res)
+	}
+	if res.Search["country"].Count["nl"] != 5 || res.Search["country"].Total != 5 || res.EventType["event_type"].Count["click"] != 5 {
+		t.Fatalf("unexpected counts %v %v", res.Search, res.EventType)
+	}
+	point := res.Chart.Buckets[1].PerType["click"]
+	if point.Count != 5 || point.CountUnique != 2 || res.Chart.TimeBucketSec != 60 {
+		t.Fatalf("unexpected chart %v", res.Chart)
+	}
+	if len(res.Sample) != 1 || res.Sample[0].Id != 10 {
+		t.Fatalf("expected the oldest sample, got %v", res.Sample)
+	}
+}
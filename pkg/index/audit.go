@@ -0,0 +1,91 @@
+package index
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditLog is an append only file with one json record per line for every
+// forget and every compaction. It keeps the hmac-sha256 of the foreign id
+// with a secret key and not the id itself, whoever has the key can prove
+// an erasure by hashing the id again, without it the ids can not be
+// guessed from the log.
+type AuditLog struct {
+	file *os.File
+	key  []byte
+	sync.Mutex
+}
+
+type AuditRecord struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Tenant string    `json:"tenant,omitempty"`
+	// api key name or ip of who asked for it
+	Client        string   `json:"client,omitempty"`
+	Reason        string   `json:"reason,omitempty"`
+	ForeignType   string   `json:"foreign_type,omitempty"`
+	ForeignIdHmac string   `json:"foreign_id_hmac_sha256,omitempty"`
+	Documents     int      `json:"documents"`
+	Segments      []string `json:"segments,omitempty"`
+}
+
+const (
+	AuditForget  = "forget"
+	AuditCompact = "compact"
+)
+
+var ErrNoAuditKey = errors.New("the audit log has no key to hash the foreign ids")
+
+// key is the secret of the hmac of the foreign ids, without it the log
+// can only record compactions
+func OpenAuditLog(fn string, key []byte) (*AuditLog, error) {
+	f, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{file: f, key: key}, nil
+}
+
+// HashForeignId is the hex hmac-sha256 of the foreign id with key
+func HashForeignId(key []byte, foreignId string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(foreignId))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashForeignId hashes the foreign id with the key of the log
+func (a *AuditLog) HashForeignId(foreignId string) (string, error) {
+	if len(a.key) == 0 {
+		return "", ErrNoAuditKey
+	}
+	return HashForeignId(a.key, foreignId), nil
+}
+
+// Append writes and syncs the record
+func (a *AuditLog) Append(r *AuditRecord) error {
+	if r.Time.IsZero() {
+		r.Time = time.Now().UTC()
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	_, err = a.file.Write(append(data, '\n'))
+	if err != nil {
+		return err
+	}
+	return a.file.Sync()
+}
+
+func (a *AuditLog) Close() error {
+	return a.file.Close()
+}
@@ -0,0 +1,344 @@
+package index
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	. "github.com/rekki/blackrock/pkg/logger"
+	pen "github.com/rekki/go-pen"
+	dsl "github.com/rekki/go-query-index"
+)
+
+type CheckReport struct {
+	Root      string
+	Documents int
+	// offsets of records that fail the checksum or do not decode as Metadata
+	Corrupt []uint32
+	// forward index end and the commit marker, they differ after a crash
+	End       uint32
+	Committed uint32
+	HasCommit bool
+
+	PostingFiles int
+	// posting files that are not multiple of 4 bytes
+	Torn []string
+	// posting files that are not strictly increasing
+	OutOfOrder []string
+	// doc ids in the postings that are not a document in the forward index
+	Orphans map[string][]int32
+	// documents missing from blackrock:match_all
+	Unindexed []int32
+}
+
+func (r *CheckReport) OK() bool {
+	return len(r.Corrupt) == 0 && len(r.Torn) == 0 && len(r.OutOfOrder) == 0 && len(r.Orphans) == 0 && len(r.Unindexed) == 0 && (!r.HasCommit || r.Committed == r.End)
+}
+
+func (r *CheckReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: documents: %d, posting files: %d, end: %d", r.Root, r.Documents, r.PostingFiles, r.End)
+	if r.HasCommit {
+		fmt.Fprintf(&sb, ", committed: %d", r.Committed)
+	} else {
+		fmt.Fprintf(&sb, ", no commit marker")
+	}
+	if len(r.Corrupt) > 0 {
+		fmt.Fprintf(&sb, "\n  corrupt records at: %v", r.Corrupt)
+	}
+	for _, fn := range r.Torn {
+		fmt.Fprintf(&sb, "\n  torn posting file: %s", fn)
+	}
+	for _, fn := range r.OutOfOrder {
+		fmt.Fprintf(&sb, "\n  posting file out of order: %s", fn)
+	}
+	for fn, dids := range r.Orphans {
+		fmt.Fprintf(&sb, "\n  orphan postings in %s: %v", fn, dids)
+	}
+	if len(r.Unindexed) > 0 {
+		fmt.Fprintf(&sb, "\n  documents missing from the inverted index: %v", r.Unindexed)
+	}
+	return sb.String()
+}
+
+// scan the forward index between from and to, cb is called for every record
+// that decodes, bad is called for the first offset of every corrupt run
+func scanForward(fn string, from, to uint32, cb func(did uint32, meta *spec.Metadata) error, bad func(did uint32)) error {
+	f, err := os.Open(fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	offset := from
+	inCorruptRun := false
+	for offset < to {
+		data, next, err := pen.ReadFromReader(f, offset, 16)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err == pen.EBADSLT {
+			if !inCorruptRun {
+				bad(offset)
+			}
+			inCorruptRun = true
+			offset++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		meta := &spec.Metadata{}
+		err = proto.Unmarshal(data, meta)
+		if err != nil {
+			if !inCorruptRun {
+				bad(offset)
+			}
+			inCorruptRun = true
+			offset = next
+			continue
+		}
+
+		inCorruptRun = false
+		err = cb(offset, meta)
+		if err != nil {
+			return err
+		}
+		offset = next
+	}
+	return nil
+}
+
+// CheckSegment validates a segment directory, it only reads the files
+// and is safe to run while the segment is not being written
+func CheckSegment(root string) (*CheckReport, error) {
+	fn := path.Join(root, "main.bin")
+	end, err := forwardEnd(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &CheckReport{Root: root, End: end, Orphans: map[string][]int32{}}
+
+	commit, err := os.Open(path.Join(root, "main.commit"))
+	if err == nil {
+		r.Committed, r.HasCommit = readCommit(commit)
+		commit.Close()
+	}
+
+	docs := map[int32]bool{}
+	err = scanForward(fn, 0, math.MaxUint32, func(did uint32, meta *spec.Metadata) error {
+		docs[int32(did)] = true
+		r.Documents++
+		return nil
+	}, func(did uint32) {
+		r.Corrupt = append(r.Corrupt, did)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	inv := path.Join(root, "inv")
+	matchAll := map[int32]bool{}
+	matchAllFile := dsl.NewDirIndex(inv, nil, nil).DirHash("match_all")
+	matchAllFile = path.Join(inv, "blackrock", matchAllFile, "match_all")
+
+	err = filepath.Walk(inv, func(fn string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(fn)
+		if err != nil {
+			return err
+		}
+
+		r.PostingFiles++
+		if len(data)%4 != 0 {
+			r.Torn = append(r.Torn, fn)
+		}
+
+		last := int32(-1)
+		ordered := true
+		for i := 0; i+4 <= len(data); i += 4 {
+			did := int32(binary.LittleEndian.Uint32(data[i:]))
+			if did <= last {
+				ordered = false
+			}
+			last = did
+
+			if !docs[did] {
+				r.Orphans[fn] = append(r.Orphans[fn], did)
+			}
+			if fn == matchAllFile {
+				matchAll[did] = true
+			}
+		}
+		if !ordered {
+			r.OutOfOrder = append(r.OutOfOrder, fn)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for did := range docs {
+		if !matchAll[did] {
+			r.Unindexed = append(r.Unindexed, did)
+		}
+	}
+
+	return r, nil
+}
+
+type invertedBuilder struct {
+	root      string
+	into      string
+	whitelist map[string]bool
+	fdc       *FDCache
+	dir       *dsl.DirIndex
+}
+
+// builds a fresh inverted index at into from the records of root/main.bin
+func newInvertedBuilder(root string, into string, whitelist map[string]bool) (*invertedBuilder, error) {
+	err := os.RemoveAll(into)
+	if err != nil {
+		return nil, err
+	}
+
+	fdc := NewFDCache(1000)
+	return &invertedBuilder{root: root, into: into, whitelist: whitelist, fdc: fdc, dir: dsl.NewDirIndex(into, fdc, nil)}, nil
+}
+
+// index the valid records between from and to, returns how many were indexed
+func (b *invertedBuilder) add(from, to uint32) (int, error) {
+	n := 0
+	err := scanForward(path.Join(b.root, "main.bin"), from, to, func(did uint32, meta *spec.Metadata) error {
+		n++
+		return indexDocument(b.dir, b.whitelist, int32(did), meta)
+	}, func(uint32) {})
+	return n, err
+}
+
+func (b *invertedBuilder) finish() error {
+	err := b.fdc.SyncUnder(b.into)
+	b.fdc.Close()
+	return err
+}
+
+func (b *invertedBuilder) abort() {
+	b.fdc.Close()
+	_ = os.RemoveAll(b.into)
+}
+
+// swap the inverted index of a segment with a rebuilt one, if it is
+// interrupted finishSwap puts things in order when the segment is opened
+func swapInverted(root string, rebuilt string) error {
+	inv := path.Join(root, "inv")
+	old := inv + ".old"
+
+	err := os.RemoveAll(old)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(inv, old)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	err = os.Rename(rebuilt, inv)
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(old)
+}
+
+func finishSwap(root string) error {
+	inv := path.Join(root, "inv")
+	old := inv + ".old"
+
+	_, err := os.Stat(old)
+	if os.IsNotExist(err) {
+		return os.RemoveAll(inv + ".rebuild")
+	}
+
+	_, err = os.Stat(inv)
+	if os.IsNotExist(err) {
+		// interrupted between the renames, the rebuilt index might be incomplete
+		Log.Warnf("segment %s: restoring the inverted index after interrupted swap", root)
+		err = os.Rename(old, inv)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.RemoveAll(inv + ".rebuild")
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+// RebuildSegment recreates the inverted index of a segment from its
+// forward index. The segment must not be open by a running server.
+func RebuildSegment(root string, whitelist map[string]bool) (int, error) {
+	rebuilt := path.Join(root, "inv.rebuild")
+	builder, err := newInvertedBuilder(root, rebuilt, whitelist)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := builder.add(0, math.MaxUint32)
+	if err != nil {
+		builder.abort()
+		return 0, err
+	}
+
+	err = builder.finish()
+	if err != nil {
+		return 0, err
+	}
+
+	err = swapInverted(root, rebuilt)
+	if err != nil {
+		return 0, err
+	}
+
+	end, err := forwardEnd(path.Join(root, "main.bin"))
+	if err != nil {
+		return 0, err
+	}
+
+	commit, err := os.OpenFile(path.Join(root, "main.commit"), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer commit.Close()
+
+	err = writeCommit(commit, end)
+	if err != nil {
+		return 0, err
+	}
+	return n, commit.Sync()
+}
@@ -0,0 +1,86 @@
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/rekki/blackrock/pkg/depths"
+)
+
+func TestCheckAndRebuildSegment(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	si := NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	inserted := 100
+	for i := 0; i < inserted; i++ {
+		err = si.Ingest(RandomEnvelope(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	segmentRoot := si.Segments["0"].root
+	si.Close()
+
+	report, err := CheckSegment(segmentRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Documents != inserted {
+		t.Fatalf("expected clean segment with %d documents, got %s", inserted, report)
+	}
+
+	matchAll := path.Join(segmentRoot, "inv", "blackrock", "l", "match_all")
+	err = os.Truncate(matchAll, 4*10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.OpenFile(matchAll, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.Write(depths.IntsToBytes([]int32{0, 99999999}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	report, err = CheckSegment(segmentRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() {
+		t.Fatal("expected broken segment")
+	}
+	if len(report.OutOfOrder) != 1 || len(report.Orphans[matchAll]) != 1 || len(report.Unindexed) != inserted-10 {
+		t.Fatalf("unexpected report %s", report)
+	}
+
+	n, err := RebuildSegment(segmentRoot, map[string]bool{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != inserted {
+		t.Fatalf("expected %d rebuilt, got %d", inserted, n)
+	}
+
+	report, err = CheckSegment(segmentRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("expected clean segment after rebuild, got %s", report)
+	}
+
+	si = NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	if n := countMatching(t, si, "blackrock", "match_all"); n != inserted {
+		t.Fatalf("expected %d got %d", inserted, n)
+	}
+	si.Close()
+}
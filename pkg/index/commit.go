@@ -0,0 +1,258 @@
+package index
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	. "github.com/rekki/blackrock/pkg/logger"
+	pen "github.com/rekki/go-pen"
+)
+
+// The commit marker (main.commit) stores the forward index offset up to
+// which every document is fully written, both in main.bin and in the
+// inverted index. Anything after it was interrupted and is repaired when
+// the segment is opened.
+
+type SyncPolicy int
+
+const (
+	// fsync only when the segment is closed, the commit marker is still
+	// updated after every write so a killed process can recover
+	SyncOnClose SyncPolicy = iota
+	// fsync the forward index, the postings and the marker after every write
+	SyncAlways
+	// fsync at most once per interval, the marker is only moved after fsync
+	SyncInterval
+)
+
+// ParseSyncPolicy accepts "close", "always" or an interval in milliseconds
+func ParseSyncPolicy(s string) (SyncPolicy, time.Duration, error) {
+	switch s {
+	case "", "close":
+		return SyncOnClose, 0, nil
+	case "always":
+		return SyncAlways, 0, nil
+	}
+
+	ms, err := strconv.Atoi(s)
+	if err != nil || ms <= 0 {
+		return SyncOnClose, 0, fmt.Errorf("bad sync policy %q, expected close, always or number of milliseconds", s)
+	}
+	return SyncInterval, time.Duration(ms) * time.Millisecond, nil
+}
+
+func readCommit(f *os.File) (uint32, bool) {
+	b := make([]byte, 4)
+	err := pen.FixedReadAt(f, 0, b)
+	if err != nil {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(b), true
+}
+
+// same layout as pen.FixedWriteAt, checksum followed by the offset
+func encodeCommit(offset uint32) []byte {
+	b := make([]byte, pen.FixedHeaderSize+4)
+	binary.LittleEndian.PutUint32(b[pen.FixedHeaderSize:], offset)
+	binary.LittleEndian.PutUint64(b, pen.Hash(b[pen.FixedHeaderSize:]))
+	return b
+}
+
+func writeCommit(f *os.File, offset uint32) error {
+	_, err := f.WriteAt(encodeCommit(offset), 0)
+	return err
+}
+
+func forwardEnd(fn string) (uint32, error) {
+	st, err := os.Stat(fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return uint32((st.Size() + int64(pen.PAD) - 1) / int64(pen.PAD)), nil
+}
+
+// must be called before the forward index is opened for writing
+func (s *Segment) recover(fn string, isNew bool) error {
+	end, err := forwardEnd(fn)
+	if err != nil {
+		return err
+	}
+
+	committed, ok := readCommit(s.commit)
+	if !ok {
+		if isNew {
+			// new segment or written before the marker existed
+			s.committed = end
+			return writeCommit(s.commit, end)
+		}
+		// corrupt marker, nothing can be trusted
+		committed = 0
+	}
+
+	if committed == end {
+		s.committed = end
+		return nil
+	}
+
+	if committed > end {
+		committed = end
+	}
+
+	Log.Warnf("segment %s: recovering from offset %d, forward index ends at %d", s.root, committed, end)
+
+	err = truncatePostings(path.Join(s.root, "inv"), int32(committed))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(fn, os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	offset := committed
+	repaired := 0
+	for offset < end {
+		data, next, err := pen.ReadFromReader(f, offset, 16)
+		if err != nil {
+			break
+		}
+
+		meta := &spec.Metadata{}
+		err = proto.Unmarshal(data, meta)
+		if err != nil {
+			break
+		}
+
+		err = s.index(int32(offset), meta)
+		if err != nil {
+			return err
+		}
+		repaired++
+		offset = next
+	}
+
+	if offset < end {
+		Log.Warnf("segment %s: truncating partially written document at offset %d", s.root, offset)
+		err = f.Truncate(int64(offset) * int64(pen.PAD))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = f.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = s.fdc.SyncUnder(s.root)
+	if err != nil {
+		return err
+	}
+
+	Log.Warnf("segment %s: recovered %d documents, committed at %d", s.root, repaired, offset)
+	s.committed = offset
+	err = writeCommit(s.commit, offset)
+	if err != nil {
+		return err
+	}
+	return s.commit.Sync()
+}
+
+// the postings are appended in document order, so everything written
+// after the commit is at the tail of each file
+func truncatePostings(root string, from int32) error {
+	return filepath.Walk(root, func(fn string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(fn)
+		if err != nil {
+			return err
+		}
+
+		keep := len(data) / 4
+		for keep > 0 && int32(binary.LittleEndian.Uint32(data[(keep-1)*4:])) >= from {
+			keep--
+		}
+
+		if keep*4 == len(data) {
+			return nil
+		}
+		return os.Truncate(fn, int64(keep*4))
+	})
+}
+
+// must be called with the segment lock held
+func (s *Segment) syncAndCommit(offset uint32) error {
+	err := s.writer.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = s.fdc.SyncUnder(s.root)
+	if err != nil {
+		return err
+	}
+
+	err = writeCommit(s.commit, offset)
+	if err != nil {
+		return err
+	}
+
+	s.committed = offset
+	s.lastSync = time.Now()
+	err = s.commit.Sync()
+	if err != nil {
+		return err
+	}
+	return s.syncPartitions()
+}
+
+// must be called with the segment lock held, after the document is fully indexed
+func (s *Segment) afterWrite(next uint32) error {
+	s.written = next
+
+	switch s.syncPolicy {
+	case SyncAlways:
+		return s.syncAndCommit(next)
+	case SyncInterval:
+		if time.Since(s.lastSync) >= s.syncInterval {
+			return s.syncAndCommit(next)
+		}
+		return nil
+	default:
+		s.committed = next
+		return writeCommit(s.commit, next)
+	}
+}
+
+// Sync fsyncs everything written so far and moves the commit marker
+func (s *Segment) Sync() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.writer == nil {
+		return nil
+	}
+	return s.syncAndCommit(s.written)
+}
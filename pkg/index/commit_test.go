@@ -0,0 +1,147 @@
+package index
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	go_query_dsl "github.com/rekki/go-query-index-dsl"
+)
+
+func countMatching(t *testing.T, si *SearchIndex, field, value string) int {
+	query := &spec.SearchQueryRequest{FromSecond: 1, ToSecond: 3599, Query: &go_query_dsl.Query{Field: field, Value: value}}
+	matching := 0
+	_, err := si.ForEach(context.Background(), query, 0, func(s *Segment, did int32, score float32) error {
+		m := &spec.Metadata{}
+		err := s.ReadForwardDecode(did, m)
+		if err != nil {
+			return err
+		}
+		matching++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matching
+}
+
+func TestRecoverInterruptedWrites(t *testing.T) {
+	for _, policy := range []string{"close", "always", "100000"} {
+		root, err := ioutil.TempDir("", "si")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(root)
+
+		p, interval, err := ParseSyncPolicy(policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		si := NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+		si.SetSyncPolicy(p, interval)
+
+		inserted := 100
+		for i := 0; i < inserted; i++ {
+			err = si.Ingest(RandomEnvelope(1))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if p == SyncInterval {
+			err = si.Sync()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		segment := si.Segments["0"]
+
+		// crash after writing the forward index
+		forwardOnly := RandomEnvelope(1)
+		forwardOnly.Metadata.ForeignId = "forward_only"
+		err = PrepareEnvelope(forwardOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, err := proto.Marshal(forwardOnly.Metadata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = segment.writer.Append(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// crash after indexing, but before the commit
+		indexed := RandomEnvelope(1)
+		indexed.Metadata.ForeignId = "not_committed"
+		err = PrepareEnvelope(indexed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, err = proto.Marshal(indexed.Metadata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		did, _, err := segment.writer.Append(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = segment.index(int32(did), indexed.Metadata)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// crash in the middle of writing
+		f, err := os.OpenFile(path.Join(segment.root, "main.bin"), os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = f.Write([]byte{1, 2, 3, 4, 5, 6, 7})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+
+		// abandon si without closing it, as if the process died
+		si.fdCache.Close()
+
+		si = NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+		if n := countMatching(t, si, "blackrock", "match_all"); n != inserted+2 {
+			t.Fatalf("%s: expected %d got %d", policy, inserted+2, n)
+		}
+		if n := countMatching(t, si, forwardOnly.Metadata.ForeignType, "forward_only"); n != 1 {
+			t.Fatalf("%s: expected 1 forward_only got %d", policy, n)
+		}
+		if n := countMatching(t, si, indexed.Metadata.ForeignType, "not_committed"); n != 1 {
+			t.Fatalf("%s: expected 1 not_committed got %d", policy, n)
+		}
+
+		err = si.Ingest(RandomEnvelope(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		si.Close()
+
+		si = NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+		if n := countMatching(t, si, "blackrock", "match_all"); n != inserted+3 {
+			t.Fatalf("%s: expected %d got %d", policy, inserted+3, n)
+		}
+		si.Close()
+	}
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	for _, bad := range []string{"never", "-1", "0"} {
+		_, _, err := ParseSyncPolicy(bad)
+		if err == nil {
+			t.Fatalf("expected error for %s", bad)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package index
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	. "github.com/rekki/blackrock/pkg/logger"
+	pen "github.com/rekki/go-pen"
+)
+
+// Envelopes from the kafka consumer have Metadata.Id = partition<<56 | offset.
+// Every segment keeps main.ids, a log of (document id, metadata id) for those
+// envelopes, appended before the forward index so a document that survives
+// recovery is always in it, and main.partitions, the highest offset per
+// partition up to some entry of the log, written on sync.
+
+const (
+	partitionShift = 56
+	offsetMask     = 1<<partitionShift - 1
+	idEntrySize    = 12
+)
+
+func splitPartitionId(id uint64) (uint32, int64) {
+	return uint32(id >> partitionShift), int64(id & offsetMask)
+}
+
+type partitionSummary struct {
+	Entries uint32           `json:"entries"`
+	Highest map[string]int64 `json:"highest"`
+}
+
+func readPartitionSummary(root string) (*partitionSummary, error) {
+	data, err := ioutil.ReadFile(path.Join(root, "main.partitions"))
+	if err != nil {
+		return nil, err
+	}
+	summary := &partitionSummary{}
+	err = json.Unmarshal(data, summary)
+	return summary, err
+}
+
+func writePartitionSummary(root string, entries uint32, highest map[uint32]int64) error {
+	summary := &partitionSummary{Entries: entries, Highest: map[string]int64{}}
+	for p, offset := range highest {
+		summary.Highest[strconv.Itoa(int(p))] = offset
+	}
+
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	fn := path.Join(root, "main.partitions")
+	err = ioutil.WriteFile(fn+".tmp", data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(fn+".tmp", fn)
+}
+
+func readIdLog(root string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path.Join(root, "main.ids"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func idEntry(data []byte, i uint32) (uint32, uint64) {
+	entry := data[i*idEntrySize:]
+	return binary.LittleEndian.Uint32(entry), binary.LittleEndian.Uint64(entry[4:])
+}
+
+// highest offset per partition of a segment and the number of valid log
+// entries, the log after the summary is replayed and stops at the first
+// entry whose document did not make it to the forward index
+func segmentPartitions(root string) (map[uint32]int64, uint32, error) {
+	data, err := readIdLog(root)
+	if err != nil {
+		return nil, 0, err
+	}
+	n := uint32(len(data) / idEntrySize)
+
+	highest := map[uint32]int64{}
+	from := uint32(0)
+	summary, err := readPartitionSummary(root)
+	if err == nil && summary.Entries <= n {
+		for k, v := range summary.Highest {
+			p, err := strconv.Atoi(k)
+			if err != nil {
+				continue
+			}
+			highest[uint32(p)] = v
+		}
+		from = summary.Entries
+	}
+
+	if from == n {
+		return highest, n, nil
+	}
+
+	f, err := os.Open(path.Join(root, "main.bin"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, 0, err
+	}
+	if f != nil {
+		defer f.Close()
+	}
+
+	valid := from
+	for ; valid < n; valid++ {
+		did, id := idEntry(data, valid)
+		if f == nil {
+			break
+		}
+		_, _, err := pen.ReadFromReader(f, did, 16)
+		if err != nil {
+			break
+		}
+
+		p, offset := splitPartitionId(id)
+		if current, ok := highest[p]; !ok || offset > current {
+			highest[p] = offset
+		}
+	}
+	return highest, valid, nil
+}
+
+// must be called after recovery, drops entries for documents that were
+// truncated and a torn tail
+func (s *Segment) openIdLog() error {
+	highest, entries, err := segmentPartitions(s.root)
+	if err != nil {
+		return err
+	}
+
+	fn := path.Join(s.root, "main.ids")
+	err = os.Truncate(fn, int64(entries)*idEntrySize)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	s.ids = f
+	s.idEntries = entries
+	s.partitions = highest
+	return nil
+}
+
+// must be called with the segment lock held, before the forward index append
+func (s *Segment) logPartitionId(id uint64) error {
+	entry := make([]byte, idEntrySize)
+	binary.LittleEndian.PutUint32(entry, s.written)
+	binary.LittleEndian.PutUint64(entry[4:], id)
+	_, err := s.ids.Write(entry)
+	if err != nil {
+		return err
+	}
+	s.idEntries++
+	return nil
+}
+
+// must be called with the segment lock held, after the document is written
+func (s *Segment) notePartitionId(id uint64) {
+	p, offset := splitPartitionId(id)
+	if current, ok := s.partitions[p]; !ok || offset > current {
+		s.partitions[p] = offset
+	}
+}
+
+// the forward index append failed, the entry would point to the next document
+func (s *Segment) unlogPartitionId() error {
+	s.idEntries--
+	return s.ids.Truncate(int64(s.idEntries) * idEntrySize)
+}
+
+// must be called with the segment lock held, after the commit marker
+func (s *Segment) syncPartitions() error {
+	if s.idEntries == 0 {
+		return nil
+	}
+	err := s.ids.Sync()
+	if err != nil {
+		return err
+	}
+	return writePartitionSummary(s.root, s.idEntries, s.partitions)
+}
+
+// document ids between from and to that were ingested with a partition id
+func partitionIdDocuments(root string, from, to uint32) (map[uint32]bool, error) {
+	data, err := readIdLog(root)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[uint32]bool{}
+	for i := uint32(0); i < uint32(len(data)/idEntrySize); i++ {
+		did, _ := idEntry(data, i)
+		if did >= from && did < to {
+			out[did] = true
+		}
+	}
+	return out, nil
+}
+
+// the lock of a partition is held from the check of an offset until it
+// is ingested, so the envelopes of a partition are ingested one at a time
+// and different partitions in parallel. The mutex guards the maps only.
+type partitionDedup struct {
+	highest map[uint32]int64
+	locks   map[uint32]*sync.Mutex
+	sync.Mutex
+}
+
+// the lock of partition p, loading the highest offsets on first use
+func (m *SearchIndex) partitionLock(p uint32) (*sync.Mutex, error) {
+	m.partitions.Lock()
+	defer m.partitions.Unlock()
+
+	err := m.loadPartitions()
+	if err != nil {
+		return nil, err
+	}
+	if m.partitions.locks == nil {
+		m.partitions.locks = map[uint32]*sync.Mutex{}
+	}
+	l, ok := m.partitions.locks[p]
+	if !ok {
+		l = &sync.Mutex{}
+		m.partitions.locks[p] = l
+	}
+	return l, nil
+}
+
+func (m *SearchIndex) highestOffset(p uint32) (int64, bool) {
+	m.partitions.Lock()
+	defer m.partitions.Unlock()
+	offset, ok := m.partitions.highest[p]
+	return offset, ok
+}
+
+// must be called with the dedup lock held
+func (m *SearchIndex) loadPartitions() error {
+	if m.partitions.highest != nil {
+		return nil
+	}
+
+	segments, err := m.ListSegments()
+	if err != nil {
+		return err
+	}
+
+	highest := map[uint32]int64{}
+	for _, ns := range segments {
+		segmentId := m.toSegmentId(ns)
+
+		var partitions map[uint32]int64
+		m.RLock()
+		s, loaded := m.Segments[segmentId]
+		m.RUnlock()
+		if loaded {
+			s.Lock()
+			partitions = map[uint32]int64{}
+			for p, offset := range s.partitions {
+				partitions[p] = offset
+			}
+			s.Unlock()
+		} else {
+			partitions, _, err = segmentPartitions(path.Join(m.root, segmentId))
+			if err != nil {
+				return err
+			}
+		}
+
+		for p, offset := range partitions {
+			if current, ok := highest[p]; !ok || offset > current {
+				highest[p] = offset
+			}
+		}
+	}
+
+	Log.Infof("loaded the highest offsets of %d partitions", len(highest))
+	m.partitions.highest = highest
+	return nil
+}
+
+// IngestPartitioned ingests an envelope with Metadata.Id set to
+// partition<<56 | offset, offsets not after the highest ingested offset of
+// the partition are skipped, returns false for skipped envelopes
+func (m *SearchIndex) IngestPartitioned(envelope *spec.Envelope) (bool, error) {
+	err := PrepareEnvelope(envelope)
+	if err != nil {
+		return false, err
+	}
+
+	p, offset := splitPartitionId(envelope.Metadata.Id)
+	l, err := m.partitionLock(p)
+	if err != nil {
+		return false, err
+	}
+	l.Lock()
+	defer l.Unlock()
+
+	if current, ok := m.highestOffset(p); ok && offset <= current {
+		Log.Debugf("skipping partition: %d offset: %d, already ingested %d", p, offset, current)
+		return false, nil
+	}
+
+	err = m.hold(envelope.Metadata.CreatedAtNs, func(segment *Segment) error {
+		return segment.ingest(envelope, true)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	m.partitions.Lock()
+	if current, ok := m.partitions.highest[p]; !ok || offset > current {
+		m.partitions.highest[p] = offset
+	}
+	m.partitions.Unlock()
+	return true, nil
+}
+
+type seenId struct {
+	id uint64
+	at int64
+}
+
+// ids seen in the last window, in memory only
+type recentIds struct {
+	// when the id was seen, an id unseen and seen again has a newer entry
+	// in order and only that one expires it
+	seen  map[uint64]int64
+	order []seenId
+	sync.Mutex
+}
+
+// returns true if id was seen within window, otherwise remembers it
+func (r *recentIds) seenWithin(id uint64, window time.Duration) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.seen == nil {
+		r.seen = map[uint64]int64{}
+	}
+
+	now := time.Now().UnixNano()
+	expired := 0
+	for expired < len(r.order) && now-r.order[expired].at > int64(window) {
+		e := r.order[expired]
+		if r.seen[e.id] == e.at {
+			delete(r.seen, e.id)
+		}
+		expired++
+	}
+	r.order = r.order[expired:]
+
+	if _, ok := r.seen[id]; ok {
+		return true
+	}
+	r.seen[id] = now
+	r.order = append(r.order, seenId{id: id, at: now})
+	return false
+}
+
+// the ingest of id failed, its retry must not be skipped
+func (r *recentIds) unsee(id uint64) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.seen, id)
+}
@@ -0,0 +1,192 @@
+package index
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+)
+
+func partitioned(p uint32, offset int64) *spec.Envelope {
+	e := RandomEnvelope(1)
+	e.Metadata.Id = uint64(p)<<partitionShift | uint64(offset)
+	return e
+}
+
+func TestPartitionDedup(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	ingest := func(si *SearchIndex, p uint32, from, to int64) int {
+		n := 0
+		for offset := from; offset < to; offset++ {
+			ok, err := si.IngestPartitioned(partitioned(p, offset))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok {
+				n++
+			}
+		}
+		return n
+	}
+
+	si := NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	if n := ingest(si, 1, 0, 10) + ingest(si, 2, 0, 5); n != 15 {
+		t.Fatalf("expected 15 ingested, got %d", n)
+	}
+	if n := ingest(si, 1, 5, 12) + ingest(si, 2, 0, 5); n != 2 {
+		t.Fatalf("expected only 2 new offsets, got %d", n)
+	}
+	err = si.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := ingest(si, 2, 5, 7); n != 2 {
+		t.Fatalf("expected 2 ingested, got %d", n)
+	}
+	si.Close()
+
+	// a crash between the id log and the forward index
+	segment := path.Join(si.root, "0")
+	entry := make([]byte, idEntrySize)
+	binary.LittleEndian.PutUint32(entry, 1<<20)
+	binary.LittleEndian.PutUint64(entry[4:], uint64(3)<<partitionShift|100)
+	f, err := os.OpenFile(path.Join(segment, "main.ids"), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.Write(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	si = NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	defer si.Close()
+	if n := ingest(si, 1, 0, 12) + ingest(si, 2, 0, 7); n != 0 {
+		t.Fatalf("expected replays to be skipped after reopen, got %d", n)
+	}
+	if n := ingest(si, 3, 0, 1); n != 1 {
+		t.Fatalf("expected the torn entry to be ignored, got %d", n)
+	}
+	if n := len(documents(t, si)); n != 20 {
+		t.Fatalf("expected 20 documents, got %d", n)
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	si := NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	defer si.Close()
+	si.DedupWindow = 50 * time.Millisecond
+
+	for i := 0; i < 3; i++ {
+		e := RandomEnvelope(1)
+		e.Metadata.Id = 7
+		err := si.Ingest(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(documents(t, si)); n != 1 {
+		t.Fatalf("expected 1 document within the window, got %d", n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	e := RandomEnvelope(1)
+	e.Metadata.Id = 7
+	err = si.Ingest(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(documents(t, si)); n != 2 {
+		t.Fatalf("expected 2 documents after the window, got %d", n)
+	}
+}
+
+func TestDedupRetryAfterFailedIngest(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	si := NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	defer si.Close()
+	si.DedupWindow = time.Minute
+
+	// the segment can not be created while a file is in its place
+	blocker := path.Join(si.root, "0")
+	err = ioutil.WriteFile(blocker, []byte("x"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := RandomEnvelope(1)
+	e.Metadata.Id = 7
+	err = si.Ingest(e)
+	if err == nil {
+		t.Fatal("expected the ingest to fail")
+	}
+
+	err = os.Remove(blocker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = RandomEnvelope(1)
+	e.Metadata.Id = 7
+	err = si.Ingest(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(documents(t, si)); n != 1 {
+		t.Fatalf("expected the retry to be indexed, got %d documents", n)
+	}
+}
+
+func TestPartitionDedupConcurrent(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	si := NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	defer si.Close()
+
+	// every partition is pushed twice at the same time, as by a consumer
+	// retrying, each offset must be ingested once
+	var wg sync.WaitGroup
+	for p := uint32(0); p < 4; p++ {
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func(p uint32) {
+				defer wg.Done()
+				for offset := int64(0); offset < 50; offset++ {
+					_, err := si.IngestPartitioned(partitioned(p, offset))
+					if err != nil {
+						panic(err)
+					}
+				}
+			}(p)
+		}
+	}
+	wg.Wait()
+
+	if n := len(documents(t, si)); n != 200 {
+		t.Fatalf("expected 200 documents, got %d", n)
+	}
+}
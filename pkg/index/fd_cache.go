@@ -0,0 +1,102 @@
+package index
+
+import (
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// same as go-query-index's FDCache, but it can close only the
+// descriptors of one segment, DirIndex.Close() closes everything
+type FDCache struct {
+	fdCache   map[string]*os.File
+	maxOpenFD int
+
+	// fsync the descriptors before they are closed because the cache is full
+	SyncOnEvict bool
+	sync.RWMutex
+}
+
+func NewFDCache(n int) *FDCache {
+	return &FDCache{maxOpenFD: n, fdCache: map[string]*os.File{}}
+}
+
+func (x *FDCache) Close() {
+	x.Lock()
+	defer x.Unlock()
+
+	for _, fd := range x.fdCache {
+		_ = fd.Close()
+	}
+	x.fdCache = map[string]*os.File{}
+}
+
+func (x *FDCache) CloseUnder(root string) {
+	x.Lock()
+	defer x.Unlock()
+
+	prefix := path.Clean(root) + "/"
+	for fn, fd := range x.fdCache {
+		if strings.HasPrefix(fn, prefix) {
+			_ = fd.Close()
+			delete(x.fdCache, fn)
+		}
+	}
+}
+
+func (x *FDCache) SyncUnder(root string) error {
+	x.RLock()
+	defer x.RUnlock()
+
+	prefix := path.Clean(root) + "/"
+	for fn, fd := range x.fdCache {
+		if strings.HasPrefix(fn, prefix) {
+			err := fd.Sync()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (x *FDCache) Use(fn string, createFile func(fn string) (*os.File, error), cb func(*os.File) error) error {
+	x.RLock()
+	f, ok := x.fdCache[fn]
+	if ok {
+		err := cb(f)
+		x.RUnlock()
+		return err
+	}
+	x.RUnlock()
+
+	_ = os.MkdirAll(path.Dir(fn), 0700)
+
+	f, err := createFile(fn)
+	if err != nil {
+		return err
+	}
+
+	x.Lock()
+	defer x.Unlock()
+
+	overriden, ok := x.fdCache[fn]
+	if ok {
+		f.Close()
+		f = overriden
+	} else {
+		if len(x.fdCache) > x.maxOpenFD {
+			for _, fd := range x.fdCache {
+				if x.SyncOnEvict {
+					_ = fd.Sync()
+				}
+				_ = fd.Close()
+			}
+			x.fdCache = map[string]*os.File{}
+		}
+		x.fdCache[fn] = f
+	}
+
+	return cb(f)
+}
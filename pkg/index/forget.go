@@ -0,0 +1,531 @@
+package index
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	. "github.com/rekki/blackrock/pkg/logger"
+	pen "github.com/rekki/go-pen"
+	iq "github.com/rekki/go-query"
+	dsl "github.com/rekki/go-query-index"
+)
+
+// Forget marks the documents of a foreign_type:foreign_id as deleted in
+// main.deleted, an append only list of document ids, and every query skips
+// them from then on. The data is still on disk until Compact rewrites the
+// segment without them, renumbering the documents that are left. The
+// compacted segment is built in <id>.compact and swapped in with two
+// renames, an interrupted swap is finished when the segment is loaded.
+//
+// Followers get the tombstones, and a placeholder of the same size instead
+// of every forgotten record. Compacting changes the offsets of a segment,
+// followers replicating it have to be restored from a snapshot, and
+// snapshots taken before still have the documents.
+
+const deletedName = "main.deleted"
+
+type tombstones struct {
+	root  string
+	count int
+	// []uint64 bitmap of document ids, copied on write so queries read
+	// it without locking
+	bits atomic.Value
+	sync.Mutex
+}
+
+func readTombstones(root string) (*tombstones, error) {
+	t := &tombstones{root: root}
+	data, err := ioutil.ReadFile(path.Join(root, deletedName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	bits := []uint64{}
+	for i := 0; i+4 <= len(data); i += 4 {
+		bits = setDeleted(bits, int32(binary.LittleEndian.Uint32(data[i:])))
+		t.count++
+	}
+	t.bits.Store(bits)
+	return t, nil
+}
+
+func setDeleted(bits []uint64, did int32) []uint64 {
+	w := int(uint32(did) / 64)
+	for len(bits) <= w {
+		bits = append(bits, 0)
+	}
+	bits[w] |= 1 << (uint32(did) % 64)
+	return bits
+}
+
+func isDeleted(bits []uint64, did int32) bool {
+	w := int(uint32(did) / 64)
+	return w < len(bits) && bits[w]&(1<<(uint32(did)%64)) != 0
+}
+
+func (t *tombstones) current() []uint64 {
+	return t.bits.Load().([]uint64)
+}
+
+// add syncs the ids that are not deleted yet to main.deleted, returns how
+// many there were
+func (t *tombstones) add(dids []int32) (int, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	bits := append([]uint64{}, t.current()...)
+	entries := []byte{}
+	for _, did := range dids {
+		if isDeleted(bits, did) {
+			continue
+		}
+		bits = setDeleted(bits, did)
+		entry := make([]byte, 4)
+		binary.LittleEndian.PutUint32(entry, uint32(did))
+		entries = append(entries, entry...)
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	f, err := os.OpenFile(path.Join(t.root, deletedName), os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	// after a torn tail, it was not synced so it was never acknowledged
+	_, err = f.WriteAt(entries, st.Size()-st.Size()%4)
+	if err != nil {
+		return 0, err
+	}
+	err = f.Sync()
+	if err != nil {
+		return 0, err
+	}
+
+	t.bits.Store(bits)
+	t.count += len(entries) / 4
+	return len(entries) / 4, nil
+}
+
+// the ids appended to main.deleted after from bytes, and the size read up
+// to
+func readTombstonesSince(root string, from int64) ([]uint32, int64, error) {
+	data, err := ioutil.ReadFile(path.Join(root, deletedName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, 0, err
+	}
+	end := int64(len(data)) - int64(len(data))%4
+	if end < from {
+		return nil, end, fmt.Errorf("%s: shrank from %d to %d bytes, the segment was compacted", path.Join(root, deletedName), from, end)
+	}
+	dids := []uint32{}
+	for i := from; i < end; i += 4 {
+		dids = append(dids, binary.LittleEndian.Uint32(data[i:]))
+	}
+	return dids, end, nil
+}
+
+// forgottenRecord is sent to followers instead of a forgotten record. It
+// has the same size, so the documents after it keep their ids, and
+// decodes to a Metadata with only the id, padded with an unknown field.
+func forgottenRecord(size int, id uint64) ([]byte, error) {
+	out := []byte{}
+	if id != 0 {
+		// field 12, fixed64
+		out = append(out, 12<<3|1, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint64(out[1:], id)
+	}
+	left := size - len(out)
+	if left == 0 {
+		return out, nil
+	}
+	if left < 2 {
+		return nil, fmt.Errorf("can not pad a record of %d bytes", size)
+	}
+
+	// field 15, bytes, with the length as a varint of up to 5 bytes, longer
+	// than needed is still valid
+	out = append(out, 15<<3|2)
+	k := left - 1
+	if k > 5 {
+		k = 5
+	}
+	n := left - 1 - k
+	for i := 0; i < k; i++ {
+		b := byte(n>>(7*uint(i))) & 0x7f
+		if i < k-1 {
+			b |= 0x80
+		}
+		out = append(out, b)
+	}
+	return append(out, make([]byte, n)...), nil
+}
+
+// Deleted tells if the document was forgotten
+func (s *Segment) Deleted(did int32) bool {
+	return isDeleted(s.deleted.current(), did)
+}
+
+// the documents of foreignType:foreignId, checked against the forward
+// index because a search key can have the same name as a foreign type
+func (s *Segment) foreignDocuments(foreignType, foreignId string) ([]int32, error) {
+	s.invLock.RLock()
+	queries := s.dir.Terms(foreignType, foreignId)
+	s.invLock.RUnlock()
+
+	query := iq.Or(queries...)
+	dids := []int32{}
+	for query.Next() != iq.NO_MORE {
+		did := query.GetDocId()
+		meta := &spec.Metadata{}
+		err := s.ReadForwardDecode(did, meta)
+		if err != nil {
+			return nil, err
+		}
+		if meta.ForeignType == foreignType && meta.ForeignId == foreignId {
+			dids = append(dids, did)
+		}
+	}
+	return dids, nil
+}
+
+func (s *Segment) forget(foreignType, foreignId string) (int, error) {
+	dids, err := s.foreignDocuments(foreignType, foreignId)
+	if err != nil || len(dids) == 0 {
+		return 0, err
+	}
+
+	s.Lock()
+	err = s.detachRemoteLocked()
+	s.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	return s.deleted.add(dids)
+}
+
+// ids of the segments on local disk and in the tiered storage
+func (m *SearchIndex) segmentIds() ([]string, error) {
+	local, err := m.ListSegments()
+	if err != nil {
+		return nil, err
+	}
+	ids := map[string]bool{}
+	for _, ns := range local {
+		ids[m.toSegmentId(ns)] = true
+	}
+	m.loading.Lock()
+	if m.tiered != nil {
+		for id := range m.tiered.remote {
+			ids[id] = true
+		}
+	}
+	m.loading.Unlock()
+
+	out := []string{}
+	for id := range ids {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// Forget marks every document of foreignType:foreignId as deleted, in all
+// segments, fetching the ones in the tiered storage. Documents pushed
+// after it are kept. Returns how many documents were deleted and the ids
+// of the segments they were in.
+func (m *SearchIndex) Forget(ctx context.Context, foreignType, foreignId string) (int, []string, error) {
+	ids, err := m.segmentIds()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	deleted := 0
+	segments := []string{}
+	for _, id := range ids {
+		err = ctx.Err()
+		if err != nil {
+			return deleted, segments, err
+		}
+
+		step, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			continue
+		}
+		err = m.hold(step*m.SegmentStep*int64(time.Second), func(s *Segment) error {
+			n, err := s.forget(foreignType, foreignId)
+			if n > 0 {
+				deleted += n
+				segments = append(segments, id)
+			}
+			return err
+		})
+		if err != nil {
+			return deleted, segments, err
+		}
+	}
+	return deleted, segments, nil
+}
+
+// Compact rewrites the local segments with deleted documents without them.
+// Segments in use are left for the next call, returns how many documents
+// were removed per segment id.
+func (m *SearchIndex) Compact() (map[string]int, error) {
+	local, err := m.ListSegments()
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]int{}
+	for _, ns := range local {
+		id := m.toSegmentId(ns)
+		st, err := os.Stat(path.Join(m.root, id, deletedName))
+		if err != nil || st.Size() < 4 {
+			continue
+		}
+
+		n, err := m.compactSegment(id)
+		if err != nil {
+			return out, err
+		}
+		if n > 0 {
+			out[id] = n
+		}
+	}
+	return out, nil
+}
+
+// loading is held while compacting, so the segment is not loaded again
+// before it is swapped
+func (m *SearchIndex) compactSegment(segmentId string) (int, error) {
+	m.loading.Lock()
+	defer m.loading.Unlock()
+
+	m.Lock()
+	s, loaded := m.Segments[segmentId]
+	if loaded {
+		if !s.isIdle() {
+			m.Unlock()
+			return 0, nil
+		}
+		delete(m.Segments, segmentId)
+	}
+	m.Unlock()
+	if loaded {
+		s.Close()
+	}
+
+	started := time.Now()
+	root := path.Join(m.root, segmentId)
+	n, err := CompactSegment(root, m.whitelist)
+	if err != nil || n == 0 {
+		return n, err
+	}
+
+	// the store has the documents as well, the compacted segment is
+	// uploaded again by the next Offload
+	if t := m.tiered; t != nil {
+		if t.remote[segmentId] {
+			err = m.deleteRemoteLocked(segmentId)
+			if err != nil {
+				return n, err
+			}
+		}
+		delete(t.cached, segmentId)
+		delete(t.lastUsed, segmentId)
+	}
+
+	Log.Infof("segment %s: compacted, removed %d documents in %s", root, n, time.Since(started))
+	return n, nil
+}
+
+// CompactSegment rewrites a segment without its deleted documents, returns
+// how many were removed. The segment must not be open.
+func CompactSegment(root string, whitelist map[string]bool) (int, error) {
+	err := finishCompaction(root)
+	if err != nil {
+		return 0, err
+	}
+	t, err := readTombstones(root)
+	if err != nil || t.count == 0 {
+		return 0, err
+	}
+	bits := t.current()
+
+	into := root + ".compact"
+	err = os.RemoveAll(into)
+	if err != nil {
+		return 0, err
+	}
+	err = os.MkdirAll(into, 0700)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := compactInto(root, into, bits, whitelist)
+	if err != nil {
+		_ = os.RemoveAll(into)
+		return 0, err
+	}
+
+	old := root + ".precompact"
+	err = os.Rename(root, old)
+	if err != nil {
+		return 0, err
+	}
+	err = os.Rename(into, root)
+	if err != nil {
+		return 0, err
+	}
+	return n, os.RemoveAll(old)
+}
+
+func compactInto(root string, into string, bits []uint64, whitelist map[string]bool) (int, error) {
+	fn := path.Join(root, "main.bin")
+	end, err := forwardEnd(fn)
+	if err != nil {
+		return 0, err
+	}
+	commit, err := os.Open(path.Join(root, "main.commit"))
+	if err == nil {
+		if committed, ok := readCommit(commit); ok && committed < end {
+			end = committed
+		}
+		commit.Close()
+	}
+
+	w, err := pen.NewWriter(path.Join(into, "main.bin"))
+	if err != nil {
+		return 0, err
+	}
+	defer w.Close()
+
+	fdc := NewFDCache(1000)
+	defer fdc.Close()
+	dir := dsl.NewDirIndex(path.Join(into, "inv"), fdc, nil)
+
+	removed := 0
+	written := uint32(0)
+	renumbered := map[uint32]uint32{}
+	if end > 0 {
+		err = readRecords(fn, 0, end, func(offset uint32, data []byte) error {
+			if isDeleted(bits, int32(offset)) {
+				removed++
+				return nil
+			}
+			meta := &spec.Metadata{}
+			err := proto.Unmarshal(data, meta)
+			if err != nil {
+				return err
+			}
+			did, next, err := w.Append(data)
+			if err != nil {
+				return err
+			}
+			renumbered[offset] = did
+			written = next
+			return indexDocument(dir, whitelist, int32(did), meta)
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = w.Sync()
+	if err != nil {
+		return 0, err
+	}
+	err = fdc.SyncUnder(into)
+	if err != nil {
+		return 0, err
+	}
+
+	// keep the highest offset per partition of the removed documents too,
+	// the consumer must not push them again
+	ids, err := readIdLog(root)
+	if err != nil {
+		return 0, err
+	}
+	highest, entries, err := segmentPartitions(root)
+	if err != nil {
+		return 0, err
+	}
+	kept := []byte{}
+	for i := uint32(0); i < entries; i++ {
+		did, id := idEntry(ids, i)
+		if to, ok := renumbered[did]; ok {
+			entry := make([]byte, idEntrySize)
+			binary.LittleEndian.PutUint32(entry, to)
+			binary.LittleEndian.PutUint64(entry[4:], id)
+			kept = append(kept, entry...)
+		}
+	}
+	if len(kept) > 0 {
+		err = writeSynced(path.Join(into, "main.ids"), kept)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if len(highest) > 0 {
+		err = writePartitionSummary(into, uint32(len(kept)/idEntrySize), highest)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return removed, writeSynced(path.Join(into, "main.commit"), encodeCommit(written))
+}
+
+func writeSynced(fn string, data []byte) error {
+	f, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	cerr := f.Close()
+	if err != nil {
+		return err
+	}
+	return cerr
+}
+
+// puts a segment in order after an interrupted CompactSegment
+func finishCompaction(root string) error {
+	into := root + ".compact"
+	old := root + ".precompact"
+
+	if _, err := os.Stat(old); err == nil {
+		if _, err := os.Stat(root); os.IsNotExist(err) {
+			// interrupted between the renames, the compacted copy is complete
+			Log.Warnf("segment %s: finishing interrupted compaction", root)
+			err = os.Rename(into, root)
+			if err != nil {
+				return err
+			}
+		}
+		err = os.RemoveAll(old)
+		if err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(into)
+}
@@ -0,0 +1,290 @@
+package index
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	go_query_dsl "github.com/rekki/go-query-index-dsl"
+)
+
+func TestForgetAndCompact(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	si := NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	count := func(field, value string) int {
+		query := &spec.SearchQueryRequest{FromSecond: 1, ToSecond: 7199, Query: &go_query_dsl.Query{Field: field, Value: value}}
+		n := 0
+		_, err := si.ForEach(context.Background(), query, 0, func(s *Segment, did int32, score float32) error {
+			n++
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// user:1 and user:2 in two segments, and a search key named user
+	for _, second := range []int64{1, 3601} {
+		for i := 0; i < 100; i++ {
+			for _, id := range []string{"1", "2"} {
+				e := RandomEnvelope(second * 1000000000)
+				e.Metadata.ForeignType = "user"
+				e.Metadata.ForeignId = id
+				e.Metadata.Search = []spec.KV{{Key: "color", Value: "red"}}
+				err = si.Ingest(e)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		e := RandomEnvelope(second * 1000000000)
+		e.Metadata.Search = []spec.KV{{Key: "user", Value: "1"}}
+		err = si.Ingest(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := count("user", "1"); n != 202 {
+		t.Fatalf("expected 202 got %d", n)
+	}
+
+	n, segments, err := si.Forget(context.Background(), "user", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 200 || len(segments) != 2 || segments[0] != "0" || segments[1] != "1" {
+		t.Fatalf("expected 200 in segments 0 and 1, got %d %v", n, segments)
+	}
+	if n := count("user", "1"); n != 2 {
+		t.Fatalf("expected only the search key left, got %d", n)
+	}
+	if n := count("color", "red"); n != 200 {
+		t.Fatalf("expected 200 got %d", n)
+	}
+
+	n, _, err = si.Forget(context.Background(), "user", "1")
+	if err != nil || n != 0 {
+		t.Fatalf("expected nothing to forget, got %d %v", n, err)
+	}
+
+	// tombstones survive a restart
+	si.Close()
+	si = NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	if n := count("user", "1"); n != 2 {
+		t.Fatalf("expected 2 after reopening got %d", n)
+	}
+
+	before, err := os.Stat(path.Join(si.root, "0", "main.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, err := si.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed["0"] != 100 || removed["1"] != 100 {
+		t.Fatalf("expected 100 removed per segment, got %v", removed)
+	}
+	after, err := os.Stat(path.Join(si.root, "0", "main.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() >= before.Size() {
+		t.Fatalf("expected main.bin to shrink, %d -> %d", before.Size(), after.Size())
+	}
+	if _, err := os.Stat(path.Join(si.root, "0", deletedName)); !os.IsNotExist(err) {
+		t.Fatalf("expected no tombstones after compaction, got %v", err)
+	}
+
+	for _, q := range [][]string{{"user", "1"}, {"user", "2"}, {"color", "red"}, {"blackrock", "match_all"}} {
+		expected := map[string]int{"1": 2, "2": 200, "red": 200, "match_all": 202}[q[1]]
+		if n := count(q[0], q[1]); n != expected {
+			t.Fatalf("%s:%s expected %d got %d", q[0], q[1], expected, n)
+		}
+	}
+
+	removed, err = si.Compact()
+	if err != nil || len(removed) != 0 {
+		t.Fatalf("expected nothing to compact, got %v %v", removed, err)
+	}
+
+	// documents pushed after the compaction go after the renumbered ones
+	e := RandomEnvelope(1000000000)
+	e.Metadata.ForeignType = "user"
+	e.Metadata.ForeignId = "1"
+	err = si.Ingest(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := count("user", "1"); n != 3 {
+		t.Fatalf("expected 3 got %d", n)
+	}
+
+	report, err := CheckSegment(si.Segments["0"].root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	si.Close()
+	if !report.OK() {
+		t.Fatalf("expected clean segment, got %s", report)
+	}
+}
+
+func TestFinishInterruptedCompaction(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	si := NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	for i := 0; i < 10; i++ {
+		e := RandomEnvelope(1)
+		e.Metadata.ForeignType = "user"
+		e.Metadata.ForeignId = "1"
+		if i%2 == 0 {
+			e.Metadata.ForeignId = "2"
+		}
+		err = si.Ingest(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _, err = si.Forget(context.Background(), "user", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	si.Close()
+
+	// crash between the two renames of CompactSegment
+	segment := path.Join(si.root, "0")
+	err = os.Rename(segment, segment+".precompact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Mkdir(segment+".compact", 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := readTombstones(segment + ".precompact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = compactInto(segment+".precompact", segment+".compact", deleted.current(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	si = NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	defer si.Close()
+	if n := countMatching(t, si, "user", "2"); n != 5 {
+		t.Fatalf("expected 5 got %d", n)
+	}
+	if n := countMatching(t, si, "user", "1"); n != 0 {
+		t.Fatalf("expected 0 got %d", n)
+	}
+	for _, leftover := range []string{".precompact", ".compact"} {
+		if _, err := os.Stat(segment + leftover); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed, got %v", leftover, err)
+		}
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fn := path.Join(dir, "forget.log")
+	key := []byte("secret")
+	for i := 0; i < 2; i++ {
+		a, err := OpenAuditLog(fn, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashed, err := a.HashForeignId("1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = a.Append(&AuditRecord{Action: AuditForget, ForeignType: "user", ForeignIdHmac: hashed, Documents: 3, Segments: []string{"0"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.Close()
+	}
+
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	lines := 0
+	for scanner.Scan() {
+		r := &AuditRecord{}
+		err = json.Unmarshal(scanner.Bytes(), r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.ForeignIdHmac != HashForeignId(key, "1") || r.Documents != 3 || r.Time.IsZero() {
+			t.Fatalf("unexpected record %+v", r)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Fatalf("expected 2 records got %d", lines)
+	}
+
+	// another key does not give the same hash, no key can not hash at all
+	if HashForeignId([]byte("other"), "1") == HashForeignId(key, "1") {
+		t.Fatal("expected the hash to depend on the key")
+	}
+	a, err := OpenAuditLog(fn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if _, err := a.HashForeignId("1"); err != ErrNoAuditKey {
+		t.Fatalf("expected ErrNoAuditKey, got %v", err)
+	}
+}
+
+func TestForgottenRecord(t *testing.T) {
+	for size := 2; size < 300; size++ {
+		for _, id := range []uint64{0, 1<<partitionShift | 42} {
+			if id != 0 && size < 11 {
+				continue
+			}
+			data, err := forgottenRecord(size, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			meta := &spec.Metadata{}
+			err = proto.Unmarshal(data, meta)
+			if err != nil {
+				t.Fatalf("size %d: %s", size, err.Error())
+			}
+			if len(data) != size || meta.Id != id || meta.ForeignId != "" {
+				t.Fatalf("size %d: got %d bytes %+v", size, len(data), meta)
+			}
+		}
+	}
+	_, err := forgottenRecord(1, 0)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -1,6 +1,7 @@
 package index
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -15,7 +16,6 @@ import (
 
 	. "github.com/rekki/blackrock/pkg/logger"
 	iq "github.com/rekki/go-query"
-	dir "github.com/rekki/go-query-index"
 	dsl "github.com/rekki/go-query-index-dsl"
 )
 
@@ -25,11 +25,38 @@ type SearchIndex struct {
 	whitelist          map[string]bool
 	SegmentStep        int64
 	enableSegmentCache bool
-	fdCache            dir.FileDescriptorCache
+	fdCache            *FDCache
+
+	// set by Tenants, "" for the envelopes without tenant
+	Tenant string
+
+	// when more segments are loaded the least recently used idle ones are closed, 0 means no limit
+	MaxLoadedSegments int
+	loading           sync.Mutex
+
+	syncPolicy   SyncPolicy
+	syncInterval time.Duration
+
+	// guarded by loading
+	tiered *tieredStorage
+
+	// client supplied ids seen within the window are skipped, 0 disables it
+	DedupWindow time.Duration
+	recent      recentIds
+	partitions  partitionDedup
+
+	// set by Shutdown, guarded by the lock
+	closed bool
 	sync.RWMutex
 }
 
 func NewSearchIndex(root string, nOpenFD int, segmentStep int64, enableSegmentCache bool, whitelist map[string]bool) *SearchIndex {
+	return NewSearchIndexWithFDCache(root, NewFDCache(nOpenFD), segmentStep, enableSegmentCache, whitelist)
+}
+
+// NewSearchIndexWithFDCache shares the open file descriptors with other
+// indexes, so they have one limit
+func NewSearchIndexWithFDCache(root string, fdc *FDCache, segmentStep int64, enableSegmentCache bool, whitelist map[string]bool) *SearchIndex {
 	root = path.Join(root, fmt.Sprintf("%d", segmentStep))
 
 	err := os.MkdirAll(root, 0700)
@@ -37,7 +64,6 @@ func NewSearchIndex(root string, nOpenFD int, segmentStep int64, enableSegmentCa
 		Log.Fatal(err)
 	}
 
-	fdc := dir.NewFDCache(nOpenFD)
 	m := &SearchIndex{root: root, fdCache: fdc, Segments: map[string]*Segment{}, SegmentStep: segmentStep, enableSegmentCache: enableSegmentCache, whitelist: whitelist}
 
 	return m
@@ -49,9 +75,17 @@ func (m *SearchIndex) Ingest(envelope *spec.Envelope) error {
 		return err
 	}
 
-	err = m.holdWrite(envelope.Metadata.CreatedAtNs, func(segment *Segment) error {
+	if m.DedupWindow > 0 && envelope.Metadata.Id != 0 && m.recent.seenWithin(envelope.Metadata.Id, m.DedupWindow) {
+		Log.Debugf("skipping id: %d, seen in the last %s", envelope.Metadata.Id, m.DedupWindow)
+		return nil
+	}
+
+	err = m.hold(envelope.Metadata.CreatedAtNs, func(segment *Segment) error {
 		return segment.Ingest(envelope)
 	})
+	if err != nil && m.DedupWindow > 0 && envelope.Metadata.Id != 0 {
+		m.recent.unsee(envelope.Metadata.Id)
+	}
 	return err
 }
 
@@ -64,6 +98,8 @@ func (m *SearchIndex) Close() {
 		s.Close()
 		delete(m.Segments, k)
 	}
+	// the cache can be shared with other indexes
+	m.fdCache.CloseUnder(m.root)
 }
 func (m *SearchIndex) toSegmentId(ns int64) string {
 	s := ns / 1000000000
@@ -106,78 +142,270 @@ func (m *SearchIndex) LookupSingleSegment(ns int64) *Segment {
 
 func (m *SearchIndex) loadSegmentFromDisk(segmentId string) (*Segment, error) {
 	p := path.Join(m.root, segmentId)
-	segment, err := NewSegment(p, m.fdCache, m.enableSegmentCache, m.whitelist)
+	err := finishCompaction(p)
+	if err != nil {
+		return nil, err
+	}
+	err = m.fetchSegment(segmentId, p)
 	if err != nil {
 		return nil, err
 	}
+
+	segment, err := NewSegment(p, m.fdCache, m.enableSegmentCache, m.whitelist, m.syncPolicy, m.syncInterval)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path.Join(p, remoteMarkerName)); err == nil {
+		segment.fetched = true
+	}
 	return segment, nil
 }
 
-func (m *SearchIndex) holdRead(step int64, cb func(s *Segment) error) error {
+// hold a reference to the segment while cb runs, so it can not be
+// evicted and closed underneath the callback
+func (m *SearchIndex) hold(step int64, cb func(s *Segment) error) error {
 	segmentId := m.toSegmentId(step)
 
-	m.RLock()
+	segment, err := m.acquireLoaded(segmentId)
+	if err != nil {
+		return err
+	}
+	if segment == nil {
+		// load one segment at a time, opening it might have to recover
+		// interrupted writes and that can not run twice on the same files
+		m.loading.Lock()
+		segment, err = m.acquireLoaded(segmentId)
+		if err != nil {
+			m.loading.Unlock()
+			return err
+		}
+		if segment == nil {
+			loaded, err := m.loadSegmentFromDisk(segmentId)
+			if err != nil {
+				m.loading.Unlock()
+				return err
+			}
+
+			m.Lock()
+			if m.closed {
+				m.Unlock()
+				m.loading.Unlock()
+				loaded.Close()
+				return ErrShuttingDown
+			}
+			m.Segments[segmentId] = loaded
+			loaded.acquire()
+			evicted := m.evictLocked(m.MaxLoadedSegments, 0)
+			m.Unlock()
+
+			closeSegments(evicted)
+			m.trimCache()
+			segment = loaded
+		}
+		m.loading.Unlock()
+	}
+
+	defer segment.release()
+	return cb(segment)
+}
 
-	var err error
+func (m *SearchIndex) acquireLoaded(segmentId string) (*Segment, error) {
+	m.RLock()
+	defer m.RUnlock()
 
+	if m.closed {
+		return nil, ErrShuttingDown
+	}
 	segment, ok := m.Segments[segmentId]
 	if !ok {
-		m.RUnlock()
+		return nil, nil
+	}
+	segment.acquire()
+	return segment, nil
+}
 
-		// RACE (multiple load)
+var ErrShuttingDown = errors.New("search index is shutting down")
 
-		segment, err = m.loadSegmentFromDisk(segmentId)
-		if err != nil {
-			return err
-		}
+// Shutdown refuses new reads and writes, waits up to timeout for the
+// ones in progress and then syncs and closes every segment. Segments
+// still in use after the timeout are left open and reported.
+func (m *SearchIndex) Shutdown(timeout time.Duration) error {
+	m.Lock()
+	m.closed = true
+	m.Unlock()
 
-		m.Lock()
+	// nothing is being loaded after that
+	m.loading.Lock()
+	defer m.loading.Unlock()
 
-		overriden, ok := m.Segments[segmentId]
-		if ok {
-			segment.Close()
-			segment = overriden
-		} else {
-			m.Segments[segmentId] = segment
+	deadline := time.Now().Add(timeout)
+	for {
+		busy := []string{}
+		m.RLock()
+		for id, s := range m.Segments {
+			if !s.isIdle() {
+				busy = append(busy, id)
+			}
 		}
+		m.RUnlock()
 
-		m.Unlock()
+		if len(busy) == 0 || time.Now().After(deadline) {
+			m.Lock()
+			closing := []*Segment{}
+			for id, s := range m.Segments {
+				if s.isIdle() {
+					closing = append(closing, s)
+					delete(m.Segments, id)
+				}
+			}
+			m.Unlock()
 
-		m.RLock()
+			for _, s := range closing {
+				s.Close()
+			}
+			if len(busy) > 0 {
+				sort.Strings(busy)
+				return fmt.Errorf("segments %v are still in use after %s", busy, timeout)
+			}
+			Log.Infof("closed %d segments", len(closing))
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
 	}
-
-	err = cb(segment)
-	m.RUnlock()
-	return err
 }
 
-func (m *SearchIndex) holdWrite(step int64, cb func(s *Segment) error) error {
-	segmentId := m.toSegmentId(step)
+// SetSyncPolicy applies to segments loaded after the call
+func (m *SearchIndex) SetSyncPolicy(policy SyncPolicy, interval time.Duration) {
 	m.Lock()
 	defer m.Unlock()
-	var err error
-	segment, ok := m.Segments[segmentId]
-	if !ok {
-		segment, err = m.loadSegmentFromDisk(segmentId)
+
+	m.syncPolicy = policy
+	m.syncInterval = interval
+
+	m.fdCache.Lock()
+	m.fdCache.SyncOnEvict = policy != SyncOnClose
+	m.fdCache.Unlock()
+}
+
+// Sync fsyncs all loaded segments, used with SyncInterval so the
+// last writes are not left behind when there is no more traffic
+func (m *SearchIndex) Sync() error {
+	m.RLock()
+	segments := []*Segment{}
+	for _, s := range m.Segments {
+		s.acquire()
+		segments = append(segments, s)
+	}
+	m.RUnlock()
+
+	var lastError error
+	for _, s := range segments {
+		err := s.Sync()
 		if err != nil {
-			return err
+			lastError = err
 		}
-		m.Segments[segmentId] = segment
+		s.release()
 	}
+	return lastError
+}
 
-	return cb(segment)
+// EvictIdle closes and drops segments that nobody holds and were not
+// accessed in the last maxIdle, returns how many were evicted
+func (m *SearchIndex) EvictIdle(maxIdle time.Duration) int {
+	// closing has to finish before the same segment can be loaded again
+	m.loading.Lock()
+	defer m.loading.Unlock()
+
+	m.Lock()
+	evicted := m.evictLocked(0, maxIdle)
+	m.Unlock()
+
+	closeSegments(evicted)
+	return len(evicted)
+}
+
+// must be called with the write lock held, the returned segments are
+// already removed from m.Segments and have to be closed by the caller
+func (m *SearchIndex) evictLocked(maxLoaded int, maxIdle time.Duration) []*Segment {
+	if maxLoaded > 0 && len(m.Segments) <= maxLoaded {
+		return nil
+	}
+	if maxLoaded <= 0 && maxIdle <= 0 {
+		return nil
+	}
+
+	ids := []string{}
+	for id, s := range m.Segments {
+		if s.isIdle() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return m.Segments[ids[i]].LastAccess().Before(m.Segments[ids[j]].LastAccess())
+	})
+
+	now := time.Now()
+	evicted := []*Segment{}
+	for _, id := range ids {
+		s := m.Segments[id]
+		if maxLoaded > 0 && len(m.Segments) <= maxLoaded {
+			break
+		}
+		if maxIdle > 0 && now.Sub(s.LastAccess()) < maxIdle {
+			break
+		}
+		delete(m.Segments, id)
+		evicted = append(evicted, s)
+	}
+	return evicted
+}
+
+func closeSegments(segments []*Segment) {
+	for _, s := range segments {
+		Log.Infof("evicting segment %s, last access: %s", s.root, s.LastAccess())
+		s.Close()
+	}
 }
 
 var errBadRequest = errors.New("missing Query")
 
-func (m *SearchIndex) ForEach(qr *spec.SearchQueryRequest, limit uint32, cb func(*Segment, int32, float32) error) error {
+// how many documents between checks of the context and of the time budget
+const checkEvery = 1024
+
+var errStop = errors.New("stop")
+
+// ForEach calls cb for every document matching the query, at most limit
+// times when limit is not 0. The context is checked between segments and
+// every checkEvery documents, when it is done ForEach returns its error.
+// qr.MaxDocs and qr.MaxTimeMs stop the query early, it returns true when
+// they did and there were more documents to look at.
+func (m *SearchIndex) ForEach(ctx context.Context, qr *spec.SearchQueryRequest, limit uint32, cb func(*Segment, int32, float32) error) (bool, error) {
 	steps := m.ExpandFromTo(qr.FromSecond, qr.ToSecond)
 	if qr.Query == nil {
-		return errBadRequest
+		return false, errBadRequest
 	}
 
+	var deadline time.Time
+	if qr.MaxTimeMs > 0 {
+		deadline = time.Now().Add(time.Duration(qr.MaxTimeMs) * time.Millisecond)
+	}
+	overTime := func() bool {
+		return !deadline.IsZero() && time.Now().After(deadline)
+	}
+
+	partial := false
+	docs := uint32(0)
 	for _, step := range steps {
-		err := m.holdRead(step, func(segment *Segment) error {
+		err := ctx.Err()
+		if err != nil {
+			return false, err
+		}
+		if overTime() {
+			return true, nil
+		}
+
+		err = m.hold(step, func(segment *Segment) error {
+			segment.invLock.RLock()
 			query, err := dsl.Parse(qr.Query, func(k, v string) iq.Query {
 				if len(k) == 0 || len(v) == 0 {
 					return iq.Term(1, k+":"+v, []int32{})
@@ -189,13 +417,34 @@ func (m *SearchIndex) ForEach(qr *spec.SearchQueryRequest, limit uint32, cb func
 					return iq.Or(queries...)
 				}
 			})
+			segment.invLock.RUnlock()
 			if err != nil {
 				return err
 			}
+			deleted := segment.deleted.current()
 
 			// no need to lock the segment after that because its used only to get data from the forward index
 			for query.Next() != iq.NO_MORE {
 				did := query.GetDocId()
+				if isDeleted(deleted, did) {
+					continue
+				}
+				if qr.MaxDocs > 0 && docs >= qr.MaxDocs {
+					partial = true
+					return errStop
+				}
+				docs++
+				if docs%checkEvery == 0 {
+					err = ctx.Err()
+					if err != nil {
+						return err
+					}
+					if overTime() {
+						partial = true
+						return errStop
+					}
+				}
+
 				score := query.Score()
 				err = cb(segment, did, score)
 				if err != nil {
@@ -204,18 +453,21 @@ func (m *SearchIndex) ForEach(qr *spec.SearchQueryRequest, limit uint32, cb func
 				if limit > 0 {
 					limit--
 					if limit == 0 {
-						return nil
+						return errStop
 					}
 				}
 			}
 			return nil
 		})
+		if err == errStop {
+			return partial, nil
+		}
 		if err != nil {
-			return err
+			return false, err
 		}
 	}
 
-	return nil
+	return false, nil
 }
 
 func (m *SearchIndex) ExpandFromTo(from uint32, to uint32) []int64 {
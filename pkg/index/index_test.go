@@ -1,6 +1,7 @@
 package index
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"math/rand"
@@ -90,7 +91,7 @@ func TestSearchCache(t *testing.T) {
 		}
 		for i := 0; i < 10; i++ {
 			matching := uint64(0)
-			err = si.ForEach(query, 0, func(s *Segment, did int32, score float32) error {
+			_, err = si.ForEach(context.Background(), query, 0, func(s *Segment, did int32, score float32) error {
 				m := &spec.Metadata{}
 				err := s.ReadForwardDecode(did, m)
 				if err != nil {
@@ -146,7 +147,7 @@ func TestSearchIndexBasic(t *testing.T) {
 	query := &spec.SearchQueryRequest{FromSecond: 1, ToSecond: 7200, Query: &go_query_dsl.Query{Field: "blackrock", Value: "match_all"}}
 
 	matching := uint64(0)
-	err = si.ForEach(query, 0, func(s *Segment, did int32, score float32) error {
+	_, err = si.ForEach(context.Background(), query, 0, func(s *Segment, did int32, score float32) error {
 		matching++
 		return nil
 	})
@@ -165,14 +166,14 @@ func TestSearchIndexBasic(t *testing.T) {
 		}
 	}
 
-	err = si.ForEach(&spec.SearchQueryRequest{FromSecond: 1, ToSecond: 7200, Query: nil}, 0, func(s *Segment, did int32, score float32) error {
+	_, err = si.ForEach(context.Background(), &spec.SearchQueryRequest{FromSecond: 1, ToSecond: 7200, Query: nil}, 0, func(s *Segment, did int32, score float32) error {
 		return nil
 	})
 	if err != errBadRequest {
 		t.Fatal("expected errBadRequest")
 	}
 
-	err = si.ForEach(query, 0, func(s *Segment, did int32, score float32) error {
+	_, err = si.ForEach(context.Background(), query, 0, func(s *Segment, did int32, score float32) error {
 		matching++
 		return nil
 	})
@@ -188,7 +189,7 @@ func TestSearchIndexBasic(t *testing.T) {
 
 	matching = uint64(0)
 	si = NewSearchIndex(root, 10, 3600, true, map[string]bool{})
-	err = si.ForEach(query, 0, func(s *Segment, did int32, score float32) error {
+	_, err = si.ForEach(context.Background(), query, 0, func(s *Segment, did int32, score float32) error {
 		atomic.AddUint64(&matching, 1)
 		b, err := s.ReadForward(did)
 		if err != nil {
@@ -210,7 +211,7 @@ func TestSearchIndexBasic(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = si.ForEach(&spec.SearchQueryRequest{FromSecond: 1, ToSecond: 7200, Query: &go_query_dsl.Query{Field: "blackrock", Value: "not_existing"}}, 0, func(s *Segment, did int32, score float32) error {
+	_, err = si.ForEach(context.Background(), &spec.SearchQueryRequest{FromSecond: 1, ToSecond: 7200, Query: &go_query_dsl.Query{Field: "blackrock", Value: "not_existing"}}, 0, func(s *Segment, did int32, score float32) error {
 		t.Fatal("should not exist")
 		return nil
 	})
@@ -218,7 +219,7 @@ func TestSearchIndexBasic(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = si.ForEach(&spec.SearchQueryRequest{FromSecond: 1, ToSecond: 7200, Query: &go_query_dsl.Query{Field: "not_existing", Value: "not_existing"}}, 0, func(s *Segment, did int32, score float32) error {
+	_, err = si.ForEach(context.Background(), &spec.SearchQueryRequest{FromSecond: 1, ToSecond: 7200, Query: &go_query_dsl.Query{Field: "not_existing", Value: "not_existing"}}, 0, func(s *Segment, did int32, score float32) error {
 		t.Fatal("should not exist")
 		return nil
 	})
@@ -227,7 +228,7 @@ func TestSearchIndexBasic(t *testing.T) {
 	}
 
 	// nothing should be found now
-	err = si.ForEach(&spec.SearchQueryRequest{FromSecond: 0, ToSecond: 0, Query: &go_query_dsl.Query{Field: "blackrock", Value: "match_all"}}, 0, func(s *Segment, did int32, score float32) error {
+	_, err = si.ForEach(context.Background(), &spec.SearchQueryRequest{FromSecond: 0, ToSecond: 0, Query: &go_query_dsl.Query{Field: "blackrock", Value: "match_all"}}, 0, func(s *Segment, did int32, score float32) error {
 		t.Fatal("should not exist")
 		return nil
 	})
@@ -241,7 +242,7 @@ func TestSearchIndexBasic(t *testing.T) {
 
 	shouldStop := 0
 	expectedError := errors.New("NOOOOOO")
-	err = si.ForEach(query, 0, func(s *Segment, did int32, score float32) error {
+	_, err = si.ForEach(context.Background(), query, 0, func(s *Segment, did int32, score float32) error {
 		shouldStop++
 		return expectedError
 	})
@@ -254,7 +255,7 @@ func TestSearchIndexBasic(t *testing.T) {
 
 	limit := 10
 	count := 0
-	err = si.ForEach(query, uint32(limit), func(s *Segment, did int32, score float32) error {
+	_, err = si.ForEach(context.Background(), query, uint32(limit), func(s *Segment, did int32, score float32) error {
 		count++
 		return nil
 	})
@@ -325,7 +326,7 @@ func TestConcurrentReadAndWrite(t *testing.T) {
 		go func() {
 			for i := 0; i < 10000; i++ {
 
-				err = si.ForEach(query, 10, func(s *Segment, did int32, score float32) error {
+				_, err = si.ForEach(context.Background(), query, 10, func(s *Segment, did int32, score float32) error {
 					m := &spec.Metadata{}
 
 					err := s.ReadForwardDecode(did, m)
@@ -351,7 +352,7 @@ func TestConcurrentReadAndWrite(t *testing.T) {
 	}
 
 	found := uint64(0)
-	err = si.ForEach(query, 0, func(s *Segment, did int32, score float32) error {
+	_, err = si.ForEach(context.Background(), query, 0, func(s *Segment, did int32, score float32) error {
 		m := &spec.Metadata{}
 		err := s.ReadForwardDecode(did, m)
 		if err != nil {
@@ -372,6 +373,69 @@ func TestConcurrentReadAndWrite(t *testing.T) {
 	si.Close()
 }
 
+func TestSegmentEviction(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	si := NewSearchIndex(root, 10, 3600, true, map[string]bool{})
+	si.MaxLoadedSegments = 2
+
+	hours := 5
+	perHour := 100
+	for h := 0; h < hours; h++ {
+		for i := 0; i < perHour; i++ {
+			err = si.Ingest(RandomEnvelope(1 + int64(h)*3600*1e9))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if len(si.Segments) > si.MaxLoadedSegments {
+		t.Fatalf("expected at most %d loaded segments, got %d", si.MaxLoadedSegments, len(si.Segments))
+	}
+
+	query := &spec.SearchQueryRequest{FromSecond: 1, ToSecond: uint32(hours * 3600), Query: &go_query_dsl.Query{Field: "blackrock", Value: "match_all"}}
+	matching := 0
+	_, err = si.ForEach(context.Background(), query, 0, func(s *Segment, did int32, score float32) error {
+		// loading other segments while this one is held must not close it
+		err := si.hold(int64(hours+1)*3600*1e9, func(*Segment) error { return nil })
+		if err != nil {
+			return err
+		}
+
+		m := &spec.Metadata{}
+		err = s.ReadForwardDecode(did, m)
+		if err != nil {
+			return err
+		}
+		matching++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matching != hours*perHour {
+		t.Fatalf("expected %d got %d", hours*perHour, matching)
+	}
+
+	if n := si.EvictIdle(time.Hour); n != 0 {
+		t.Fatalf("expected nothing to be evicted, got %d", n)
+	}
+	loaded := len(si.Segments)
+	if n := si.EvictIdle(time.Nanosecond); n != loaded {
+		t.Fatalf("expected %d evicted, got %d", loaded, n)
+	}
+	if len(si.Segments) != 0 {
+		t.Fatal("expected no loaded segments")
+	}
+
+	si.Close()
+}
+
 var dontOptimizeMe = 0
 
 func BenchmarkIngest1000(b *testing.B) {
@@ -421,7 +485,7 @@ func BenchmarkSearch1000000(b *testing.B) {
 	b.StartTimer()
 	for i := 0; i < b.N; i++ {
 		matching := 0
-		_ = si.ForEach(query, 0, func(s *Segment, did int32, score float32) error {
+		_, _ = si.ForEach(context.Background(), query, 0, func(s *Segment, did int32, score float32) error {
 			matching++
 			dontOptimizeMe++
 			return nil
@@ -457,7 +521,7 @@ func BenchmarkSearchDecode1000000(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		matching := 0
 		m := spec.BasicMetadata{}
-		_ = si.ForEach(query, 0, func(s *Segment, did int32, score float32) error {
+		_, _ = si.ForEach(context.Background(), query, 0, func(s *Segment, did int32, score float32) error {
 			matching++
 			err := s.ReadForwardDecode(did, &m)
 			if err != nil {
@@ -500,7 +564,7 @@ func BenchmarkSearchRead1000000(b *testing.B) {
 	b.StartTimer()
 	for i := 0; i < b.N; i++ {
 		matching := 0
-		_ = si.ForEach(query, 0, func(s *Segment, did int32, score float32) error {
+		_, _ = si.ForEach(context.Background(), query, 0, func(s *Segment, did int32, score float32) error {
 			matching++
 			b, err := s.ReadForward(did)
 			if err != nil {
@@ -541,7 +605,7 @@ func BenchmarkSearchRead10(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		matching := 0
 		m := spec.BasicMetadata{}
-		_ = si.ForEach(query, 10, func(s *Segment, did int32, score float32) error {
+		_, _ = si.ForEach(context.Background(), query, 10, func(s *Segment, did int32, score float32) error {
 			matching++
 			err := s.ReadForwardDecode(did, &m)
 			if err != nil {
@@ -560,3 +624,149 @@ func BenchmarkSearchRead10(b *testing.B) {
 	os.RemoveAll(root)
 
 }
+
+func TestShutdown(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	si := NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	for i := 0; i < 10; i++ {
+		err := si.Ingest(RandomEnvelope(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	busy := make(chan bool)
+	done := make(chan error)
+	go func() {
+		done <- si.hold(1, func(s *Segment) error {
+			busy <- true
+			time.Sleep(50 * time.Millisecond)
+			return s.Ingest(RandomEnvelope(1))
+		})
+	}()
+	<-busy
+
+	err = si.Shutdown(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = <-done
+	if err != nil {
+		t.Fatalf("the write in progress should finish, got %s", err.Error())
+	}
+	if err := si.Ingest(RandomEnvelope(1)); err != ErrShuttingDown {
+		t.Fatalf("expected ErrShuttingDown, got %v", err)
+	}
+	if len(si.Segments) != 0 {
+		t.Fatalf("expected all segments closed, got %d", len(si.Segments))
+	}
+
+	si = NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	defer si.Close()
+	if n := len(documents(t, si)); n != 11 {
+		t.Fatalf("expected 11 documents, got %d", n)
+	}
+
+	// a segment that stays in use is reported and left open
+	s, err := si.acquireLoaded("0")
+	if err != nil || s == nil {
+		t.Fatalf("expected a loaded segment, got %v %v", s, err)
+	}
+	err = si.Shutdown(20 * time.Millisecond)
+	if err == nil {
+		t.Fatal("expected an error for the busy segment")
+	}
+	s.release()
+}
+
+func TestForEachBudget(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	si := NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	defer si.Close()
+	// two segments of 1500
+	for _, createdAt := range []int64{1, 3600*1000000000 + 1} {
+		for _, e := range RandomEnvelopes(1500, createdAt) {
+			err = si.Ingest(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	count := func(ctx context.Context, qr *spec.SearchQueryRequest, limit uint32) (int, bool, error) {
+		matching := 0
+		partial, err := si.ForEach(ctx, qr, limit, func(s *Segment, did int32, score float32) error {
+			matching++
+			return nil
+		})
+		return matching, partial, err
+	}
+	query := func() *spec.SearchQueryRequest {
+		return &spec.SearchQueryRequest{FromSecond: 1, ToSecond: 7200, Query: &go_query_dsl.Query{Field: "blackrock", Value: "match_all"}}
+	}
+
+	n, partial, err := count(context.Background(), query(), 0)
+	if err != nil || n != 3000 || partial {
+		t.Fatalf("expected 3000 complete, got %d %v %v", n, partial, err)
+	}
+
+	// the limit holds across segments and is not partial
+	n, partial, err = count(context.Background(), query(), 10)
+	if err != nil || n != 10 || partial {
+		t.Fatalf("expected 10 complete, got %d %v %v", n, partial, err)
+	}
+
+	qr := query()
+	qr.MaxDocs = 2000
+	n, partial, err = count(context.Background(), qr, 0)
+	if err != nil || n != 2000 || !partial {
+		t.Fatalf("expected 2000 partial, got %d %v %v", n, partial, err)
+	}
+
+	qr.MaxDocs = 3000
+	n, partial, err = count(context.Background(), qr, 0)
+	if err != nil || n != 3000 || partial {
+		t.Fatalf("expected 3000 complete, got %d %v %v", n, partial, err)
+	}
+
+	qr = query()
+	qr.MaxTimeMs = 1
+	partial, err = si.ForEach(context.Background(), qr, 0, func(s *Segment, did int32, score float32) error {
+		time.Sleep(10 * time.Microsecond)
+		return nil
+	})
+	if err != nil || !partial {
+		t.Fatalf("expected partial, got %v %v", partial, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = count(ctx, query(), 0)
+	if err != context.Canceled {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+
+	// canceled while iterating
+	ctx, cancel = context.WithCancel(context.Background())
+	n = 0
+	_, err = si.ForEach(ctx, query(), 0, func(s *Segment, did int32, score float32) error {
+		n++
+		if n == 100 {
+			cancel()
+		}
+		return nil
+	})
+	if err != context.Canceled || n != checkEvery-1 {
+		t.Fatalf("expected canceled after %d, got %d %v", checkEvery-1, n, err)
+	}
+}
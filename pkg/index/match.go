@@ -0,0 +1,34 @@
+package index
+
+import (
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	iq "github.com/rekki/go-query"
+	mem "github.com/rekki/go-query-index"
+	dsl "github.com/rekki/go-query-index-dsl"
+)
+
+// Matches tells if a document with this metadata would match the query
+// once indexed, without the automatic year tags added by PrepareEnvelope
+func Matches(query *dsl.Query, meta *spec.Metadata) (bool, error) {
+	if query == nil {
+		return false, errBadRequest
+	}
+
+	m := mem.NewMemOnlyIndex(nil)
+	m.Index(&Indexable{data: indexableFields(nil, meta)})
+
+	q, err := dsl.Parse(query, func(k, v string) iq.Query {
+		if len(k) == 0 || len(v) == 0 {
+			return iq.Term(1, k+":"+v, []int32{})
+		}
+		queries := m.Terms(k, v)
+		if len(queries) == 1 {
+			return queries[0]
+		}
+		return iq.Or(queries...)
+	})
+	if err != nil {
+		return false, err
+	}
+	return q.Next() != iq.NO_MORE, nil
+}
@@ -14,6 +14,16 @@ var errMissingMetadata = errors.New("missing metadata")
 var errMissingForeignType = errors.New("missing foreign_type")
 var errMissingEventType = errors.New("missing event_type")
 
+// IsInvalidEnvelope tells if the error is PrepareEnvelope rejecting the
+// envelope, sending it again will fail the same way
+func IsInvalidEnvelope(err error) bool {
+	switch err {
+	case errMissingMetadata, errMissingForeignId, errMissingForeignType, errMissingEventType:
+		return true
+	}
+	return false
+}
+
 func PrepareEnvelope(envelope *spec.Envelope) error {
 	meta := envelope.Metadata
 	if meta == nil {
@@ -0,0 +1,100 @@
+package index
+
+import (
+	"os"
+	"path"
+	"time"
+
+	. "github.com/rekki/blackrock/pkg/logger"
+)
+
+// Reindex rebuilds the inverted index of a segment from its forward index
+// with the current whitelist. The old inverted index keeps serving queries
+// until the new one is complete, then they are swapped while ingestion
+// into this segment is paused.
+func (s *Segment) Reindex() (int, error) {
+	s.reindexing.Lock()
+	defer s.reindexing.Unlock()
+
+	s.Lock()
+	upTo := s.written
+	s.Unlock()
+
+	rebuilt := path.Join(s.root, "inv.rebuild")
+	builder, err := newInvertedBuilder(s.root, rebuilt, s.whitelist)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := builder.add(0, upTo)
+	if err != nil {
+		builder.abort()
+		return 0, err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	err = s.detachRemoteLocked()
+	if err != nil {
+		builder.abort()
+		return 0, err
+	}
+
+	// catch up with what was ingested while building
+	caught, err := builder.add(upTo, s.written)
+	if err != nil {
+		builder.abort()
+		return 0, err
+	}
+	n += caught
+
+	err = builder.finish()
+	if err != nil {
+		_ = os.RemoveAll(rebuilt)
+		return 0, err
+	}
+
+	s.invLock.Lock()
+	defer s.invLock.Unlock()
+
+	// the cached descriptors point to the files of the old inverted index
+	err = s.fdc.SyncUnder(s.root)
+	if err != nil {
+		return 0, err
+	}
+	s.fdc.CloseUnder(s.root)
+
+	err = swapInverted(s.root, rebuilt)
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Reindex rebuilds all segments stored on disk between from and to
+// (unix seconds), returns the number of documents per segment id
+func (m *SearchIndex) Reindex(from, to uint32) (map[string]uint32, error) {
+	segments, err := m.listSegmentsBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]uint32{}
+	for _, ns := range segments {
+		err = m.hold(ns, func(s *Segment) error {
+			started := time.Now()
+			n, err := s.Reindex()
+			if err != nil {
+				return err
+			}
+			Log.Infof("segment %s: reindexed %d documents in %s", s.root, n, time.Since(started))
+			out[m.toSegmentId(ns)] = uint32(n)
+			return nil
+		})
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
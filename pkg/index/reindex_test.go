@@ -0,0 +1,79 @@
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+)
+
+func TestReindexWithNewWhitelist(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	envelope := func() *spec.Envelope {
+		e := RandomEnvelope(1)
+		e.Metadata.Search = []spec.KV{{Key: "a", Value: "x"}, {Key: "b", Value: "y"}}
+		return e
+	}
+
+	si := NewSearchIndex(root, 10, 3600, false, map[string]bool{"a": true})
+	inserted := 1000
+	for i := 0; i < inserted; i++ {
+		err = si.Ingest(envelope())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := countMatching(t, si, "b", "y"); n != 0 {
+		t.Fatalf("expected b:y not to be indexed, got %d", n)
+	}
+	si.Close()
+
+	si = NewSearchIndex(root, 10, 3600, false, map[string]bool{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			err := si.Ingest(envelope())
+			if err != nil {
+				panic(err)
+			}
+			if n := countMatching(t, si, "a", "x"); n < inserted {
+				panic("queries must keep working while reindexing")
+			}
+		}
+	}()
+
+	documents, err := si.Reindex(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	if documents["0"] < uint32(inserted) {
+		t.Fatalf("expected at least %d reindexed, got %v", inserted, documents)
+	}
+
+	total := inserted + 100
+	for _, q := range [][]string{{"a", "x"}, {"b", "y"}, {"blackrock", "match_all"}} {
+		if n := countMatching(t, si, q[0], q[1]); n != total {
+			t.Fatalf("%s:%s expected %d got %d", q[0], q[1], total, n)
+		}
+	}
+
+	report, err := CheckSegment(si.Segments["0"].root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	si.Close()
+	if !report.OK() {
+		t.Fatalf("expected clean segment, got %s", report)
+	}
+}
@@ -0,0 +1,257 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	pen "github.com/rekki/go-pen"
+)
+
+// A follower copies the forward index of the leader record by record, in
+// the same order, so document ids are the same on both sides and the
+// follower resumes from the committed end of its own segments. Only
+// committed records are sent, with SyncInterval the lag is at least the
+// fsync interval.
+
+var replicationPollInterval = 200 * time.Millisecond
+
+// how far a segment can be read safely
+func (m *SearchIndex) committedOffset(segmentId string) (uint32, error) {
+	m.RLock()
+	s, ok := m.Segments[segmentId]
+	m.RUnlock()
+	if ok {
+		s.Lock()
+		defer s.Unlock()
+		return s.committed, nil
+	}
+	return segmentPosition(path.Join(m.root, segmentId))
+}
+
+func segmentPosition(root string) (uint32, error) {
+	f, err := os.Open(path.Join(root, "main.commit"))
+	if err == nil {
+		committed, ok := readCommit(f)
+		f.Close()
+		if ok {
+			return committed, nil
+		}
+	}
+	// written before the commit marker existed
+	return forwardEnd(path.Join(root, "main.bin"))
+}
+
+// Positions returns the committed end of every local segment, used by a
+// follower to resume replication
+func (m *SearchIndex) Positions() (map[string]uint32, error) {
+	segments, err := m.ListSegments()
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]uint32{}
+	for _, ns := range segments {
+		segmentId := m.toSegmentId(ns)
+		committed, err := m.committedOffset(segmentId)
+		if err != nil {
+			return nil, err
+		}
+		out[segmentId] = committed
+	}
+	return out, nil
+}
+
+// Tail sends every committed record after positions of the segments
+// since from (unix seconds), then keeps polling for new records until
+// ctx is done. A heartbeat with the pending bytes is sent every poll.
+func (m *SearchIndex) Tail(ctx context.Context, positions map[string]uint32, from uint32, cb func(*spec.ReplicatedEnvelope) error) error {
+	sent := map[string]uint32{}
+	for k, v := range positions {
+		sent[k] = v
+	}
+	// bytes of main.deleted sent, all of it after a reconnect, the
+	// follower skips the ids it has
+	sentDeleted := map[string]int64{}
+
+	for {
+		segments, err := m.listSegmentsBetween(from, 0)
+		if err != nil {
+			return err
+		}
+
+		todo := []string{}
+		committed := map[string]uint32{}
+		pending := uint64(0)
+		for _, ns := range segments {
+			segmentId := m.toSegmentId(ns)
+			c, err := m.committedOffset(segmentId)
+			if err != nil {
+				return err
+			}
+			if c < sent[segmentId] {
+				return fmt.Errorf("segment %s: follower is at %d but the leader committed only %d, the replicas diverged", segmentId, sent[segmentId], c)
+			}
+			if c > sent[segmentId] {
+				todo = append(todo, segmentId)
+				committed[segmentId] = c
+				pending += uint64(c-sent[segmentId]) * uint64(pen.PAD)
+			}
+		}
+
+		err = cb(&spec.ReplicatedEnvelope{LeaderTimeNs: time.Now().UnixNano(), PendingBytes: pending})
+		if err != nil {
+			return err
+		}
+
+		for _, ns := range segments {
+			segmentId := m.toSegmentId(ns)
+			dids, end, err := readTombstonesSince(path.Join(m.root, segmentId), sentDeleted[segmentId])
+			if err != nil {
+				return fmt.Errorf("segment %s: %s, restore the follower from a snapshot", segmentId, err.Error())
+			}
+			if len(dids) > 0 {
+				err = cb(&spec.ReplicatedEnvelope{Segment: segmentId, Tombstones: dids})
+				if err != nil {
+					return err
+				}
+			}
+			sentDeleted[segmentId] = end
+		}
+
+		for _, segmentId := range todo {
+			root := path.Join(m.root, segmentId)
+			partitionIds, err := partitionIdDocuments(root, sent[segmentId], committed[segmentId])
+			if err != nil {
+				return err
+			}
+			deleted, err := readTombstones(root)
+			if err != nil {
+				return err
+			}
+			bits := deleted.current()
+			err = readRecords(path.Join(root, "main.bin"), sent[segmentId], committed[segmentId], func(offset uint32, data []byte) error {
+				e := &spec.ReplicatedEnvelope{Segment: segmentId, Offset: offset, Data: data, PartitionId: partitionIds[offset]}
+				if isDeleted(bits, int32(offset)) {
+					err := forgetRecord(e)
+					if err != nil {
+						return fmt.Errorf("segment %s: record at %d: %s", segmentId, offset, err.Error())
+					}
+				}
+				return cb(e)
+			})
+			if err != nil {
+				return err
+			}
+			sent[segmentId] = committed[segmentId]
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(replicationPollInterval):
+		}
+	}
+}
+
+func readRecords(fn string, from, to uint32, cb func(offset uint32, data []byte) error) error {
+	f, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	offset := from
+	for offset < to {
+		data, next, err := pen.ReadFromReader(f, offset, 16)
+		if err != nil {
+			return fmt.Errorf("%s: failed to read record at %d: %s", fn, offset, err.Error())
+		}
+		err = cb(offset, data)
+		if err != nil {
+			return err
+		}
+		offset = next
+	}
+	return nil
+}
+
+// replaces the data of a forgotten record with a placeholder
+func forgetRecord(e *spec.ReplicatedEnvelope) error {
+	id := uint64(0)
+	if e.PartitionId {
+		meta := &spec.Metadata{}
+		err := proto.Unmarshal(e.Data, meta)
+		if err != nil {
+			return err
+		}
+		id = meta.Id
+	}
+	data, err := forgottenRecord(len(e.Data), id)
+	if err != nil {
+		return err
+	}
+	e.Data = data
+	e.Deleted = true
+	return nil
+}
+
+// ApplyReplicated appends a record received from the leader, records the
+// segment already has are skipped
+func (m *SearchIndex) ApplyReplicated(e *spec.ReplicatedEnvelope) error {
+	id, err := strconv.ParseInt(e.Segment, 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad segment id %q", e.Segment)
+	}
+
+	if len(e.Tombstones) > 0 {
+		dids := make([]int32, len(e.Tombstones))
+		for i, did := range e.Tombstones {
+			dids[i] = int32(did)
+		}
+		return m.hold(id*m.SegmentStep*int64(time.Second), func(s *Segment) error {
+			_, err := s.deleted.add(dids)
+			return err
+		})
+	}
+
+	meta := &spec.Metadata{}
+	err = proto.Unmarshal(e.Data, meta)
+	if err != nil {
+		return err
+	}
+
+	return m.hold(id*m.SegmentStep*int64(time.Second), func(s *Segment) error {
+		s.Lock()
+		defer s.Unlock()
+
+		if e.Offset < s.written {
+			return nil
+		}
+		if e.Offset > s.written {
+			return fmt.Errorf("segment %s: expected record at %d, got %d", e.Segment, s.written, e.Offset)
+		}
+
+		if e.Deleted {
+			// before the placeholder is written, queries must never see it
+			_, err := s.deleted.add([]int32{int32(e.Offset)})
+			if err != nil {
+				return err
+			}
+		}
+
+		did, err := s.appendLocked(e.Data, meta, e.PartitionId)
+		if err != nil {
+			return err
+		}
+		if did != e.Offset {
+			return fmt.Errorf("segment %s: record from %d was written at %d", e.Segment, e.Offset, did)
+		}
+		return nil
+	})
+}
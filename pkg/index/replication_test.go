@@ -0,0 +1,250 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	go_query_dsl "github.com/rekki/go-query-index-dsl"
+)
+
+func documents(t *testing.T, si *SearchIndex) map[string]string {
+	out := map[string]string{}
+	query := &spec.SearchQueryRequest{FromSecond: 1, ToSecond: 7199, Query: &go_query_dsl.Query{Field: "blackrock", Value: "match_all"}}
+	_, err := si.ForEach(context.Background(), query, 0, func(s *Segment, did int32, score float32) error {
+		m := &spec.Metadata{}
+		err := s.ReadForwardDecode(did, m)
+		if err != nil {
+			return err
+		}
+		out[fmt.Sprintf("%s:%d", path.Base(s.root), did)] = m.ForeignId
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestReplication(t *testing.T) {
+	replicationPollInterval = 10 * time.Millisecond
+
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	leader := NewSearchIndex(path.Join(root, "leader"), 10, 3600, false, map[string]bool{})
+	defer leader.Close()
+	follower := NewSearchIndex(path.Join(root, "follower"), 10, 3600, false, map[string]bool{})
+	defer follower.Close()
+
+	ingest := func(n int) {
+		for i := 0; i < n; i++ {
+			err := leader.Ingest(RandomEnvelope(1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = leader.Ingest(RandomEnvelope(3601 * int64(time.Second)))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	follow := func(ctx context.Context, caughtUp chan bool) chan error {
+		done := make(chan error, 1)
+		positions, err := follower.Positions()
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			done <- leader.Tail(ctx, positions, 0, func(e *spec.ReplicatedEnvelope) error {
+				if len(e.Data) == 0 && len(e.Tombstones) == 0 {
+					if e.PendingBytes == 0 {
+						select {
+						case caughtUp <- true:
+						default:
+						}
+					}
+					return nil
+				}
+				return follower.ApplyReplicated(e)
+			})
+		}()
+		return done
+	}
+
+	waitFor := func(caughtUp chan bool) {
+		// the first heartbeat might be before the last ingest
+		<-caughtUp
+		<-caughtUp
+	}
+
+	ingest(100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	caughtUp := make(chan bool)
+	done := follow(ctx, caughtUp)
+	waitFor(caughtUp)
+
+	ingest(50)
+	waitFor(caughtUp)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatal(err)
+	}
+
+	expected := documents(t, leader)
+	if len(expected) != 300 {
+		t.Fatalf("expected 300 documents on the leader, got %d", len(expected))
+	}
+	got := documents(t, follower)
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d documents on the follower, got %d", len(expected), len(got))
+	}
+	for did, foreignId := range expected {
+		if got[did] != foreignId {
+			t.Fatalf("document %s differs, expected %s got %s", did, foreignId, got[did])
+		}
+	}
+
+	// resume from the positions of the follower
+	ingest(10)
+	ctx, cancel = context.WithCancel(context.Background())
+	done = follow(ctx, caughtUp)
+	waitFor(caughtUp)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatal(err)
+	}
+	if n := len(documents(t, follower)); n != 320 {
+		t.Fatalf("expected 320 documents after resuming, got %d", n)
+	}
+}
+
+func TestReplicateForget(t *testing.T) {
+	replicationPollInterval = 10 * time.Millisecond
+
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	leader := NewSearchIndex(path.Join(root, "leader"), 10, 3600, false, map[string]bool{})
+	defer leader.Close()
+	follower := NewSearchIndex(path.Join(root, "follower"), 10, 3600, false, map[string]bool{})
+	defer follower.Close()
+
+	offset := int64(0)
+	ingest := func(foreignId string, n int) {
+		for i := 0; i < n; i++ {
+			e := partitioned(0, offset)
+			offset++
+			e.Metadata.ForeignType = "user"
+			e.Metadata.ForeignId = foreignId
+			_, err := leader.IngestPartitioned(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	count := func(si *SearchIndex, foreignId string) int {
+		n := 0
+		for _, v := range documents(t, si) {
+			if v == foreignId {
+				n++
+			}
+		}
+		return n
+	}
+
+	ingest("forgotten-before", 20)
+	ingest("kept", 20)
+	ingest("forgotten-while-following", 20)
+	n, _, err := leader.Forget(context.Background(), "user", "forgotten-before")
+	if err != nil || n != 20 {
+		t.Fatalf("expected 20 forgotten, got %d %v", n, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	caughtUp := make(chan bool)
+	done := make(chan error, 1)
+	go func() {
+		done <- leader.Tail(ctx, map[string]uint32{}, 0, func(e *spec.ReplicatedEnvelope) error {
+			if len(e.Data) == 0 && len(e.Tombstones) == 0 {
+				if e.PendingBytes == 0 {
+					select {
+					case caughtUp <- true:
+					default:
+					}
+				}
+				return nil
+			}
+			return follower.ApplyReplicated(e)
+		})
+	}()
+	waitFor := func() {
+		<-caughtUp
+		<-caughtUp
+	}
+	waitFor()
+
+	// the follower never got the forgotten records, only placeholders of
+	// the same size
+	leaderData, err := ioutil.ReadFile(path.Join(leader.root, "0", "main.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	followerData, err := ioutil.ReadFile(path.Join(follower.root, "0", "main.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leaderData) != len(followerData) {
+		t.Fatalf("expected the same forward index size, %d vs %d", len(leaderData), len(followerData))
+	}
+	if !bytes.Contains(leaderData, []byte("forgotten-before")) || bytes.Contains(followerData, []byte("forgotten-before")) {
+		t.Fatal("expected the forgotten documents only on the leader")
+	}
+	if n := count(follower, "forgotten-before"); n != 0 {
+		t.Fatalf("expected 0 got %d", n)
+	}
+
+	n, _, err = leader.Forget(context.Background(), "user", "forgotten-while-following")
+	if err != nil || n != 20 {
+		t.Fatalf("expected 20 forgotten, got %d %v", n, err)
+	}
+	ingest("kept", 10)
+	waitFor()
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatal(err)
+	}
+
+	expected := documents(t, leader)
+	got := documents(t, follower)
+	if len(expected) != 30 || len(got) != len(expected) {
+		t.Fatalf("expected 30 documents on both, got %d and %d", len(expected), len(got))
+	}
+	for did, foreignId := range expected {
+		if got[did] != foreignId {
+			t.Fatalf("document %s differs, expected %s got %s", did, foreignId, got[did])
+		}
+	}
+
+	// the placeholders keep the partition offsets, a promoted follower does
+	// not take the forgotten envelopes again
+	ok, err := follower.IngestPartitioned(partitioned(0, 5))
+	if err != nil || ok {
+		t.Fatalf("expected offset 5 to be skipped, got %v %v", ok, err)
+	}
+}
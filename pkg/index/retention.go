@@ -0,0 +1,144 @@
+package index
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	. "github.com/rekki/blackrock/pkg/logger"
+)
+
+// DeleteOlderThan removes the segments that ended more than age ago, from
+// disk and from the tiered storage. Segments in use are left for the next
+// call, returns how many were removed.
+func (m *SearchIndex) DeleteOlderThan(age time.Duration) (int, error) {
+	local, err := m.ListSegments()
+	if err != nil {
+		return 0, err
+	}
+	ids := map[string]bool{}
+	for _, ns := range local {
+		ids[m.toSegmentId(ns)] = true
+	}
+	m.loading.Lock()
+	if m.tiered != nil {
+		for id := range m.tiered.remote {
+			ids[id] = true
+		}
+	}
+	m.loading.Unlock()
+
+	cutoff := time.Now().Add(-age).Unix()
+	deleted := 0
+	for id := range ids {
+		step, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			continue
+		}
+		if (step+1)*m.SegmentStep > cutoff {
+			continue
+		}
+		ok, err := m.deleteSegment(id)
+		if err != nil {
+			return deleted, err
+		}
+		if ok {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *SearchIndex) deleteSegment(segmentId string) (bool, error) {
+	m.loading.Lock()
+	defer m.loading.Unlock()
+
+	m.Lock()
+	s, loaded := m.Segments[segmentId]
+	if loaded {
+		if !s.isIdle() {
+			m.Unlock()
+			return false, nil
+		}
+		delete(m.Segments, segmentId)
+	}
+	m.Unlock()
+	if loaded {
+		s.Close()
+	}
+
+	root := path.Join(m.root, segmentId)
+	if _, err := os.Stat(root); err == nil {
+		// rename first so a partial removal is never opened as a segment
+		removed := root + ".deleted"
+		err = os.Rename(root, removed)
+		if err != nil {
+			return false, err
+		}
+		err = os.RemoveAll(removed)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if t := m.tiered; t != nil {
+		if t.remote[segmentId] {
+			err := m.deleteRemoteLocked(segmentId)
+			if err != nil {
+				return false, err
+			}
+		}
+		delete(t.cached, segmentId)
+		delete(t.lastUsed, segmentId)
+	}
+
+	Log.Infof("deleted segment %s", root)
+	return true, nil
+}
+
+// must be called with m.loading held
+func (m *SearchIndex) deleteRemoteLocked(segmentId string) error {
+	t := m.tiered
+	keys, err := t.store.List(t.key(segmentId, "") + "/")
+	if err != nil {
+		return err
+	}
+	// the manifest first, without it the rest is ignored, so a crash in
+	// the middle leaves unused objects and not a manifest pointing at
+	// missing ones
+	err = t.store.Delete(t.key(segmentId, remoteManifestName))
+	if err != nil {
+		return err
+	}
+	delete(t.remote, segmentId)
+	for _, k := range keys {
+		if path.Base(k) != remoteManifestName {
+			err = t.store.Delete(k)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DiskUsage is the size of the segments on local disk, fetched ones included
+func (m *SearchIndex) DiskUsage() (int64, error) {
+	var total int64
+	err := filepath.Walk(m.root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				// removed by offload or retention while walking
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
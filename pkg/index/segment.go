@@ -4,9 +4,12 @@ import (
 	"os"
 	"path"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	. "github.com/rekki/blackrock/pkg/logger"
 	pen "github.com/rekki/go-pen"
 	dsl "github.com/rekki/go-query-index"
 )
@@ -16,22 +19,52 @@ func init() {
 }
 
 type Segment struct {
+	lastAccess  int64
+	refs        int32
 	dir         *dsl.DirIndex
+	fdc         *FDCache
 	root        string
 	whitelist   map[string]bool
 	reader      *pen.Reader
 	writer      *pen.Writer
 	cache       sync.Map
 	enableCache bool
+
+	commit       *os.File
+	committed    uint32
+	written      uint32
+	lastSync     time.Time
+	syncPolicy   SyncPolicy
+	syncInterval time.Duration
+
+	// downloaded from the tiered storage and not modified since
+	fetched bool
+
+	// see forget.go
+	deleted *tombstones
+
+	// see dedup.go
+	ids        *os.File
+	idEntries  uint32
+	partitions map[uint32]int64
+
+	// held while reading the inverted index, Reindex swaps it underneath
+	invLock    sync.RWMutex
+	reindexing sync.Mutex
 	sync.Mutex
 }
 
-func NewSegment(root string, fdc dsl.FileDescriptorCache, enableCache bool, whitelist map[string]bool) (*Segment, error) {
-	s := &Segment{root: root, dir: dsl.NewDirIndex(path.Join(root, "inv"), fdc, nil), enableCache: enableCache, whitelist: whitelist}
+func NewSegment(root string, fdc *FDCache, enableCache bool, whitelist map[string]bool, syncPolicy SyncPolicy, syncInterval time.Duration) (*Segment, error) {
+	s := &Segment{root: root, fdc: fdc, dir: dsl.NewDirIndex(path.Join(root, "inv"), fdc, nil), enableCache: enableCache, whitelist: whitelist, lastAccess: time.Now().UnixNano(), syncPolicy: syncPolicy, syncInterval: syncInterval, lastSync: time.Now()}
 	err := s.OpenForwardIndex()
 	if err != nil {
 		return nil, err
 	}
+	s.deleted, err = readTombstones(root)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
 	return s, nil
 }
 
@@ -49,6 +82,11 @@ func (x *Indexable) DocumentID() int32 {
 }
 
 func (s *Segment) Ingest(envelope *spec.Envelope) error {
+	return s.ingest(envelope, false)
+}
+
+// partitionId means Metadata.Id is partition<<56 | offset
+func (s *Segment) ingest(envelope *spec.Envelope, partitionId bool) error {
 	s.Lock()
 	defer s.Unlock()
 
@@ -57,30 +95,76 @@ func (s *Segment) Ingest(envelope *spec.Envelope) error {
 		return err
 	}
 
-	did, _, err := s.writer.Append(encoded)
+	_, err = s.appendLocked(encoded, envelope.Metadata, partitionId)
+	return err
+}
+
+// must be called with the segment lock held, returns the document id
+func (s *Segment) appendLocked(encoded []byte, meta *spec.Metadata, partitionId bool) (uint32, error) {
+	err := s.detachRemoteLocked()
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	if partitionId {
+		err = s.logPartitionId(meta.Id)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	did, next, err := s.writer.Append(encoded)
+	if err != nil {
+		if partitionId {
+			_ = s.unlogPartitionId()
+		}
+		return 0, err
+	}
+	if partitionId {
+		s.notePartitionId(meta.Id)
+	}
+
+	err = s.index(int32(did), meta)
+	if err != nil {
+		return 0, err
 	}
-	meta := envelope.Metadata
 
+	return did, s.afterWrite(next)
+}
+
+func (s *Segment) index(did int32, meta *spec.Metadata) error {
+	return indexDocument(s.dir, s.whitelist, did, meta)
+}
+
+func indexDocument(dir *dsl.DirIndex, whitelist map[string]bool, did int32, meta *spec.Metadata) error {
 	x := Indexable{
-		data: map[string][]string{},
-		id:   int32(did),
+		data: indexableFields(whitelist, meta),
+		id:   did,
 	}
+
+	return dir.Index(dsl.DocumentWithID(&x))
+}
+
+func indexableFields(whitelist map[string]bool, meta *spec.Metadata) map[string][]string {
+	data := map[string][]string{}
 	for _, kv := range meta.Search {
 		if len(kv.Key) == 0 || len(kv.Value) == 0 {
 			continue
 		}
-		if s.whitelist == nil || len(s.whitelist) == 0 || s.whitelist[kv.Key] {
-			x.data[kv.Key] = append(x.data[kv.Key], kv.Value)
+		if whitelist == nil || len(whitelist) == 0 || whitelist[kv.Key] {
+			data[kv.Key] = append(data[kv.Key], kv.Value)
 		}
 	}
 
-	x.data[meta.ForeignType] = []string{meta.ForeignId}
-	x.data["event_type"] = []string{meta.EventType}
-	x.data["blackrock"] = []string{"match_all"}
-
-	return s.dir.Index(dsl.DocumentWithID(&x))
+	// both empty only for the placeholders of forgotten documents
+	if meta.ForeignType != "" {
+		data[meta.ForeignType] = []string{meta.ForeignId}
+	}
+	if meta.EventType != "" {
+		data["event_type"] = []string{meta.EventType}
+	}
+	data["blackrock"] = []string{"match_all"}
+	return data
 }
 
 func (s *Segment) ReadForward(did int32) ([]byte, error) {
@@ -119,28 +203,87 @@ func (s *Segment) OpenForwardIndex() error {
 		return err
 	}
 
+	cfn := path.Join(s.root, "main.commit")
+	_, err = os.Stat(cfn)
+	isNew := os.IsNotExist(err)
+
+	commit, err := os.OpenFile(cfn, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+
+	s.commit = commit
+
+	err = finishSwap(s.root)
+	if err != nil {
+		commit.Close()
+		return err
+	}
+
 	fn := path.Join(s.root, "main.bin")
+	err = s.recover(fn, isNew)
+	if err != nil {
+		commit.Close()
+		return err
+	}
+
 	writer, err := pen.NewWriter(fn)
 	if err != nil {
+		commit.Close()
 		return err
 	}
 
 	reader, err := pen.NewReader(fn, 0)
 	if err != nil {
+		commit.Close()
 		writer.Close()
 		return err
 	}
 
 	s.reader = reader
 	s.writer = writer
+	s.written = s.committed
+
+	err = s.openIdLog()
+	if err != nil {
+		commit.Close()
+		writer.Close()
+		reader.Close()
+		return err
+	}
 	return nil
 }
 
+// the segment can not be closed while someone holds a reference,
+// SearchIndex only evicts segments with no references
+func (s *Segment) acquire() {
+	atomic.AddInt32(&s.refs, 1)
+	atomic.StoreInt64(&s.lastAccess, time.Now().UnixNano())
+}
+
+func (s *Segment) release() {
+	atomic.AddInt32(&s.refs, -1)
+}
+
+func (s *Segment) isIdle() bool {
+	return atomic.LoadInt32(&s.refs) == 0
+}
+
+func (s *Segment) LastAccess() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&s.lastAccess))
+}
+
 func (s *Segment) Close() {
 	if s.writer != nil {
-		_ = s.writer.Sync()
+		err := s.Sync()
+		if err != nil {
+			Log.Warnf("segment %s: failed to sync on close, err: %s", s.root, err.Error())
+		}
 		_ = s.writer.Close()
 		_ = s.reader.Close()
-		s.dir.Close()
+		_ = s.commit.Close()
+		_ = s.ids.Close()
+		// do not use s.dir.Close(), it closes the shared descriptors of all segments
+		s.fdc.CloseUnder(s.root)
 	}
 }
@@ -0,0 +1,403 @@
+package index
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/rekki/blackrock/pkg/logger"
+	"github.com/rekki/blackrock/pkg/objectstore"
+	pen "github.com/rekki/go-pen"
+)
+
+const manifestName = "manifest.json"
+
+type SnapshotFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+type SnapshotSegment struct {
+	Id        string         `json:"id"`
+	Committed uint32         `json:"committed"`
+	Files     []SnapshotFile `json:"files"`
+}
+
+// check rejects a segment that would be written outside of its segment
+// directory, the id is the numeric segment id and every file name is a
+// clean relative path
+func (s *SnapshotSegment) check() error {
+	if _, err := strconv.ParseUint(s.Id, 10, 64); err != nil {
+		return fmt.Errorf("bad segment id %q in manifest", s.Id)
+	}
+	for _, f := range s.Files {
+		if !validFileName(f.Name) {
+			return fmt.Errorf("segment %s: bad file name %q in manifest", s.Id, f.Name)
+		}
+	}
+	return nil
+}
+
+func validFileName(name string) bool {
+	if name == "" || filepath.Clean(name) != name || filepath.IsAbs(name) {
+		return false
+	}
+	return name != "." && name != ".." && !strings.HasPrefix(name, ".."+string(filepath.Separator))
+}
+
+type SnapshotManifest struct {
+	CreatedAtNs int64             `json:"created_at_ns"`
+	SegmentStep int64             `json:"segment_step"`
+	Tenant      string            `json:"tenant,omitempty"`
+	Segments    []SnapshotSegment `json:"segments"`
+}
+
+// Snapshot copies the segments between from and to (unix seconds) into
+// a new directory. Every segment is fsynced first and copied up to its
+// commit marker, so documents written while copying are left out and the
+// snapshot is consistent without pausing ingestion.
+func (m *SearchIndex) Snapshot(into string, from, to uint32) (*SnapshotManifest, error) {
+	segments, err := m.listSegmentsBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(into, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &SnapshotManifest{CreatedAtNs: time.Now().UnixNano(), SegmentStep: m.SegmentStep, Tenant: m.Tenant}
+	for _, ns := range segments {
+		segmentId := m.toSegmentId(ns)
+		err = m.hold(ns, func(s *Segment) error {
+			ss, err := s.snapshot(path.Join(into, segmentId))
+			if err != nil {
+				return err
+			}
+			ss.Id = segmentId
+			manifest.Segments = append(manifest.Segments, *ss)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// the manifest is written last, a snapshot without it is incomplete
+	err = ioutil.WriteFile(path.Join(into, manifestName), data, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func (m *SearchIndex) listSegmentsBetween(from, to uint32) ([]int64, error) {
+	segments, err := m.ListSegments()
+	if err != nil {
+		return nil, err
+	}
+
+	out := []int64{}
+	for _, ns := range segments {
+		second := ns / int64(time.Second)
+		if second+m.SegmentStep <= int64(from) || (to > 0 && second > int64(to)) {
+			continue
+		}
+		out = append(out, ns)
+	}
+	return out, nil
+}
+
+func (s *Segment) snapshot(into string) (*SnapshotSegment, error) {
+	err := s.Sync()
+	if err != nil {
+		return nil, err
+	}
+
+	s.Lock()
+	committed := s.committed
+	s.Unlock()
+
+	ss := &SnapshotSegment{Committed: committed}
+
+	f, err := copyFile(path.Join(s.root, "main.bin"), into, "main.bin", int64(committed)*int64(pen.PAD))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if f != nil {
+		ss.Files = append(ss.Files, *f)
+	}
+
+	s.invLock.RLock()
+	defer s.invLock.RUnlock()
+
+	err = filepath.Walk(path.Join(s.root, "inv"), func(fn string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		name, err := filepath.Rel(s.root, fn)
+		if err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(fn)
+		if err != nil {
+			return err
+		}
+
+		// postings of documents after the commit are cut off
+		keep := len(data) / 4
+		for keep > 0 && binary.LittleEndian.Uint32(data[(keep-1)*4:]) >= committed {
+			keep--
+		}
+
+		f, err := writeFile(into, filepath.ToSlash(name), data[:keep*4])
+		if err != nil {
+			return err
+		}
+		ss.Files = append(ss.Files, *f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := readIdLog(s.root)
+	if err != nil {
+		return nil, err
+	}
+	keep := uint32(0)
+	for keep < uint32(len(ids)/idEntrySize) {
+		did, _ := idEntry(ids, keep)
+		if did >= committed {
+			break
+		}
+		keep++
+	}
+	if keep > 0 {
+		f, err = writeFile(into, "main.ids", ids[:keep*idEntrySize])
+		if err != nil {
+			return nil, err
+		}
+		ss.Files = append(ss.Files, *f)
+	}
+
+	// forgotten documents stay hidden in the restored segment
+	f, err = copyFile(path.Join(s.root, deletedName), into, deletedName, math.MaxInt64)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if f != nil {
+		ss.Files = append(ss.Files, *f)
+	}
+
+	f, err = writeFile(into, "main.commit", encodeCommit(committed))
+	if err != nil {
+		return nil, err
+	}
+	ss.Files = append(ss.Files, *f)
+	return ss, nil
+}
+
+// copy at most limit bytes of from into dir/name and checksum the copy
+func copyFile(from string, dir string, name string, limit int64) (*SnapshotFile, error) {
+	src, err := os.Open(from)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	fn := path.Join(dir, name)
+	err = os.MkdirAll(path.Dir(fn), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	dst, err := os.OpenFile(fn, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer dst.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(dst, h), io.LimitReader(src, limit))
+	if err != nil {
+		return nil, err
+	}
+	return &SnapshotFile{Name: name, Size: n, Sha256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+func writeFile(dir string, name string, data []byte) (*SnapshotFile, error) {
+	fn := path.Join(dir, name)
+	err := os.MkdirAll(path.Dir(fn), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ioutil.WriteFile(fn, data, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	return &SnapshotFile{Name: name, Size: int64(len(data)), Sha256: hex.EncodeToString(sum[:])}, nil
+}
+
+func ReadSnapshotManifest(dir string) (*SnapshotManifest, error) {
+	data, err := ioutil.ReadFile(path.Join(dir, manifestName))
+	if err != nil {
+		return nil, err
+	}
+	manifest := &SnapshotManifest{}
+	err = json.Unmarshal(data, manifest)
+	return manifest, err
+}
+
+// UploadSnapshot puts every file of a local snapshot under prefix, the
+// manifest goes last so a partial upload is never restored
+func UploadSnapshot(dir string, store objectstore.Store, prefix string) error {
+	manifest, err := ReadSnapshotManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range manifest.Segments {
+		for _, f := range s.Files {
+			err = uploadFile(store, path.Join(dir, s.Id, f.Name), path.Join(prefix, s.Id, f.Name))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return uploadFile(store, path.Join(dir, manifestName), path.Join(prefix, manifestName))
+}
+
+func uploadFile(store objectstore.Store, fn string, key string) error {
+	f, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return store.Put(key, f, st.Size())
+}
+
+// RestoreSnapshot downloads a snapshot into root/<segment_step>, or
+// root/<tenant>/<segment_step> for the snapshot of a tenant, verifying
+// every checksum. Segments that already exist in root are not touched.
+// tenant restores into another tenant, "" keeps the one of the snapshot.
+func RestoreSnapshot(store objectstore.Store, prefix string, root string, tenant string) (*SnapshotManifest, error) {
+	r, err := store.Get(path.Join(prefix, manifestName))
+	if err != nil {
+		return nil, err
+	}
+	manifest := &SnapshotManifest{}
+	err = json.NewDecoder(r).Decode(manifest)
+	r.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if tenant == "" {
+		tenant = manifest.Tenant
+	}
+	if tenant != "" {
+		if !ValidTenant(tenant) {
+			return nil, ErrBadTenant
+		}
+		root = path.Join(root, tenant)
+	}
+	root = path.Join(root, fmt.Sprintf("%d", manifest.SegmentStep))
+	for _, s := range manifest.Segments {
+		err = s.check()
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range manifest.Segments {
+		into := path.Join(root, s.Id)
+		if _, err := os.Stat(into); err == nil {
+			Log.Warnf("skipping segment %s, already exists", into)
+			continue
+		}
+
+		tmp := into + ".restore"
+		err = os.RemoveAll(tmp)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range s.Files {
+			err = downloadFile(store, path.Join(prefix, s.Id, f.Name), path.Join(tmp, f.Name), f)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = os.Rename(tmp, into)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+func downloadFile(store objectstore.Store, key string, fn string, expected SnapshotFile) error {
+	r, err := store.Get(key)
+	if err != nil {
+		return fmt.Errorf("%s: %s", key, err.Error())
+	}
+	defer r.Close()
+
+	err = os.MkdirAll(path.Dir(fn), 0700)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(fn, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), r)
+	if err != nil {
+		return err
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	if n != expected.Size || sum != expected.Sha256 {
+		return fmt.Errorf("%s: checksum mismatch, expected %d bytes %s got %d bytes %s", key, expected.Size, expected.Sha256, n, sum)
+	}
+	return f.Sync()
+}
@@ -0,0 +1,198 @@
+package index
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"github.com/rekki/blackrock/pkg/objectstore"
+)
+
+func TestSnapshotUploadAndRestore(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	envelope := func() *spec.Envelope {
+		e := RandomEnvelope(1)
+		e.Metadata.Search = []spec.KV{{Key: "a", Value: "x"}}
+		return e
+	}
+
+	si := NewSearchIndex(path.Join(root, "data"), 10, 3600, false, map[string]bool{})
+	inserted := 500
+	for i := 0; i < inserted; i++ {
+		err = si.Ingest(envelope())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			err := si.Ingest(envelope())
+			if err != nil {
+				panic(err)
+			}
+		}
+	}()
+
+	manifest, err := si.Snapshot(path.Join(root, "snap"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	si.Close()
+
+	if len(manifest.Segments) != 1 {
+		t.Fatalf("expected 1 segment, got %d", len(manifest.Segments))
+	}
+
+	store, err := objectstore.Open("file://" + path.Join(root, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = UploadSnapshot(path.Join(root, "snap"), store, "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = RestoreSnapshot(store, "backup", path.Join(root, "restored"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := CheckSegment(path.Join(root, "restored", "3600", "0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("restored segment is broken: %s", report.String())
+	}
+
+	restored := NewSearchIndex(path.Join(root, "restored"), 10, 3600, false, map[string]bool{})
+	defer restored.Close()
+	n := countMatching(t, restored, "a", "x")
+	if n < inserted || n > inserted+100 || n != report.Documents {
+		t.Fatalf("expected between %d and %d documents, got %d, forward index has %d", inserted, inserted+100, n, report.Documents)
+	}
+
+	// corrupted objects are refused
+	err = store.Put("backup/0/main.bin", strings.NewReader("garbage"), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = RestoreSnapshot(store, "backup", path.Join(root, "corrupt"), "")
+	if err == nil {
+		t.Fatal("expected checksum mismatch")
+	}
+}
+
+func TestRestoreTenantSnapshot(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	tenants, err := NewTenants(path.Join(root, "data"), &TenantsConfig{Tenants: map[string]TenantConfig{"acme": {}}}, func(root string) (*SearchIndex, error) {
+		return NewSearchIndex(root, 10, 3600, false, map[string]bool{}), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := RandomEnvelope(1)
+	e.Metadata.Tenant = "acme"
+	err = tenants.Ingest(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	si, err := tenants.Get("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := si.Snapshot(path.Join(root, "snap"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = tenants.Shutdown(0)
+	if manifest.Tenant != "acme" {
+		t.Fatalf("expected the tenant in the manifest, got %q", manifest.Tenant)
+	}
+
+	store, err := objectstore.Open("file://" + path.Join(root, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = UploadSnapshot(path.Join(root, "snap"), store, "acme/backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for tenant, dir := range map[string]string{"": "acme", "beta": "beta"} {
+		_, err = RestoreSnapshot(store, "acme/backup", path.Join(root, "restored"), tenant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(path.Join(root, "restored", dir, "3600", "0", "main.bin")); err != nil {
+			t.Fatalf("expected the segment in %s, got %v", dir, err)
+		}
+	}
+	if _, err = RestoreSnapshot(store, "acme/backup", path.Join(root, "restored"), "Bad!"); err != ErrBadTenant {
+		t.Fatalf("expected bad tenant, got %v", err)
+	}
+}
+
+func TestRestoreSnapshotBadManifest(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	store, err := objectstore.Open("file://" + path.Join(root, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := "x"
+	err = store.Put("escape", strings.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		id   string
+		name string
+	}{
+		{"../../escape", "main.bin"},
+		{"", "main.bin"},
+		{"0x1", "main.bin"},
+		{"0", "../../../escape"},
+		{"0", "/tmp/escape"},
+		{"0", "a/../../escape"},
+		{"0", ".."},
+		{"0", ""},
+	}
+	for _, c := range cases {
+		manifest := fmt.Sprintf(`{"segment_step":3600,"segments":[{"id":%q,"files":[{"name":%q,"size":1}]}]}`, c.id, c.name)
+		err = store.Put("backup/"+manifestName, strings.NewReader(manifest), int64(len(manifest)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = RestoreSnapshot(store, "backup", path.Join(root, "restored"), "")
+		if err == nil {
+			t.Fatalf("%q %q: expected the manifest to be rejected", c.id, c.name)
+		}
+	}
+	if _, err := os.Stat(path.Join(root, "restored")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be restored, got %v", err)
+	}
+}
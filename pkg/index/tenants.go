@@ -0,0 +1,300 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	. "github.com/rekki/blackrock/pkg/logger"
+)
+
+var (
+	ErrUnknownTenant = errors.New("unknown tenant")
+	ErrBadTenant     = errors.New("bad tenant name, use lowercase letters, digits, _ and - starting with a letter")
+	ErrOverQuota     = errors.New("tenant is over its disk quota")
+)
+
+// IsTenantError tells if the envelope was refused because of its tenant
+func IsTenantError(err error) bool {
+	return err == ErrUnknownTenant || err == ErrBadTenant || err == ErrOverQuota
+}
+
+// tenant names start with a letter so they never clash with the segment
+// step directories next to them
+var tenantName = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,63}$`)
+
+// names used by other directories in root
+var reservedTenants = map[string]bool{"snapshots": true}
+
+func ValidTenant(name string) bool {
+	return tenantName.MatchString(name) && !reservedTenants[name]
+}
+
+type TenantConfig struct {
+	// segments that ended more than # days ago are deleted, 0 keeps them
+	RetentionDays int `json:"retention_days"`
+	// pushes are refused while the segments on local disk are bigger, 0 means no limit
+	MaxBytes int64 `json:"max_bytes"`
+}
+
+// TenantsConfig example:
+//
+//	{
+//	  "allow_unlisted": false,
+//	  "default": {"retention_days": 90},
+//	  "tenants": {
+//	    "acme": {"retention_days": 30, "max_bytes": 107374182400}
+//	  }
+//	}
+//
+// default applies to envelopes without a tenant and to unlisted tenants,
+// those are refused unless allow_unlisted is set
+type TenantsConfig struct {
+	AllowUnlisted bool                    `json:"allow_unlisted"`
+	Default       TenantConfig            `json:"default"`
+	Tenants       map[string]TenantConfig `json:"tenants"`
+}
+
+func LoadTenantsConfig(fn string) (*TenantsConfig, error) {
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	c := &TenantsConfig{}
+	err = json.Unmarshal(data, c)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", fn, err.Error())
+	}
+	for name := range c.Tenants {
+		if !ValidTenant(name) {
+			return nil, fmt.Errorf("%s: bad tenant name %q, use lowercase letters, digits, _ and - starting with a letter", fn, name)
+		}
+	}
+	return c, nil
+}
+
+func (c *TenantsConfig) get(tenant string) (TenantConfig, bool) {
+	if tenant == "" {
+		return c.Default, true
+	}
+	if tc, ok := c.Tenants[tenant]; ok {
+		return tc, true
+	}
+	return c.Default, c.AllowUnlisted
+}
+
+// Tenants keeps one SearchIndex per tenant, the envelopes without a tenant
+// are in root/<step> as before tenants existed, the others in
+// root/<tenant>/<step>. Indexes are opened on first use, an unlisted
+// tenant is created only by a push.
+type Tenants struct {
+	root    string
+	config  *TenantsConfig
+	open    func(root string) (*SearchIndex, error)
+	indexes map[string]*tenantIndex
+	sync.Mutex
+}
+
+type tenantIndex struct {
+	si        *SearchIndex
+	config    TenantConfig
+	overQuota int32
+}
+
+// open creates and configures the index of a tenant, root is passed to
+// NewSearchIndex
+func NewTenants(root string, config *TenantsConfig, open func(root string) (*SearchIndex, error)) (*Tenants, error) {
+	t := &Tenants{root: root, config: config, open: open, indexes: map[string]*tenantIndex{}}
+
+	_, err := t.Get("")
+	if err != nil {
+		return nil, err
+	}
+
+	// open the tenants on disk so retention and quotas apply to them
+	dirs, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range dirs {
+		if !d.IsDir() || !ValidTenant(d.Name()) {
+			continue
+		}
+		_, err = t.Get(d.Name())
+		if err == ErrUnknownTenant {
+			Log.Warnf("tenant %s is on disk but not in the tenants config, it can not be queried", d.Name())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Get opens the index of a tenant, creating it if needed
+func (t *Tenants) Get(tenant string) (*SearchIndex, error) {
+	ti, err := t.get(tenant, true)
+	if err != nil {
+		return nil, err
+	}
+	return ti.si, nil
+}
+
+// Lookup is Get for reads, an unlisted tenant without data is unknown, so
+// made up tenant names use no disk or file descriptors
+func (t *Tenants) Lookup(tenant string) (*SearchIndex, error) {
+	ti, err := t.get(tenant, false)
+	if err != nil {
+		return nil, err
+	}
+	return ti.si, nil
+}
+
+func (t *Tenants) get(tenant string, create bool) (*tenantIndex, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	if ti, ok := t.indexes[tenant]; ok {
+		return ti, nil
+	}
+	if tenant != "" && !ValidTenant(tenant) {
+		return nil, ErrBadTenant
+	}
+	config, ok := t.config.get(tenant)
+	if !ok {
+		return nil, ErrUnknownTenant
+	}
+
+	root := t.root
+	if tenant != "" {
+		root = path.Join(t.root, tenant)
+		if _, listed := t.config.Tenants[tenant]; !create && !listed {
+			if _, err := os.Stat(root); err != nil {
+				return nil, ErrUnknownTenant
+			}
+		}
+	}
+	si, err := t.open(root)
+	if err != nil {
+		return nil, err
+	}
+	si.Tenant = tenant
+	ti := &tenantIndex{si: si, config: config}
+	t.indexes[tenant] = ti
+	return ti, nil
+}
+
+// Ingest writes into the index of Metadata.Tenant
+func (t *Tenants) Ingest(envelope *spec.Envelope) error {
+	ti, err := t.forEnvelope(envelope)
+	if err != nil {
+		return err
+	}
+	return ti.si.Ingest(envelope)
+}
+
+func (t *Tenants) IngestPartitioned(envelope *spec.Envelope) (bool, error) {
+	ti, err := t.forEnvelope(envelope)
+	if err != nil {
+		return false, err
+	}
+	return ti.si.IngestPartitioned(envelope)
+}
+
+func (t *Tenants) forEnvelope(envelope *spec.Envelope) (*tenantIndex, error) {
+	tenant := ""
+	if envelope.Metadata != nil {
+		tenant = envelope.Metadata.Tenant
+	}
+	ti, err := t.get(tenant, true)
+	if err != nil {
+		return nil, err
+	}
+	if atomic.LoadInt32(&ti.overQuota) == 1 {
+		return nil, ErrOverQuota
+	}
+	return ti, nil
+}
+
+// Each calls cb for every open tenant index, sorted by tenant
+func (t *Tenants) Each(cb func(tenant string, si *SearchIndex) error) error {
+	t.Lock()
+	names := []string{}
+	for name := range t.indexes {
+		names = append(names, name)
+	}
+	t.Unlock()
+	sort.Strings(names)
+
+	var lastError error
+	for _, name := range names {
+		si, err := t.Get(name)
+		if err == nil {
+			err = cb(name, si)
+		}
+		if err != nil {
+			lastError = err
+		}
+	}
+	return lastError
+}
+
+// Enforce deletes the segments past the retention of each tenant and
+// refuses pushes to the tenants over their quota until they are under it
+func (t *Tenants) Enforce() error {
+	return t.Each(func(tenant string, si *SearchIndex) error {
+		ti, err := t.get(tenant, true)
+		if err != nil {
+			return err
+		}
+		if ti.config.RetentionDays > 0 {
+			n, err := si.DeleteOlderThan(time.Duration(ti.config.RetentionDays) * 24 * time.Hour)
+			if n > 0 {
+				Log.Infof("tenant %q: deleted %d segments older than %d days", tenant, n, ti.config.RetentionDays)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if ti.config.MaxBytes > 0 {
+			used, err := si.DiskUsage()
+			if err != nil {
+				return err
+			}
+			over := int32(0)
+			if used > ti.config.MaxBytes {
+				over = 1
+			}
+			if atomic.SwapInt32(&ti.overQuota, over) != over {
+				Log.Warnf("tenant %q: %d bytes used of %d, over quota: %v", tenant, used, ti.config.MaxBytes, over == 1)
+			}
+		}
+		return nil
+	})
+}
+
+func (t *Tenants) Sync() error {
+	return t.Each(func(tenant string, si *SearchIndex) error {
+		return si.Sync()
+	})
+}
+
+func (t *Tenants) Shutdown(timeout time.Duration) error {
+	return t.Each(func(tenant string, si *SearchIndex) error {
+		err := si.Shutdown(timeout)
+		if err != nil {
+			return fmt.Errorf("tenant %q: %s", tenant, err.Error())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,166 @@
+package index
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestTenants(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	config := &TenantsConfig{
+		Tenants: map[string]TenantConfig{
+			"acme": {RetentionDays: 1},
+			"beta": {MaxBytes: 1},
+		},
+	}
+	open := func(root string) (*SearchIndex, error) {
+		return NewSearchIndex(root, 10, 3600, false, map[string]bool{}), nil
+	}
+	tenants, err := NewTenants(root, config, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for tenant, n := range map[string]int{"": 3, "acme": 2, "beta": 1} {
+		for i := 0; i < n; i++ {
+			e := RandomEnvelope(1000000000)
+			e.Metadata.Tenant = tenant
+			err = tenants.Ingest(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for tenant, expected := range map[string]error{"zzz": ErrUnknownTenant, "Bad!": ErrBadTenant, "snapshots": ErrBadTenant} {
+		e := RandomEnvelope(1000000000)
+		e.Metadata.Tenant = tenant
+		err = tenants.Ingest(e)
+		if err != expected || !IsTenantError(err) {
+			t.Fatalf("tenant %s: expected %v, got %v", tenant, expected, err)
+		}
+	}
+
+	count := func(tenant string) int {
+		si, err := tenants.Get(tenant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return countMatching(t, si, "blackrock", "match_all")
+	}
+	for tenant, expected := range map[string]int{"": 3, "acme": 2, "beta": 1} {
+		if n := count(tenant); n != expected {
+			t.Fatalf("tenant %q: expected %d, got %d", tenant, expected, n)
+		}
+	}
+	for _, dir := range []string{"3600/0", "acme/3600/0", "beta/3600/0"} {
+		if _, err := os.Stat(path.Join(root, dir)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = tenants.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tenants.Enforce()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// acme segments are older than a day, beta is over its quota
+	if _, err := os.Stat(path.Join(root, "acme/3600/0")); !os.IsNotExist(err) {
+		t.Fatalf("expected acme segment to be deleted, got %v", err)
+	}
+	if n := count("acme"); n != 0 {
+		t.Fatalf("expected nothing left in acme, got %d", n)
+	}
+	e := RandomEnvelope(1000000000)
+	e.Metadata.Tenant = "beta"
+	if err := tenants.Ingest(e); err != ErrOverQuota {
+		t.Fatalf("expected over quota, got %v", err)
+	}
+	if n := count(""); n != 3 {
+		t.Fatalf("expected the default tenant untouched, got %d", n)
+	}
+
+	err = tenants.Shutdown(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewTenants(root, config, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	_ = reopened.Each(func(tenant string, si *SearchIndex) error {
+		names = append(names, tenant)
+		return nil
+	})
+	if len(names) != 3 || names[0] != "" || names[1] != "acme" || names[2] != "beta" {
+		t.Fatalf("expected the tenants on disk to be opened, got %v", names)
+	}
+	_ = reopened.Shutdown(0)
+}
+
+func TestTenantsLookup(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	fdc := NewFDCache(10)
+	opened := 0
+	open := func(root string) (*SearchIndex, error) {
+		opened++
+		return NewSearchIndexWithFDCache(root, fdc, 3600, false, map[string]bool{}), nil
+	}
+	tenants, err := NewTenants(root, &TenantsConfig{AllowUnlisted: true, Tenants: map[string]TenantConfig{"acme": {}}}, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tenants.Shutdown(0)
+
+	// reads do not create unlisted tenants
+	for i := 0; i < 3; i++ {
+		_, err = tenants.Lookup(fmt.Sprintf("made-up-%d", i))
+		if err != ErrUnknownTenant {
+			t.Fatalf("expected unknown tenant, got %v", err)
+		}
+	}
+	if _, err := os.Stat(path.Join(root, "made-up-0")); !os.IsNotExist(err) {
+		t.Fatalf("expected no directory, got %v", err)
+	}
+	if opened != 1 {
+		t.Fatalf("expected only the default index open, got %d", opened)
+	}
+
+	// listed tenants can be queried before their first push
+	_, err = tenants.Lookup("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := RandomEnvelope(1000000000)
+	e.Metadata.Tenant = "pushed"
+	err = tenants.Ingest(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	si, err := tenants.Lookup("pushed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countMatching(t, si, "blackrock", "match_all"); n != 1 {
+		t.Fatalf("expected 1 got %d", n)
+	}
+}
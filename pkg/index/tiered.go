@@ -0,0 +1,466 @@
+package index
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	. "github.com/rekki/blackrock/pkg/logger"
+	"github.com/rekki/blackrock/pkg/objectstore"
+)
+
+// Old segments can be moved to an object store. The remote layout is
+// prefix/<segment_step>/<segment_id>/ with the files of the segment and
+// segment.json, which is uploaded last. A segment that is queried again is
+// downloaded into root and kept there with a remote.json marker, those
+// fetched segments form a cache of bounded size. Writing to a fetched
+// segment removes the marker, it becomes local again and is uploaded by
+// the next Offload.
+
+const (
+	remoteManifestName = "segment.json"
+	remoteMarkerName   = "remote.json"
+)
+
+type tieredStorage struct {
+	store         objectstore.Store
+	prefix        string
+	maxCacheBytes int64
+
+	// segment ids present in the store
+	remote map[string]bool
+	// fetched segment id -> size in bytes
+	cached   map[string]int64
+	lastUsed map[string]time.Time
+}
+
+// EnableTieredStorage makes the index fetch segments missing from root
+// from the store, at most maxCacheBytes of fetched segments are kept on
+// local disk, 0 means no limit
+func (m *SearchIndex) EnableTieredStorage(store objectstore.Store, prefix string, maxCacheBytes int64) error {
+	t := &tieredStorage{
+		store:         store,
+		prefix:        path.Join(prefix, fmt.Sprintf("%d", m.SegmentStep)),
+		maxCacheBytes: maxCacheBytes,
+		remote:        map[string]bool{},
+		cached:        map[string]int64{},
+		lastUsed:      map[string]time.Time{},
+	}
+
+	keys, err := store.List(t.prefix + "/")
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if path.Base(k) == remoteManifestName {
+			t.remote[path.Base(path.Dir(k))] = true
+		}
+	}
+
+	dirs, err := ioutil.ReadDir(m.root)
+	if err != nil {
+		return err
+	}
+	for _, d := range dirs {
+		marker, err := readRemoteMarker(path.Join(m.root, d.Name()))
+		if err != nil {
+			continue
+		}
+		t.cached[d.Name()] = marker.size()
+		t.lastUsed[d.Name()] = d.ModTime()
+	}
+
+	m.loading.Lock()
+	m.tiered = t
+	m.loading.Unlock()
+
+	Log.Infof("tiered storage: %d remote segments, %d fetched", len(t.remote), len(t.cached))
+	return nil
+}
+
+func (s *SnapshotSegment) size() int64 {
+	total := int64(0)
+	for _, f := range s.Files {
+		total += f.Size
+	}
+	return total
+}
+
+func readRemoteMarker(root string) (*SnapshotSegment, error) {
+	data, err := ioutil.ReadFile(path.Join(root, remoteMarkerName))
+	if err != nil {
+		return nil, err
+	}
+	ss := &SnapshotSegment{}
+	err = json.Unmarshal(data, ss)
+	return ss, err
+}
+
+func (t *tieredStorage) key(segmentId string, name string) string {
+	return path.Join(t.prefix, segmentId, name)
+}
+
+// must be called with m.loading held, downloads the segment into root
+// if it is not present locally but is in the store
+func (m *SearchIndex) fetchSegment(segmentId string, root string) error {
+	t := m.tiered
+	if t == nil {
+		return nil
+	}
+
+	if _, err := os.Stat(root); err == nil {
+		if _, ok := t.cached[segmentId]; ok {
+			t.lastUsed[segmentId] = time.Now()
+		}
+		return nil
+	}
+
+	if !t.remote[segmentId] {
+		return nil
+	}
+
+	started := time.Now()
+	r, err := t.store.Get(t.key(segmentId, remoteManifestName))
+	if err != nil {
+		return err
+	}
+	ss := &SnapshotSegment{}
+	err = json.NewDecoder(r).Decode(ss)
+	r.Close()
+	if err != nil {
+		return err
+	}
+	err = ss.check()
+	if err != nil {
+		return err
+	}
+	if ss.Id != segmentId {
+		return fmt.Errorf("segment %s: manifest is for segment %s", segmentId, ss.Id)
+	}
+
+	tmp := root + ".fetch"
+	err = os.RemoveAll(tmp)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range ss.Files {
+		err = downloadFile(t.store, t.key(segmentId, f.Name), path.Join(tmp, f.Name), f)
+		if err != nil {
+			_ = os.RemoveAll(tmp)
+			return err
+		}
+	}
+
+	data, err := json.Marshal(ss)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path.Join(tmp, remoteMarkerName), data, 0600)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmp, root)
+	if err != nil {
+		return err
+	}
+
+	t.cached[segmentId] = ss.size()
+	t.lastUsed[segmentId] = time.Now()
+	Log.Infof("fetched segment %s, %d bytes in %s", root, ss.size(), time.Since(started))
+	return nil
+}
+
+// must be called with m.loading held, removes the least recently used
+// fetched segments until the cache fits, loaded segments that are in use
+// are kept
+func (m *SearchIndex) trimCache() {
+	t := m.tiered
+	if t == nil || t.maxCacheBytes <= 0 {
+		return
+	}
+
+	total := int64(0)
+	ids := []string{}
+	for id, size := range t.cached {
+		total += size
+		ids = append(ids, id)
+	}
+	if total <= t.maxCacheBytes {
+		return
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return t.lastUsed[ids[i]].Before(t.lastUsed[ids[j]])
+	})
+
+	for _, id := range ids {
+		if total <= t.maxCacheBytes {
+			break
+		}
+
+		m.Lock()
+		s, loaded := m.Segments[id]
+		if loaded {
+			if !s.isIdle() {
+				m.Unlock()
+				continue
+			}
+			delete(m.Segments, id)
+		}
+		m.Unlock()
+		if loaded {
+			closeSegments([]*Segment{s})
+		}
+
+		root := path.Join(m.root, id)
+		size := t.cached[id]
+		delete(t.cached, id)
+		delete(t.lastUsed, id)
+
+		if _, err := os.Stat(path.Join(root, remoteMarkerName)); err != nil {
+			// written to since it was fetched, it is local now
+			continue
+		}
+
+		err := os.RemoveAll(root)
+		if err != nil {
+			Log.Warnf("failed to remove fetched segment %s, err: %s", root, err.Error())
+			continue
+		}
+		total -= size
+	}
+}
+
+// must be called with the segment lock held, the segment is about to be
+// modified so the remote copy is no longer up to date
+func (s *Segment) detachRemoteLocked() error {
+	if !s.fetched {
+		return nil
+	}
+
+	err := os.Remove(path.Join(s.root, remoteMarkerName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	s.fetched = false
+	return nil
+}
+
+// Offload uploads local segments that ended more than olderThan ago to the
+// store and removes them from disk, returns how many were moved
+func (m *SearchIndex) Offload(olderThan time.Duration) (int, error) {
+	m.loading.Lock()
+	t := m.tiered
+	m.loading.Unlock()
+	if t == nil {
+		return 0, nil
+	}
+
+	segments, err := m.ListSegments()
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-olderThan).UnixNano()
+	moved := 0
+	for _, ns := range segments {
+		if ns+m.SegmentStep*int64(time.Second) > cutoff {
+			continue
+		}
+
+		segmentId := m.toSegmentId(ns)
+		ok, err := m.offloadSegment(t, segmentId)
+		if err != nil {
+			return moved, err
+		}
+		if ok {
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+func (m *SearchIndex) offloadSegment(t *tieredStorage, segmentId string) (bool, error) {
+	root := path.Join(m.root, segmentId)
+	if _, err := os.Stat(path.Join(root, remoteMarkerName)); err == nil {
+		// fetched and not modified, the store already has it
+		return false, nil
+	}
+
+	// close it first so everything is synced and committed
+	m.loading.Lock()
+	m.Lock()
+	s, loaded := m.Segments[segmentId]
+	if loaded {
+		if !s.isIdle() {
+			m.Unlock()
+			m.loading.Unlock()
+			return false, nil
+		}
+		delete(m.Segments, segmentId)
+	}
+	m.Unlock()
+	if loaded {
+		closeSegments([]*Segment{s})
+	}
+	m.loading.Unlock()
+
+	started := time.Now()
+	ss, err := segmentFiles(root)
+	if err != nil {
+		return false, err
+	}
+
+	// empty segments are created by queries, they are just removed
+	empty := fileSize(ss, "main.bin") == 0
+	if !empty {
+		for _, f := range ss.Files {
+			err = uploadFile(t.store, path.Join(root, f.Name), t.key(segmentId, f.Name))
+			if err != nil {
+				return false, err
+			}
+		}
+
+		data, err := json.Marshal(ss)
+		if err != nil {
+			return false, err
+		}
+		err = t.store.Put(t.key(segmentId, remoteManifestName), bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return false, err
+		}
+	}
+
+	m.loading.Lock()
+	defer m.loading.Unlock()
+
+	if !empty {
+		t.remote[segmentId] = true
+	}
+
+	m.RLock()
+	_, changed := m.Segments[segmentId]
+	m.RUnlock()
+	if !changed {
+		now, err := segmentFiles(root)
+		if err != nil {
+			return false, err
+		}
+		changed = !sameFiles(ss, now)
+	}
+	if changed {
+		// opened or written while uploading, a forget, reindex or compaction
+		// could be missing from the store, so it must not keep that copy.
+		// The next Offload uploads the segment again.
+		if !empty {
+			err = m.deleteRemoteLocked(segmentId)
+			if err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+
+	// rename first so a partial removal is never opened as a segment
+	removed := root + ".offloaded"
+	err = os.Rename(root, removed)
+	if err != nil {
+		return false, err
+	}
+	err = os.RemoveAll(removed)
+	if err != nil {
+		return false, err
+	}
+
+	if !empty {
+		Log.Infof("offloaded segment %s, %d bytes in %s", root, ss.size(), time.Since(started))
+	}
+	return true, nil
+}
+
+func fileSize(ss *SnapshotSegment, name string) int64 {
+	for _, f := range ss.Files {
+		if f.Name == name {
+			return f.Size
+		}
+	}
+	return 0
+}
+
+func sameFiles(a, b *SnapshotSegment) bool {
+	if a.Committed != b.Committed || len(a.Files) != len(b.Files) {
+		return false
+	}
+	for i := range a.Files {
+		if a.Files[i] != b.Files[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// lists and checksums the files of a closed segment
+func segmentFiles(root string) (*SnapshotSegment, error) {
+	ss := &SnapshotSegment{Id: path.Base(root)}
+
+	commit, err := os.Open(path.Join(root, "main.commit"))
+	if err == nil {
+		ss.Committed, _ = readCommit(commit)
+		commit.Close()
+	}
+
+	add := func(fn string) error {
+		name, err := filepath.Rel(root, fn)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(fn)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		h := sha256.New()
+		n, err := io.Copy(h, f)
+		if err != nil {
+			return err
+		}
+		ss.Files = append(ss.Files, SnapshotFile{Name: filepath.ToSlash(name), Size: n, Sha256: hex.EncodeToString(h.Sum(nil))})
+		return nil
+	}
+
+	for _, name := range []string{"main.bin", "main.commit", "main.ids", "main.partitions", deletedName} {
+		err := add(path.Join(root, name))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	err = filepath.Walk(path.Join(root, "inv"), func(fn string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		return add(fn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
@@ -0,0 +1,265 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"github.com/rekki/blackrock/pkg/objectstore"
+	go_query_dsl "github.com/rekki/go-query-index-dsl"
+)
+
+func TestTieredStorage(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	store, err := objectstore.Open("file://" + path.Join(root, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	countSecondSegment := func(si *SearchIndex) int {
+		n := 0
+		query := &spec.SearchQueryRequest{FromSecond: 3601, ToSecond: 7199, Query: &go_query_dsl.Query{Field: "blackrock", Value: "match_all"}}
+		_, err := si.ForEach(context.Background(), query, 0, func(s *Segment, did int32, score float32) error {
+			n++
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	si := NewSearchIndex(path.Join(root, "a"), 10, 3600, false, map[string]bool{})
+	err = si.EnableTieredStorage(store, "node", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		err = si.Ingest(RandomEnvelope(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = si.Ingest(RandomEnvelope(3601 * int64(time.Second)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	moved, err := si.Offload(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 2 {
+		t.Fatalf("expected 2 offloaded segments, got %d", moved)
+	}
+	if _, err := os.Stat(path.Join(root, "a", "3600", "0")); !os.IsNotExist(err) {
+		t.Fatalf("expected the segment to be removed, got %v", err)
+	}
+
+	if n := countMatching(t, si, "blackrock", "match_all"); n != 100 {
+		t.Fatalf("expected 100 documents from the fetched segment, got %d", n)
+	}
+	if _, err := os.Stat(path.Join(root, "a", "3600", "0", remoteMarkerName)); err != nil {
+		t.Fatal(err)
+	}
+
+	// writing makes it local again and the next offload uploads it
+	err = si.Ingest(RandomEnvelope(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(root, "a", "3600", "0", remoteMarkerName)); !os.IsNotExist(err) {
+		t.Fatalf("expected the marker to be removed, got %v", err)
+	}
+	moved, err = si.Offload(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 1 {
+		t.Fatalf("expected 1 offloaded segment, got %d", moved)
+	}
+	si.Close()
+
+	si = NewSearchIndex(path.Join(root, "b"), 10, 3600, false, map[string]bool{})
+	defer si.Close()
+	err = si.EnableTieredStorage(store, "node", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := countMatching(t, si, "blackrock", "match_all"); n != 101 {
+		t.Fatalf("expected 101 documents, got %d", n)
+	}
+	if n := countSecondSegment(si); n != 100 {
+		t.Fatalf("expected 100 documents, got %d", n)
+	}
+
+	// the cache holds 1 byte, the least recently used segment is dropped
+	if _, err := os.Stat(path.Join(root, "b", "3600", "0")); !os.IsNotExist(err) {
+		t.Fatalf("expected the first segment to be dropped from the cache, got %v", err)
+	}
+	if n := countMatching(t, si, "blackrock", "match_all"); n != 101 {
+		t.Fatalf("expected 101 documents after fetching again, got %d", n)
+	}
+}
+
+type hookStore struct {
+	objectstore.Store
+	put    func(key string)
+	delete func(key string) error
+}
+
+func (s *hookStore) Put(key string, r io.Reader, size int64) error {
+	if s.put != nil {
+		s.put(key)
+	}
+	return s.Store.Put(key, r, size)
+}
+
+func (s *hookStore) Delete(key string) error {
+	if s.delete != nil {
+		err := s.delete(key)
+		if err != nil {
+			return err
+		}
+	}
+	return s.Store.Delete(key)
+}
+
+func TestDeleteRemoteManifestFirst(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	fs, err := objectstore.Open("file://" + path.Join(root, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the process dies after the first delete
+	deleted := 0
+	store := &hookStore{Store: fs, delete: func(key string) error {
+		deleted++
+		if deleted > 1 {
+			return errors.New("crashed")
+		}
+		return nil
+	}}
+
+	si := NewSearchIndex(path.Join(root, "a"), 10, 3600, false, map[string]bool{})
+	defer si.Close()
+	err = si.EnableTieredStorage(store, "node", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		err = si.Ingest(RandomEnvelope(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	moved, err := si.Offload(time.Hour)
+	if err != nil || moved != 1 {
+		t.Fatalf("expected 1 offloaded segment, got %d %v", moved, err)
+	}
+
+	si.loading.Lock()
+	err = si.deleteRemoteLocked("0")
+	si.loading.Unlock()
+	if err == nil {
+		t.Fatal("expected the delete to fail")
+	}
+	keys, err := fs.List("node/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) == 0 {
+		t.Fatal("expected the data to be left")
+	}
+	for _, k := range keys {
+		if path.Base(k) == remoteManifestName {
+			t.Fatalf("expected the manifest to be deleted first, got %v", keys)
+		}
+	}
+}
+
+func TestOffloadChangedWhileUploading(t *testing.T) {
+	root, err := ioutil.TempDir("", "si")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	fs, err := objectstore.Open("file://" + path.Join(root, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	si := NewSearchIndex(path.Join(root, "a"), 10, 3600, false, map[string]bool{})
+	defer si.Close()
+
+	forgotten := false
+	store := &hookStore{Store: fs, put: func(key string) {
+		if forgotten {
+			return
+		}
+		forgotten = true
+		// forgotten and closed again before the upload ends
+		n, _, err := si.Forget(context.Background(), "user", "1")
+		if err != nil || n != 10 {
+			t.Fatalf("expected 10 forgotten, got %d %v", n, err)
+		}
+		si.EvictIdle(0)
+	}}
+	err = si.EnableTieredStorage(store, "node", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		e := RandomEnvelope(1)
+		e.Metadata.ForeignType = "user"
+		e.Metadata.ForeignId = "1"
+		err = si.Ingest(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	moved, err := si.Offload(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 0 {
+		t.Fatalf("expected the changed segment to stay, got %d offloaded", moved)
+	}
+	keys, err := fs.List("node/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("expected the stale upload to be deleted, got %v", keys)
+	}
+
+	moved, err = si.Offload(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 1 {
+		t.Fatalf("expected 1 offloaded segment, got %d", moved)
+	}
+	if n := countMatching(t, si, "user", "1"); n != 0 {
+		t.Fatalf("expected the fetched segment to have the tombstones, got %d", n)
+	}
+}
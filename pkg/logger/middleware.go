@@ -20,20 +20,26 @@ func codeToLevel(code codes.Code) zapcore.Level {
 	return grpc_zap.DefaultCodeToLevel(code)
 }
 func AddLogging(opts []grpc.ServerOption) []grpc.ServerOption {
+	return AddLoggingWith(opts, nil, nil)
+}
+
+// AddLoggingWith chains the interceptors after the logging ones, so the
+// calls they reject are logged too, grpc takes only one chain per server
+func AddLoggingWith(opts []grpc.ServerOption, unary []grpc.UnaryServerInterceptor, stream []grpc.StreamServerInterceptor) []grpc.ServerOption {
 	o := []grpc_zap.Option{
 		grpc_zap.WithLevels(codeToLevel),
 	}
 	grpc_zap.ReplaceGrpcLogger(log)
 
-	opts = append(opts, grpc_middleware.WithUnaryServerChain(
+	opts = append(opts, grpc_middleware.WithUnaryServerChain(append([]grpc.UnaryServerInterceptor{
 		grpc_ctxtags.UnaryServerInterceptor(grpc_ctxtags.WithFieldExtractor(grpc_ctxtags.CodeGenRequestFieldExtractor)),
 		grpc_zap.UnaryServerInterceptor(log, o...),
-	))
+	}, unary...)...))
 
-	opts = append(opts, grpc_middleware.WithStreamServerChain(
+	opts = append(opts, grpc_middleware.WithStreamServerChain(append([]grpc.StreamServerInterceptor{
 		grpc_ctxtags.StreamServerInterceptor(grpc_ctxtags.WithFieldExtractor(grpc_ctxtags.CodeGenRequestFieldExtractor)),
 		grpc_zap.StreamServerInterceptor(log, o...),
-	))
+	}, stream...)...))
 
 	return opts
 }
@@ -0,0 +1,92 @@
+package objectstore
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type FileStore struct {
+	root string
+}
+
+func NewFileStore(root string) (*FileStore, error) {
+	err := os.MkdirAll(root, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{root: root}, nil
+}
+
+func (s *FileStore) Put(key string, r io.Reader, size int64) error {
+	fn := path.Join(s.root, key)
+	err := os.MkdirAll(path.Dir(fn), 0700)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(path.Dir(fn), ".put")
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tmp, r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), fn)
+}
+
+func (s *FileStore) Get(key string) (io.ReadCloser, error) {
+	f, err := os.Open(path.Join(s.root, key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *FileStore) List(prefix string) ([]string, error) {
+	out := []string{}
+	err := filepath.Walk(s.root, func(fn string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".put") {
+			return nil
+		}
+		key, err := filepath.Rel(s.root, fn)
+		if err != nil {
+			return err
+		}
+		key = filepath.ToSlash(key)
+		if strings.HasPrefix(key, prefix) {
+			out = append(out, key)
+		}
+		return nil
+	})
+	sort.Strings(out)
+	return out, err
+}
+
+func (s *FileStore) Delete(key string) error {
+	err := os.Remove(path.Join(s.root, key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
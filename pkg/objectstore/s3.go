@@ -0,0 +1,246 @@
+package objectstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Store talks to S3 compatible endpoints (aws, minio, ceph) using path
+// style addressing and signature v4, payloads are not signed so large
+// files can be streamed
+type S3Store struct {
+	endpoint  string
+	bucket    string
+	accessKey string
+	secretKey string
+	region    string
+	client    *http.Client
+}
+
+func NewS3Store(endpoint, bucket, accessKey, secretKey, region string) *S3Store {
+	return &S3Store{
+		endpoint:  strings.TrimRight(endpoint, "/"),
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		region:    region,
+		client:    &http.Client{},
+	}
+}
+
+func (s *S3Store) Put(key string, r io.Reader, size int64) error {
+	req, err := s.newRequest("PUT", key, nil, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Store) Get(key string) (io.ReadCloser, error) {
+	req, err := s.newRequest("GET", key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Store) Delete(key string) error {
+	req, err := s.newRequest("DELETE", key, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+}
+
+func (s *S3Store) List(prefix string) ([]string, error) {
+	out := []string{}
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		req, err := s.newRequest("GET", "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := s.do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		result := listBucketResult{}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range result.Contents {
+			out = append(out, c.Key)
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func (s *S3Store) do(req *http.Request) (*http.Response, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s, %s", req.Method, req.URL.Path, resp.Status, string(body))
+	}
+	return resp, nil
+}
+
+func (s *S3Store) newRequest(method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	uri := "/" + escapePath(s.bucket)
+	if key != "" {
+		uri += "/" + escapePath(key)
+	}
+
+	rawQuery := canonicalQuery(query)
+	u := s.endpoint + uri
+	if rawQuery != "" {
+		u += "?" + rawQuery
+	}
+
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, uri, rawQuery, time.Now().UTC())
+	return req, nil
+}
+
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+func (s *S3Store) sign(req *http.Request, uri, rawQuery string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", unsignedPayload)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		uri,
+		rawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + unsignedPayload,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256hex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// aws wants everything but the unreserved characters escaped, with %20 for space
+func escape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+func escapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = escape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := []string{}
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
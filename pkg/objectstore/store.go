@@ -0,0 +1,52 @@
+package objectstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Store is the minimal interface blackrock needs from an object store,
+// keys are slash separated paths
+type Store interface {
+	Put(key string, r io.Reader, size int64) error
+	Get(key string) (io.ReadCloser, error)
+	List(prefix string) ([]string, error)
+	Delete(key string) error
+}
+
+// Open creates a store from url:
+//
+//	file:///var/backup
+//	s3://access_key:secret_key@minio:9000/bucket?region=us-east-1&insecure=true
+func Open(rawurl string) (Store, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "file":
+		return NewFileStore(u.Path)
+	case "s3":
+		bucket := strings.Trim(u.Path, "/")
+		if bucket == "" || u.Host == "" {
+			return nil, fmt.Errorf("expected s3://key:secret@host/bucket, got %s", rawurl)
+		}
+		secret, _ := u.User.Password()
+		region := u.Query().Get("region")
+		if region == "" {
+			region = "us-east-1"
+		}
+		scheme := "https"
+		if u.Query().Get("insecure") == "true" {
+			scheme = "http"
+		}
+		return NewS3Store(scheme+"://"+u.Host, bucket, u.User.Username(), secret, region), nil
+	}
+	return nil, fmt.Errorf("unsupported store %s, expected file:// or s3://", rawurl)
+}
@@ -0,0 +1,158 @@
+package objectstore
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is a minimal in memory stand-in for minio, it paginates the
+// listing after 2 keys to exercise continuation tokens
+type fakeS3 struct {
+	objects map[string][]byte
+	sync.Mutex
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") || r.Header.Get("x-amz-date") == "" {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if parts[0] != "bucket" {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+
+	if len(parts) == 1 {
+		prefix := r.URL.Query().Get("prefix")
+		keys := []string{}
+		for k := range f.objects {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		start := 0
+		fmt.Sscanf(r.URL.Query().Get("continuation-token"), "%d", &start)
+		result := listBucketResult{}
+		for i := start; i < len(keys) && i < start+2; i++ {
+			result.Contents = append(result.Contents, struct {
+				Key string `xml:"Key"`
+			}{keys[i]})
+		}
+		if start+2 < len(keys) {
+			result.IsTruncated = true
+			result.NextContinuationToken = fmt.Sprintf("%d", start+2)
+		}
+		_ = xml.NewEncoder(w).Encode(result)
+		return
+	}
+
+	key := parts[1]
+	switch r.Method {
+	case "PUT":
+		data, _ := ioutil.ReadAll(r.Body)
+		f.objects[key] = data
+	case "GET":
+		data, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case "DELETE":
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func testStore(t *testing.T, s Store) {
+	keys := []string{"a/1", "a/2 with space", "a/b/3", "b/4"}
+	for _, k := range keys {
+		data := []byte("data of " + k)
+		err := s.Put(k, bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, k := range keys {
+		r, err := s.Get(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "data of "+k {
+			t.Fatalf("unexpected data for %s: %s", k, data)
+		}
+	}
+
+	listed, err := s.List("a/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(listed, ",") != "a/1,a/2 with space,a/b/3" {
+		t.Fatalf("unexpected list %v", listed)
+	}
+
+	err = s.Delete("a/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Get("a/1")
+	if err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	err = s.Delete("a/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	s, err := Open("file://" + root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, s)
+}
+
+func TestS3Store(t *testing.T) {
+	server := httptest.NewServer(&fakeS3{objects: map[string][]byte{}})
+	defer server.Close()
+
+	s, err := Open("s3://key:secret@" + strings.TrimPrefix(server.URL, "http://") + "/bucket?insecure=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, s)
+
+	_, err = Open("s3://key:secret@localhost")
+	if err == nil {
+		t.Fatal("expected error without bucket")
+	}
+}
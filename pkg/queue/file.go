@@ -0,0 +1,656 @@
+package queue
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofrs/flock"
+	. "github.com/rekki/blackrock/pkg/logger"
+)
+
+// FileQueue is a durable queue on local disk, for deployments without a
+// broker and for tests. Every partition is a directory of append only
+// segments named after the offset of their first message:
+//
+//	root/<topic>/<partition>/00000000000000000000.log
+//
+// A record is [payload length uint32][crc32 of payload][payload], the
+// payload holds the offset, the key, the headers and the value. Only one
+// process can write a topic, readers in other processes poll for new
+// records. Old segments are never deleted by the queue.
+type FileQueue struct {
+	root       string
+	partitions int
+
+	// writers of the same topic in this process share the lock and the files
+	writers map[string]*fileWriter
+	sync.Mutex
+}
+
+var (
+	fileSegmentBytes int64 = 64 * 1024 * 1024
+	filePollInterval       = 100 * time.Millisecond
+)
+
+const (
+	fileRecordHeader   = 8
+	fileMaxRecordBytes = 256 * 1024 * 1024
+)
+
+func NewFileQueue(root string, partitions int) (*FileQueue, error) {
+	err := os.MkdirAll(root, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &FileQueue{root: root, partitions: partitions, writers: map[string]*fileWriter{}}, nil
+}
+
+func segmentName(base int64) string {
+	return fmt.Sprintf("%020d.log", base)
+}
+
+// bases of the segments of a partition, sorted
+func listSegments(dir string) ([]int64, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	out := []int64{}
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".log") {
+			continue
+		}
+		base, err := strconv.ParseInt(strings.TrimSuffix(f.Name(), ".log"), 10, 64)
+		if err != nil {
+			continue
+		}
+		out = append(out, base)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out, nil
+}
+
+func (q *FileQueue) topicDir(topic string) string {
+	return path.Join(q.root, topic)
+}
+
+func (q *FileQueue) partitionDir(topic string, partition int) string {
+	return path.Join(q.root, topic, strconv.Itoa(partition))
+}
+
+// Partitions creates the topic with the configured number of partitions
+// if it does not exist, an existing topic keeps its partitions
+func (q *FileQueue) Partitions(topic string) ([]int, error) {
+	dir := q.topicDir(topic)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	out := []int{}
+	for _, f := range files {
+		p, err := strconv.Atoi(f.Name())
+		if err == nil && f.IsDir() {
+			out = append(out, p)
+		}
+	}
+	if len(out) > 0 {
+		sort.Ints(out)
+		return out, nil
+	}
+
+	for p := 0; p < q.partitions; p++ {
+		err := os.MkdirAll(q.partitionDir(topic, p), 0700)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (q *FileQueue) Health(topic string) error {
+	_, err := q.Partitions(topic)
+	return err
+}
+
+func (q *FileQueue) Offsets(topic string, partition int) (int64, int64, error) {
+	dir := q.partitionDir(topic, partition)
+	segments, err := listSegments(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(segments) == 0 {
+		return 0, 0, nil
+	}
+
+	last := segments[len(segments)-1]
+	n, _, err := scanSegment(path.Join(dir, segmentName(last)), -1)
+	if err != nil {
+		return 0, 0, err
+	}
+	return segments[0], last + n, nil
+}
+
+func encodeRecord(offset int64, m Message) []byte {
+	size := 8 + 4 + len(m.Key) + 2 + len(m.Value)
+	for _, h := range m.Headers {
+		size += 2 + len(h.Key) + 4 + len(h.Value)
+	}
+
+	out := make([]byte, fileRecordHeader+size)
+	p := out[fileRecordHeader:]
+	binary.LittleEndian.PutUint64(p, uint64(offset))
+	binary.LittleEndian.PutUint32(p[8:], uint32(len(m.Key)))
+	i := 12 + copy(p[12:], m.Key)
+	binary.LittleEndian.PutUint16(p[i:], uint16(len(m.Headers)))
+	i += 2
+	for _, h := range m.Headers {
+		binary.LittleEndian.PutUint16(p[i:], uint16(len(h.Key)))
+		i += 2 + copy(p[i+2:], h.Key)
+		binary.LittleEndian.PutUint32(p[i:], uint32(len(h.Value)))
+		i += 4 + copy(p[i+4:], h.Value)
+	}
+	copy(p[i:], m.Value)
+
+	binary.LittleEndian.PutUint32(out, uint32(size))
+	binary.LittleEndian.PutUint32(out[4:], crc32.ChecksumIEEE(p))
+	return out
+}
+
+var errBadRecord = errors.New("bad record")
+
+func decodeRecord(p []byte) (Message, error) {
+	m := Message{}
+	if len(p) < 14 {
+		return m, errBadRecord
+	}
+	m.Offset = int64(binary.LittleEndian.Uint64(p))
+	keyLen := int(binary.LittleEndian.Uint32(p[8:]))
+	i := 12
+	if len(p) < i+keyLen+2 {
+		return m, errBadRecord
+	}
+	m.Key = p[i : i+keyLen]
+	i += keyLen
+	n := int(binary.LittleEndian.Uint16(p[i:]))
+	i += 2
+	for j := 0; j < n; j++ {
+		if len(p) < i+2 {
+			return m, errBadRecord
+		}
+		kl := int(binary.LittleEndian.Uint16(p[i:]))
+		i += 2
+		if len(p) < i+kl+4 {
+			return m, errBadRecord
+		}
+		k := string(p[i : i+kl])
+		i += kl
+		vl := int(binary.LittleEndian.Uint32(p[i:]))
+		i += 4
+		if len(p) < i+vl {
+			return m, errBadRecord
+		}
+		m.Headers = append(m.Headers, Header{Key: k, Value: p[i : i+vl]})
+		i += vl
+	}
+	m.Value = p[i:]
+	return m, nil
+}
+
+// CorruptRecordError is a complete record that does not match its
+// checksum, unlike a torn record it is not fixed by waiting or by
+// truncating the segment
+type CorruptRecordError struct {
+	File string
+	Pos  int64
+}
+
+func (e *CorruptRecordError) Error() string {
+	return fmt.Sprintf("%s: corrupt record at %d", e.File, e.Pos)
+}
+
+// readRecord returns io.EOF when there is no complete record at pos, a
+// record being written looks the same as a torn one
+func readRecord(f *os.File, pos int64) (Message, int64, error) {
+	header := make([]byte, fileRecordHeader)
+	_, err := f.ReadAt(header, pos)
+	if err != nil {
+		return Message{}, 0, io.EOF
+	}
+	size := int64(binary.LittleEndian.Uint32(header))
+	if size > fileMaxRecordBytes {
+		return Message{}, 0, &CorruptRecordError{File: f.Name(), Pos: pos}
+	}
+	payload := make([]byte, size)
+	_, err = f.ReadAt(payload, pos+fileRecordHeader)
+	if err != nil {
+		return Message{}, 0, io.EOF
+	}
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:]) {
+		return Message{}, 0, &CorruptRecordError{File: f.Name(), Pos: pos}
+	}
+	m, err := decodeRecord(payload)
+	if err != nil {
+		return Message{}, 0, err
+	}
+	return m, pos + fileRecordHeader + size, nil
+}
+
+// scanSegment counts the complete records and returns the position
+// after them, or the position of the record at offset if it is >= 0. A
+// corrupt record is returned with the count and position before it.
+func scanSegment(fn string, offset int64) (int64, int64, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	n := int64(0)
+	pos := int64(0)
+	for {
+		m, next, err := readRecord(f, pos)
+		if err == io.EOF {
+			return n, pos, nil
+		}
+		if _, ok := err.(*CorruptRecordError); ok {
+			return n, pos, err
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("%s at %d: %s", fn, pos, err.Error())
+		}
+		if offset >= 0 && m.Offset >= offset {
+			return n, pos, nil
+		}
+		n++
+		pos = next
+	}
+}
+
+// tornTail tells if the corrupt record at pos is the last one of the
+// segment, the crash of the writer left it half synced
+func tornTail(fn string, pos int64) (bool, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	header := make([]byte, fileRecordHeader)
+	_, err = f.ReadAt(header, pos)
+	if err != nil {
+		return false, err
+	}
+	size := int64(binary.LittleEndian.Uint32(header))
+	return pos+fileRecordHeader+size >= st.Size(), nil
+}
+
+type filePartition struct {
+	dir  string
+	f    *os.File
+	next int64
+	size int64
+	sync.Mutex
+}
+
+// opens the last segment and drops a torn record at its end, a corrupt
+// record before the end is an error
+func openPartition(dir string) (*filePartition, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	base := int64(0)
+	if len(segments) > 0 {
+		base = segments[len(segments)-1]
+	}
+	fn := path.Join(dir, segmentName(base))
+	n, size := int64(0), int64(0)
+	if len(segments) > 0 {
+		n, size, err = scanSegment(fn, -1)
+		if _, ok := err.(*CorruptRecordError); ok {
+			torn, terr := tornTail(fn, size)
+			if terr != nil {
+				return nil, terr
+			}
+			if torn {
+				err = nil
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		st, err := os.Stat(fn)
+		if err != nil {
+			return nil, err
+		}
+		if st.Size() > size {
+			Log.Warnf("%s: dropping %d bytes of a torn record at %d", fn, st.Size()-size, size)
+			err = os.Truncate(fn, size)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	f, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &filePartition{dir: dir, f: f, next: base + n, size: size}, nil
+}
+
+func (p *filePartition) append(messages []Message) error {
+	p.Lock()
+	defer p.Unlock()
+
+	for _, m := range messages {
+		if p.size >= fileSegmentBytes {
+			err := p.roll()
+			if err != nil {
+				return err
+			}
+		}
+
+		record := encodeRecord(p.next, m)
+		_, err := p.f.Write(record)
+		if err != nil {
+			return err
+		}
+		p.next++
+		p.size += int64(len(record))
+	}
+	return p.f.Sync()
+}
+
+// must be called with the lock held
+func (p *filePartition) roll() error {
+	err := p.f.Sync()
+	if err != nil {
+		return err
+	}
+	err = p.f.Close()
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path.Join(p.dir, segmentName(p.next)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	p.f = f
+	p.size = 0
+	return nil
+}
+
+type fileWriterStats struct {
+	Messages int64 `json:"messages"`
+	Writes   int64 `json:"writes"`
+	Errors   int64 `json:"errors"`
+}
+
+type fileWriter struct {
+	q          *FileQueue
+	topic      string
+	refs       int
+	partitions []*filePartition
+	lock       *flock.Flock
+	next       uint32
+	stats      fileWriterStats
+}
+
+// the writer syncs before returning, async is ignored
+func (q *FileQueue) Writer(topic string, async bool) (Writer, error) {
+	q.Lock()
+	defer q.Unlock()
+
+	if w, ok := q.writers[topic]; ok {
+		w.refs++
+		return w, nil
+	}
+
+	ids, err := q.Partitions(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := flock.New(path.Join(q.topicDir(topic), "writer.lock"))
+	locked, err := lock.TryLock()
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, fmt.Errorf("topic %s is already written by another process", topic)
+	}
+
+	w := &fileWriter{q: q, topic: topic, refs: 1, lock: lock}
+	for _, id := range ids {
+		p, err := openPartition(q.partitionDir(topic, id))
+		if err != nil {
+			w.close()
+			return nil, err
+		}
+		w.partitions = append(w.partitions, p)
+	}
+	q.writers[topic] = w
+	return w, nil
+}
+
+// messages with a key always go to the same partition, the others are
+// spread round robin
+func (w *fileWriter) Write(ctx context.Context, messages ...Message) error {
+	perPartition := map[int][]Message{}
+	for _, m := range messages {
+		p := 0
+		if len(m.Key) > 0 {
+			p = KeyPartition(m.Key, len(w.partitions))
+		} else {
+			p = int(atomic.AddUint32(&w.next, 1) % uint32(len(w.partitions)))
+		}
+		perPartition[p] = append(perPartition[p], m)
+	}
+
+	atomic.AddInt64(&w.stats.Writes, 1)
+	for p, batch := range perPartition {
+		err := w.partitions[p].append(batch)
+		if err != nil {
+			atomic.AddInt64(&w.stats.Errors, 1)
+			return err
+		}
+		atomic.AddInt64(&w.stats.Messages, int64(len(batch)))
+	}
+	return nil
+}
+
+func (w *fileWriter) Stats() interface{} {
+	return fileWriterStats{
+		Messages: atomic.LoadInt64(&w.stats.Messages),
+		Writes:   atomic.LoadInt64(&w.stats.Writes),
+		Errors:   atomic.LoadInt64(&w.stats.Errors),
+	}
+}
+
+func (w *fileWriter) Close() error {
+	w.q.Lock()
+	defer w.q.Unlock()
+
+	w.refs--
+	if w.refs > 0 {
+		return nil
+	}
+	delete(w.q.writers, w.topic)
+	return w.close()
+}
+
+func (w *fileWriter) close() error {
+	var lastError error
+	for _, p := range w.partitions {
+		p.Lock()
+		err := p.f.Close()
+		p.Unlock()
+		if err != nil {
+			lastError = err
+		}
+	}
+	w.lock.Close()
+	return lastError
+}
+
+type fileReader struct {
+	topic     string
+	partition int
+	dir       string
+	f         *os.File
+	base      int64
+	pos       int64
+	offset    int64
+	closed    int32
+}
+
+func (q *FileQueue) Reader(topic string, partition int) (Reader, error) {
+	dir := q.partitionDir(topic, partition)
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	r := &fileReader{topic: topic, partition: partition, dir: dir}
+	return r, r.SetOffset(FirstOffset)
+}
+
+func (r *fileReader) SetOffset(offset int64) error {
+	if atomic.LoadInt32(&r.closed) == 1 {
+		return ErrClosed
+	}
+
+	segments, err := listSegments(r.dir)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		// nothing written yet
+		segments = []int64{0}
+	}
+
+	if offset == FirstOffset || (offset != LastOffset && offset < segments[0]) {
+		offset = segments[0]
+	}
+
+	base := segments[0]
+	for _, s := range segments {
+		if s <= offset || offset == LastOffset {
+			base = s
+		}
+	}
+
+	fn := path.Join(r.dir, segmentName(base))
+	pos := int64(0)
+	if offset == LastOffset {
+		n := int64(0)
+		n, pos, err = scanSegment(fn, -1)
+		offset = base + n
+	} else {
+		_, pos, err = scanSegment(fn, offset)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if r.f != nil {
+		r.f.Close()
+		r.f = nil
+	}
+	r.base = base
+	r.pos = pos
+	r.offset = offset
+	return nil
+}
+
+func (r *fileReader) open() error {
+	if r.f != nil {
+		return nil
+	}
+	f, err := os.Open(path.Join(r.dir, segmentName(r.base)))
+	if err != nil {
+		return err
+	}
+	r.f = f
+	return nil
+}
+
+func (r *fileReader) Fetch(ctx context.Context) (Message, error) {
+	for {
+		if atomic.LoadInt32(&r.closed) == 1 {
+			if r.f != nil {
+				r.f.Close()
+				r.f = nil
+			}
+			return Message{}, ErrClosed
+		}
+
+		err := r.open()
+		if err != nil && !os.IsNotExist(err) {
+			return Message{}, err
+		}
+
+		if r.f != nil {
+			m, next, err := readRecord(r.f, r.pos)
+			if err == nil {
+				r.pos = next
+				if m.Offset < r.offset {
+					// SetOffset was after the end of the partition
+					continue
+				}
+				r.offset = m.Offset + 1
+				m.Topic = r.topic
+				m.Partition = r.partition
+				return m, nil
+			}
+			if err != io.EOF {
+				return Message{}, err
+			}
+
+			// the writer moves to a new segment only after the current one is complete
+			_, err = os.Stat(path.Join(r.dir, segmentName(r.offset)))
+			if err == nil && r.offset != r.base {
+				r.f.Close()
+				r.f = nil
+				r.base = r.offset
+				r.pos = 0
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		case <-time.After(filePollInterval):
+		}
+	}
+}
+
+func (r *fileReader) Close() error {
+	atomic.StoreInt32(&r.closed, 1)
+	return nil
+}
@@ -0,0 +1,228 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/rekki/blackrock/pkg/logger"
+)
+
+func init() {
+	logger.LogInit(3)
+}
+
+func TestFileQueue(t *testing.T) {
+	fileSegmentBytes = 1024
+	filePollInterval = 5 * time.Millisecond
+
+	root, err := ioutil.TempDir("", "queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	q, err := Open("file://" + root + "?partitions=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := q.Writer("data", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Open("file://" + root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = other.Writer("data", false)
+	if err == nil {
+		t.Fatal("expected a writer from another queue to fail")
+	}
+	shared, err := q.Writer("data", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared.Close()
+
+	write := func(w Writer, from, to int) {
+		for i := from; i < to; i++ {
+			err := w.Write(context.Background(), Message{Key: []byte("k"), Value: []byte(fmt.Sprintf("value %d", i)), Headers: []Header{{Key: "h", Value: []byte("v")}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	write(w, 0, 100)
+	w.Close()
+
+	partitions, err := q.Partitions("data")
+	if err != nil || len(partitions) != 2 {
+		t.Fatalf("expected 2 partitions, got %v %v", partitions, err)
+	}
+
+	// the same key always goes to the same partition
+	p := -1
+	for _, id := range partitions {
+		first, last, err := q.Offsets("data", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last == 100 && first == 0 {
+			p = id
+		}
+	}
+	if p < 0 {
+		t.Fatal("expected all messages in one partition")
+	}
+	segments, _ := listSegments(path.Join(root, "data", fmt.Sprintf("%d", p)))
+	if len(segments) < 2 {
+		t.Fatalf("expected the partition to roll, got %v", segments)
+	}
+
+	// a torn record at the end is dropped by the next writer
+	dir := path.Join(root, "data", fmt.Sprintf("%d", p))
+	fn := path.Join(dir, segmentName(segments[len(segments)-1]))
+	f, err := os.OpenFile(fn, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{100, 0, 0, 0, 1, 2})
+	f.Close()
+
+	w, err = q.Writer("data", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	r, err := q.Reader("data", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.SetOffset(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		write(w, 100, 110)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i := 42; i < 110; i++ {
+		m, err := r.Fetch(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Offset != int64(i) || string(m.Value) != fmt.Sprintf("value %d", i) || m.Partition != p || len(m.Headers) != 1 || string(m.Headers[0].Value) != "v" {
+			t.Fatalf("unexpected message at %d: %v", i, m)
+		}
+	}
+
+	short, cancelShort := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelShort()
+	_, err = r.Fetch(short)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected to wait for new messages, got %v", err)
+	}
+
+	err = r.SetOffset(LastOffset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(w, 110, 111)
+	m, err := r.Fetch(ctx)
+	if err != nil || m.Offset != 110 {
+		t.Fatalf("expected offset 110 after LastOffset, got %v %v", m.Offset, err)
+	}
+
+	r.Close()
+	_, err = r.Fetch(ctx)
+	if err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestFileQueueCorruptRecord(t *testing.T) {
+	root, err := ioutil.TempDir("", "queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	q, err := NewFileQueue(root, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(from, to int) {
+		w, err := q.Writer("data", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Close()
+		for i := from; i < to; i++ {
+			err := w.Write(context.Background(), Message{Value: []byte(fmt.Sprintf("value %d", i))})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	write(0, 3)
+
+	fn := path.Join(root, "data", "0", segmentName(0))
+	flip := func(pos int64) {
+		t.Helper()
+		f, err := os.OpenFile(fn, os.O_RDWR, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		b := make([]byte, 1)
+		_, err = f.ReadAt(b, pos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b[0] ^= 0xff
+		_, err = f.WriteAt(b, pos)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	size := func() int64 {
+		st, err := os.Stat(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st.Size()
+	}
+
+	// a bad checksum in the last record is a torn tail, the writer drops it
+	before := size()
+	flip(before - 1)
+	write(3, 4)
+	if size() != before {
+		t.Fatalf("expected the torn record to be replaced, got %d bytes instead of %d", size(), before)
+	}
+
+	// a bad checksum before the end is not truncated
+	flip(fileRecordHeader)
+	_, err = q.Writer("data", false)
+	if _, ok := err.(*CorruptRecordError); !ok {
+		t.Fatalf("expected a corrupt record, got %v", err)
+	}
+	if size() != before {
+		t.Fatalf("expected nothing to be truncated, got %d bytes instead of %d", size(), before)
+	}
+
+	_, err = q.Reader("data", 0)
+	if e, ok := err.(*CorruptRecordError); !ok || e.Pos != 0 {
+		t.Fatalf("expected a corrupt record at 0, got %v", err)
+	}
+}
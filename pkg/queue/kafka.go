@@ -0,0 +1,158 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rekki/blackrock/pkg/depths"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/snappy"
+)
+
+type KafkaQueue struct {
+	brokers []string
+}
+
+func NewKafkaQueue(brokers []string) *KafkaQueue {
+	return &KafkaQueue{brokers: brokers}
+}
+
+type kafkaWriter struct {
+	kw *kafka.Writer
+}
+
+func (q *KafkaQueue) Writer(topic string, async bool) (Writer, error) {
+	batchTimeout := 10 * time.Millisecond
+	if async {
+		batchTimeout = 1 * time.Second
+	}
+	kw := kafka.NewWriter(kafka.WriterConfig{
+		Brokers:          q.brokers,
+		Topic:            topic,
+		Balancer:         &KeyBalancer{},
+		BatchTimeout:     batchTimeout,
+		CompressionCodec: snappy.NewCompressionCodec(),
+		Async:            async,
+	})
+	return &kafkaWriter{kw: kw}, nil
+}
+
+// KeyBalancer sends the messages with the same key to the same partition,
+// using the same hash as the file queue, messages without a key go to the
+// partition with the least bytes
+type KeyBalancer struct {
+	leastBytes kafka.LeastBytes
+}
+
+func (b *KeyBalancer) Balance(msg kafka.Message, partitions ...int) int {
+	if len(msg.Key) == 0 {
+		return b.leastBytes.Balance(msg, partitions...)
+	}
+	if !sort.IntsAreSorted(partitions) {
+		partitions = append([]int{}, partitions...)
+		sort.Ints(partitions)
+	}
+	return partitions[KeyPartition(msg.Key, len(partitions))]
+}
+
+func (w *kafkaWriter) Write(ctx context.Context, messages ...Message) error {
+	out := make([]kafka.Message, len(messages))
+	for i, m := range messages {
+		out[i] = kafka.Message{Key: m.Key, Value: m.Value}
+		for _, h := range m.Headers {
+			out[i].Headers = append(out[i].Headers, kafka.Header{Key: h.Key, Value: h.Value})
+		}
+	}
+	return w.kw.WriteMessages(ctx, out...)
+}
+
+func (w *kafkaWriter) Stats() interface{} {
+	return w.kw.Stats()
+}
+
+func (w *kafkaWriter) Close() error {
+	return w.kw.Close()
+}
+
+type kafkaReader struct {
+	rd *kafka.Reader
+}
+
+func (q *KafkaQueue) Reader(topic string, partition int) (Reader, error) {
+	rd := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   q.brokers,
+		Topic:     topic,
+		MaxWait:   1 * time.Second,
+		Partition: partition,
+	})
+	return &kafkaReader{rd: rd}, nil
+}
+
+func (r *kafkaReader) SetOffset(offset int64) error {
+	err := r.rd.SetOffset(offset)
+	if err == io.ErrClosedPipe {
+		return ErrClosed
+	}
+	return err
+}
+
+func (r *kafkaReader) Fetch(ctx context.Context) (Message, error) {
+	m, err := r.rd.FetchMessage(ctx)
+	if err == io.EOF {
+		return Message{}, ErrClosed
+	}
+	if err != nil {
+		return Message{}, err
+	}
+
+	out := Message{Topic: m.Topic, Partition: m.Partition, Offset: m.Offset, Key: m.Key, Value: m.Value}
+	for _, h := range m.Headers {
+		out.Headers = append(out.Headers, Header{Key: h.Key, Value: h.Value})
+	}
+	return out, nil
+}
+
+func (r *kafkaReader) Close() error {
+	return r.rd.Close()
+}
+
+func (q *KafkaQueue) Partitions(topic string) ([]int, error) {
+	for _, b := range depths.ShuffledStrings(q.brokers) {
+		conn, err := kafka.Dial("tcp", b)
+		if err == nil {
+			partitions, err := conn.ReadPartitions(topic)
+			conn.Close()
+			if err == nil {
+				out := []int{}
+				for _, p := range partitions {
+					out = append(out, p.ID)
+				}
+				return out, nil
+			}
+		}
+	}
+	return nil, errors.New("failed to get partitions, assuming we cant reach kafka")
+}
+
+func (q *KafkaQueue) Offsets(topic string, partition int) (int64, int64, error) {
+	for _, b := range depths.ShuffledStrings(q.brokers) {
+		conn, err := kafka.DialLeader(context.Background(), "tcp", b, topic, partition)
+		if err != nil {
+			continue
+		}
+		first, last, err := conn.ReadOffsets()
+		conn.Close()
+		if err == nil {
+			return first, last, nil
+		}
+	}
+	return 0, 0, errors.New("failed to read the offsets, assuming we cant reach kafka")
+}
+
+func (q *KafkaQueue) Health(topic string) error {
+	return depths.HealthCheckKafka(strings.Join(q.brokers, ","), topic)
+}
@@ -0,0 +1,36 @@
+package queue
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestKeyBalancer(t *testing.T) {
+	b := &KeyBalancer{}
+	partitions := []int{0, 1, 2, 3}
+	seen := map[int]bool{}
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("user:%d", i))
+		p := b.Balance(kafka.Message{Key: key}, partitions...)
+		if p != KeyPartition(key, len(partitions)) {
+			t.Fatalf("expected the same partition as the file queue for %s", key)
+		}
+		if b.Balance(kafka.Message{Key: key}, 3, 1, 0, 2) != p {
+			t.Fatalf("partition order changed the partition of %s", key)
+		}
+		seen[p] = true
+	}
+	if len(seen) != len(partitions) {
+		t.Fatalf("expected keys on every partition, got %v", seen)
+	}
+
+	seen = map[int]bool{}
+	for i := 0; i < 8; i++ {
+		seen[b.Balance(kafka.Message{Value: []byte("x")}, partitions...)] = true
+	}
+	if len(seen) != len(partitions) {
+		t.Fatalf("expected messages without key on every partition, got %v", seen)
+	}
+}
@@ -0,0 +1,97 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rekki/blackrock/pkg/depths"
+)
+
+// same values as kafka
+const (
+	FirstOffset int64 = -2
+	LastOffset  int64 = -1
+)
+
+var ErrClosed = errors.New("queue reader is closed")
+
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+type Message struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   []Header
+}
+
+// Writer appends messages to a topic, the partition is picked by the
+// queue, Partition and Offset of the messages are ignored
+type Writer interface {
+	Write(ctx context.Context, messages ...Message) error
+	Stats() interface{}
+	Close() error
+}
+
+// Reader reads one partition of a topic in order
+type Reader interface {
+	// SetOffset accepts FirstOffset and LastOffset
+	SetOffset(offset int64) error
+	// Fetch blocks until there is a message or ctx is done
+	Fetch(ctx context.Context) (Message, error)
+	Close() error
+}
+
+// Queue is where the producer sends envelopes and the consumer reads them
+type Queue interface {
+	// Writer with async set returns before the messages are stored
+	Writer(topic string, async bool) (Writer, error)
+	Reader(topic string, partition int) (Reader, error)
+	Partitions(topic string) ([]int, error)
+	// Offsets returns the first offset and the offset of the next message
+	Offsets(topic string, partition int) (int64, int64, error)
+	Health(topic string) error
+}
+
+// Open creates a queue from url:
+//
+//	kafka://localhost:9092,localhost:9093
+//	file:///var/blackrock/queue?partitions=4
+func Open(rawurl string) (Queue, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "kafka":
+		if u.Host == "" {
+			return nil, fmt.Errorf("expected kafka://host:port,host:port, got %s", rawurl)
+		}
+		return NewKafkaQueue(strings.Split(u.Host, ",")), nil
+	case "file":
+		partitions := 1
+		if p := u.Query().Get("partitions"); p != "" {
+			partitions, err = strconv.Atoi(p)
+			if err != nil || partitions < 1 {
+				return nil, fmt.Errorf("bad number of partitions %q", p)
+			}
+		}
+		return NewFileQueue(u.Path, partitions)
+	}
+	return nil, fmt.Errorf("unsupported queue %s, expected kafka:// or file://", rawurl)
+}
+
+// KeyPartition is the partition index of a message key, both queues use it
+// so the same key lands on the same partition number in kafka and on disk
+func KeyPartition(key []byte, partitions int) int {
+	return int(depths.Hash(key) % uint64(partitions))
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/rekki/blackrock/pkg/auth"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Client identifies the caller by the name of its api key, or else by
+// its ip. Calls from the http gateway in the same process come from
+// loopback, the gateway appends the address of the http client to
+// x-forwarded-for, what is before it is trusted only from TrustedProxies.
+func Client(ctx context.Context) string {
+	if key := auth.FromContext(ctx); key != nil {
+		return "key:" + key.Name()
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	remote := host(p.Addr.String())
+	if ip := net.ParseIP(remote); ip != nil && ip.IsLoopback() {
+		md, _ := metadata.FromIncomingContext(ctx)
+		hops := splitHops(md.Get("x-forwarded-for"))
+		if len(hops) > 0 {
+			return "ip:" + TrustedProxies.ClientIP(hops[len(hops)-1], hops[:len(hops)-1])
+		}
+	}
+	return "ip:" + remote
+}
+
+// Exhausted is the error for a client over its limit, with a RetryInfo
+// detail when waiting helps
+func Exhausted(client string, what string, retry time.Duration) error {
+	msg := fmt.Sprintf("%s: rate limit exceeded for %s", what, client)
+	if retry > 0 {
+		msg += fmt.Sprintf(", retry in %s", retry.Round(time.Millisecond))
+	}
+	st := status.New(codes.ResourceExhausted, msg)
+	if retry > 0 {
+		withDetails, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(retry)})
+		if err == nil {
+			return withDetails.Err()
+		}
+	}
+	return st.Err()
+}
+
+// UnaryServerInterceptor takes one token per call of the methods
+func UnaryServerInterceptor(l *Limiter, methods map[string]bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if methods[info.FullMethod] {
+			client := Client(ctx)
+			ok, retry := l.Allow(client)
+			if !ok {
+				return nil, Exhausted(client, info.FullMethod, retry)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor takes one token per stream of the methods
+func StreamServerInterceptor(l *Limiter, methods map[string]bool) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if methods[info.FullMethod] {
+			client := Client(ss.Context())
+			ok, retry := l.Allow(client)
+			if !ok {
+				return Exhausted(client, info.FullMethod, retry)
+			}
+		}
+		return handler(srv, ss)
+	}
+}
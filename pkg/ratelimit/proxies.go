@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Proxies are the addresses allowed to tell the ip of a client with
+// x-forwarded-for, anyone else can put anything in it
+type Proxies []*net.IPNet
+
+// TrustedProxies are used by Client, set from the flags of the server
+var TrustedProxies Proxies
+
+// ParseProxies takes a csv list of ips and cidrs
+func ParseProxies(csv string) (Proxies, error) {
+	out := Proxies{}
+	for _, v := range strings.Split(csv, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			if strings.Contains(v, ":") {
+				v += "/128"
+			} else {
+				v += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("bad trusted proxy %q: %s", v, err.Error())
+		}
+		out = append(out, network)
+	}
+	return out, nil
+}
+
+func (p Proxies) trusts(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the ip of the client that sent the request to remote.
+// Every proxy appends the address it got the request from to
+// x-forwarded-for, so it is walked from the end and only while the hop is
+// a trusted proxy.
+func (p Proxies) ClientIP(remote string, forwarded []string) string {
+	hops := splitHops(forwarded)
+	ip := remote
+	for i := len(hops) - 1; i >= 0 && p.trusts(ip); i-- {
+		ip = hops[i]
+	}
+	return ip
+}
+
+func splitHops(forwarded []string) []string {
+	hops := []string{}
+	for _, v := range forwarded {
+		for _, hop := range strings.Split(v, ",") {
+			hop = strings.TrimSpace(hop)
+			if hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+// host of a host:port address
+func host(addr string) string {
+	h, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return h
+}
+
+// RemoteIP is ClientIP for a host:port remote address
+func (p Proxies) RemoteIP(remoteAddr string, forwarded []string) string {
+	return p.ClientIP(host(remoteAddr), forwarded)
+}
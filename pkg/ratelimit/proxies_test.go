@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseProxies("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		remote    string
+		forwarded []string
+		expected  string
+	}{
+		// not from a proxy, whatever the client says
+		{"1.1.1.1", []string{"2.2.2.2"}, "1.1.1.1"},
+		{"192.168.1.1", nil, "192.168.1.1"},
+		{"192.168.1.1", []string{"2.2.2.2"}, "2.2.2.2"},
+		// the client sent 3.3.3.3 to the proxy
+		{"10.0.0.1", []string{"3.3.3.3, 2.2.2.2"}, "2.2.2.2"},
+		// two proxies
+		{"10.0.0.1", []string{"3.3.3.3, 2.2.2.2", "192.168.1.1"}, "2.2.2.2"},
+		{"10.0.0.1", []string{"10.0.0.2"}, "10.0.0.2"},
+	}
+	for _, c := range cases {
+		if got := proxies.ClientIP(c.remote, c.forwarded); got != c.expected {
+			t.Fatalf("%s %v: expected %s got %s", c.remote, c.forwarded, c.expected, got)
+		}
+	}
+
+	_, err = ParseProxies("10.0.0.0/99")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestClientFromGateway(t *testing.T) {
+	call := func(from string, forwarded ...string) string {
+		addr, err := net.ResolveTCPAddr("tcp", from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+		if len(forwarded) > 0 {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", forwarded[0]))
+		}
+		return Client(ctx)
+	}
+
+	// the gateway appends the address of the http client last, what the
+	// client sent before it does not matter
+	if got := call("127.0.0.1:5000", "6.6.6.6, 2.2.2.2"); got != "ip:2.2.2.2" {
+		t.Fatalf("expected ip:2.2.2.2 got %s", got)
+	}
+	if got := call("127.0.0.1:5000", "7.7.7.7, 2.2.2.2"); got != "ip:2.2.2.2" {
+		t.Fatalf("expected ip:2.2.2.2 got %s", got)
+	}
+	if got := call("1.1.1.1:5000", "2.2.2.2"); got != "ip:1.1.1.1" {
+		t.Fatalf("expected ip:1.1.1.1 got %s", got)
+	}
+
+	TrustedProxies, _ = ParseProxies("10.0.0.1")
+	defer func() { TrustedProxies = nil }()
+	if got := call("127.0.0.1:5000", "6.6.6.6, 2.2.2.2, 10.0.0.1"); got != "ip:2.2.2.2" {
+		t.Fatalf("expected ip:2.2.2.2 got %s", got)
+	}
+}
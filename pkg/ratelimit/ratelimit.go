@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket per client, a bucket holds up to burst
+// tokens and refills at rate tokens per second
+type Limiter struct {
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+
+	lastCleanup time.Time
+	sync.Mutex
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New returns nil when rate is 0, a nil Limiter allows everything
+func New(rate float64, burst int) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = int(math.Ceil(rate))
+	}
+	return &Limiter{rate: rate, burst: float64(burst), buckets: map[string]*bucket{}, lastCleanup: time.Now()}
+}
+
+// AllowN takes n tokens from the bucket of the client, when there are not
+// enough it takes nothing and returns how long until there are
+func (l *Limiter) AllowN(client string, n int, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	l.cleanup(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	need := float64(n)
+	if b.tokens >= need {
+		b.tokens -= need
+		return true, 0
+	}
+	if need > l.burst {
+		// never enough, waiting does not help
+		return false, 0
+	}
+	return false, time.Duration((need - b.tokens) / l.rate * float64(time.Second))
+}
+
+func (l *Limiter) Allow(client string) (bool, time.Duration) {
+	return l.AllowN(client, 1, time.Now())
+}
+
+// full buckets are the same as no bucket, drop them once a minute so
+// clients that went away do not use memory
+func (l *Limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < time.Minute {
+		return
+	}
+	l.lastCleanup = now
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
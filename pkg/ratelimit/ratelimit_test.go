@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rekki/blackrock/pkg/auth"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLimiter(t *testing.T) {
+	l := New(10, 5)
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		ok, _ := l.AllowN("a", 1, now)
+		if !ok {
+			t.Fatalf("expected %d to be allowed", i)
+		}
+	}
+	ok, retry := l.AllowN("a", 1, now)
+	if ok || retry != 100*time.Millisecond {
+		t.Fatalf("expected to wait 100ms, got %v %s", ok, retry)
+	}
+
+	// other clients have their own bucket
+	ok, _ = l.AllowN("b", 5, now)
+	if !ok {
+		t.Fatal("expected b to be allowed")
+	}
+
+	ok, _ = l.AllowN("a", 2, now.Add(200*time.Millisecond))
+	if !ok {
+		t.Fatal("expected a to be refilled")
+	}
+
+	ok, retry = l.AllowN("a", 6, now.Add(time.Hour))
+	if ok || retry != 0 {
+		t.Fatalf("more than the burst never passes, got %v %s", ok, retry)
+	}
+
+	var disabled *Limiter
+	ok, _ = disabled.AllowN("a", 1000, now)
+	if !ok || New(0, 10) != nil {
+		t.Fatal("expected no limit")
+	}
+
+	l.AllowN("c", 1, now)
+	l.AllowN("a", 1, now.Add(2*time.Hour))
+	if len(l.buckets) != 1 {
+		t.Fatalf("expected the full buckets to be dropped, got %d", len(l.buckets))
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	keys, err := auth.Parse([]byte(`{"keys": [{"key": "abc"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := keys.Authenticate("Bearer abc", auth.Request{}, time.Now())
+	ctx := auth.NewContext(context.Background(), key)
+
+	interceptor := UnaryServerInterceptor(New(0.001, 1), map[string]bool{"/q": true})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/q"}, handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/q"}, handler)
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+	if len(st.Details()) != 1 || st.Details()[0].(*errdetails.RetryInfo).RetryDelay.Seconds < 900 {
+		t.Fatalf("expected retry info, got %v", st.Details())
+	}
+	// other methods are not limited
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/other"}, handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
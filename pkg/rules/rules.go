@@ -0,0 +1,234 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+	"github.com/rekki/blackrock/pkg/depths"
+	"github.com/rekki/blackrock/pkg/index"
+	dsl "github.com/rekki/go-query-index-dsl"
+)
+
+// Rules shape envelopes before they are indexed, they are applied in order
+// and every rule whose conditions match runs its actions, example:
+//
+//	{
+//	  "rules": [
+//	    {"event_types": ["debug"], "drop": true},
+//	    {"when": {"field": "env", "value": "test"}, "drop": true},
+//	    {"rename": {"user": "user_id"}, "drop_keys": ["password"]},
+//	    {"move": [{"key": "price", "from": "search", "to": "properties"}]},
+//	    {"event_types": ["pageview"], "sample": 0.1},
+//	    {"event_types": ["payment"], "route": "payments"}
+//	  ]
+//	}
+type Rules struct {
+	Rules []*Rule
+}
+
+// Rule runs when the event type is in EventTypes (if any) and the envelope
+// matches When (if any). Actions run in this order: drop, sample, rename,
+// drop keys, move, route. Sample keeps that fraction of the foreign ids,
+// 0 means no sampling. The last matching route wins.
+type Rule struct {
+	When       *dsl.Query
+	EventTypes map[string]bool
+	Drop       bool
+	Sample     float64
+	Rename     map[string]string
+	DropKeys   map[string]bool
+	Move       []Move
+	Route      string
+}
+
+type Move struct {
+	Key  string `json:"key"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type ruleJSON struct {
+	When       json.RawMessage   `json:"when"`
+	EventTypes []string          `json:"event_types"`
+	Drop       bool              `json:"drop"`
+	Sample     float64           `json:"sample"`
+	Rename     map[string]string `json:"rename"`
+	DropKeys   []string          `json:"drop_keys"`
+	Move       []Move            `json:"move"`
+	Route      string            `json:"route"`
+}
+
+type rulesJSON struct {
+	Rules []ruleJSON `json:"rules"`
+}
+
+func Load(fn string) (*Rules, error) {
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	r, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", fn, err.Error())
+	}
+	return r, nil
+}
+
+func Parse(data []byte) (*Rules, error) {
+	in := rulesJSON{}
+	err := json.Unmarshal(data, &in)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Rules{}
+	for i, rj := range in.Rules {
+		r := &Rule{Drop: rj.Drop, Sample: rj.Sample, Rename: rj.Rename, Move: rj.Move, Route: rj.Route}
+		if len(rj.When) > 0 {
+			r.When, err = dsl.QueryFromBytes(rj.When)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: bad query: %s", i, err.Error())
+			}
+			_, err = index.Matches(r.When, &spec.Metadata{})
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: bad query: %s", i, err.Error())
+			}
+		}
+		if len(rj.EventTypes) > 0 {
+			r.EventTypes = map[string]bool{}
+			for _, t := range rj.EventTypes {
+				r.EventTypes[t] = true
+			}
+		}
+		if len(rj.DropKeys) > 0 {
+			r.DropKeys = map[string]bool{}
+			for _, k := range rj.DropKeys {
+				r.DropKeys[k] = true
+			}
+		}
+		if r.Sample < 0 || r.Sample > 1 {
+			return nil, fmt.Errorf("rule %d: sample must be between 0 and 1, got %f", i, r.Sample)
+		}
+		for _, m := range r.Move {
+			if m.Key == "" || !validSection(m.From) || !validSection(m.To) {
+				return nil, fmt.Errorf("rule %d: bad move %v, sections are search, count and properties", i, m)
+			}
+		}
+		out.Rules = append(out.Rules, r)
+	}
+	return out, nil
+}
+
+// Routes returns the routes used by the rules
+func (r *Rules) Routes() []string {
+	out := []string{}
+	for _, rule := range r.Rules {
+		if rule.Route != "" {
+			out = append(out, rule.Route)
+		}
+	}
+	return out
+}
+
+// Apply modifies the metadata in place, returns false if the envelope has
+// to be dropped, and the route if any rule routed it
+func (r *Rules) Apply(meta *spec.Metadata) (bool, string, error) {
+	route := ""
+	for _, rule := range r.Rules {
+		ok, err := rule.matches(meta)
+		if err != nil {
+			return false, "", err
+		}
+		if !ok {
+			continue
+		}
+
+		if rule.Drop {
+			return false, "", nil
+		}
+		if rule.Sample > 0 && !sampled(meta.ForeignId, rule.Sample) {
+			return false, "", nil
+		}
+
+		for _, name := range sections {
+			kvs := section(meta, name)
+			*kvs = rule.transform(*kvs)
+		}
+
+		for _, m := range rule.Move {
+			from := section(meta, m.From)
+			to := section(meta, m.To)
+			keep := []spec.KV{}
+			for _, kv := range *from {
+				if kv.Key == m.Key {
+					*to = append(*to, kv)
+				} else {
+					keep = append(keep, kv)
+				}
+			}
+			*from = keep
+		}
+
+		if rule.Route != "" {
+			route = rule.Route
+		}
+	}
+	return true, route, nil
+}
+
+func (rule *Rule) matches(meta *spec.Metadata) (bool, error) {
+	if rule.EventTypes != nil && !rule.EventTypes[meta.EventType] {
+		return false, nil
+	}
+	if rule.When != nil {
+		return index.Matches(rule.When, meta)
+	}
+	return true, nil
+}
+
+func (rule *Rule) transform(kvs []spec.KV) []spec.KV {
+	if len(rule.Rename) == 0 && len(rule.DropKeys) == 0 {
+		return kvs
+	}
+
+	out := []spec.KV{}
+	for _, kv := range kvs {
+		if to, ok := rule.Rename[kv.Key]; ok {
+			kv.Key = to
+		}
+		if rule.DropKeys[kv.Key] {
+			continue
+		}
+		out = append(out, kv)
+	}
+	return out
+}
+
+// the same foreign id is always kept or always dropped
+func sampled(foreignId string, fraction float64) bool {
+	return depths.Hashs(foreignId)%10000 < uint64(fraction*10000)
+}
+
+var sections = []string{"search", "count", "properties"}
+
+func validSection(name string) bool {
+	for _, s := range sections {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+func section(meta *spec.Metadata, name string) *[]spec.KV {
+	switch name {
+	case "search":
+		return &meta.Search
+	case "count":
+		return &meta.Count
+	default:
+		return &meta.Properties
+	}
+}
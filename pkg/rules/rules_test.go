@@ -0,0 +1,86 @@
+package rules
+
+import (
+	"fmt"
+	"testing"
+
+	spec "github.com/rekki/blackrock/pkg/blackrock_io"
+)
+
+func envelope(eventType string, foreignId string, search ...string) *spec.Metadata {
+	meta := &spec.Metadata{EventType: eventType, ForeignType: "user", ForeignId: foreignId}
+	for i := 0; i < len(search); i += 2 {
+		meta.Search = append(meta.Search, spec.KV{Key: search[i], Value: search[i+1]})
+	}
+	return meta
+}
+
+func TestRules(t *testing.T) {
+	r, err := Parse([]byte(`{
+  "rules": [
+    {"event_types": ["debug"], "drop": true},
+    {"when": {"type": "AND", "queries": [{"field": "env", "value": "test"}, {"field": "user", "value": "bob"}]}, "drop": true},
+    {"rename": {"user": "user_id"}, "drop_keys": ["password"]},
+    {"move": [{"key": "price", "from": "search", "to": "properties"}]},
+    {"event_types": ["pageview"], "sample": 0.5},
+    {"event_types": ["payment"], "route": "payments"}
+  ]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keep, _, err := r.Apply(envelope("debug", "a"))
+	if err != nil || keep {
+		t.Fatalf("expected debug to be dropped, %v %v", keep, err)
+	}
+	keep, _, err = r.Apply(envelope("click", "bob", "env", "test"))
+	if err != nil || keep {
+		t.Fatalf("expected test env to be dropped, %v %v", keep, err)
+	}
+
+	meta := envelope("payment", "a", "env", "prod", "user", "x", "password", "p", "price", "10")
+	keep, route, err := r.Apply(meta)
+	if err != nil || !keep || route != "payments" {
+		t.Fatalf("expected payment to be routed, %v %v %v", keep, route, err)
+	}
+	if len(meta.Search) != 2 || meta.Search[0].Key != "env" || meta.Search[1].Key != "user_id" {
+		t.Fatalf("unexpected search %v", meta.Search)
+	}
+	if len(meta.Properties) != 1 || meta.Properties[0].Key != "price" || meta.Properties[0].Value != "10" {
+		t.Fatalf("unexpected properties %v", meta.Properties)
+	}
+
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("user_%d", i)
+		a, _, _ := r.Apply(envelope("pageview", id))
+		b, _, _ := r.Apply(envelope("pageview", id))
+		if a != b {
+			t.Fatalf("sampling of %s is not stable", id)
+		}
+		if a {
+			kept++
+		}
+	}
+	if kept < 400 || kept > 600 {
+		t.Fatalf("expected about half sampled, got %d", kept)
+	}
+
+	if routes := r.Routes(); len(routes) != 1 || routes[0] != "payments" {
+		t.Fatalf("unexpected routes %v", routes)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, bad := range []string{
+		`{"rules": [{"sample": 2}]}`,
+		`{"rules": [{"move": [{"key": "a", "from": "search", "to": "nowhere"}]}]}`,
+		`{"rules": [{"when": {"field": "a", "value": "b", "queries": [{"field": "c", "value": "d"}]}}]}`,
+	} {
+		_, err := Parse([]byte(bad))
+		if err == nil {
+			t.Fatalf("expected error for %s", bad)
+		}
+	}
+}